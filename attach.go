@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// attachment is a file attached to a package, or to a test if test is set.
+type attachment struct {
+	pkg, test, path string
+}
+
+// attachFlag is a repeatable flag of package=path or package:test=path pairs.
+type attachFlag []attachment
+
+func (a *attachFlag) String() string {
+	var pairs []string
+	for _, att := range *a {
+		target := att.pkg
+		if att.test != "" {
+			target += ":" + att.test
+		}
+		pairs = append(pairs, target+"="+att.path)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (a *attachFlag) Set(value string) error {
+	idx := strings.Index(value, "=")
+	if idx < 1 || idx == len(value)-1 {
+		return errors.New("attachment must be of the form package=path or package:test=path")
+	}
+	att := attachment{pkg: value[:idx], path: value[idx+1:]}
+	if i := strings.Index(att.pkg, ":"); i > -1 {
+		att.pkg, att.test = att.pkg[:i], att.pkg[i+1:]
+	}
+	*a = append(*a, att)
+	return nil
+}
+
+// addAttachments collects the attachment markers in the output of report and
+// adds the attachments given on the command line. Attachments of packages or
+// tests that aren't in the report are ignored.
+func addAttachments(report *parser.Report, attachments []attachment) error {
+	if err := report.CollectAttachments(); err != nil {
+		return err
+	}
+	for _, att := range attachments {
+		report.Attach(att.pkg, att.test, att.path)
+	}
+	return nil
+}
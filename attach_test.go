@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestAttachFlag(t *testing.T) {
+	var attachments attachFlag
+	for _, value := range []string{"example.com/a=/logs/a.log", "example.com/a:TestB/c=b.png"} {
+		if err := attachments.Set(value); err != nil {
+			t.Fatalf("Set(%q) returned error: %s", value, err)
+		}
+	}
+	for _, value := range []string{"", "example.com/a", "=a.log", "example.com/a="} {
+		if err := attachments.Set(value); err == nil {
+			t.Errorf("Set(%q) did not return an error", value)
+		}
+	}
+
+	want := attachFlag{
+		{pkg: "example.com/a", path: "/logs/a.log"},
+		{pkg: "example.com/a", test: "TestB/c", path: "b.png"},
+	}
+	if !reflect.DeepEqual(attachments, want) {
+		t.Errorf("attachments == %v, want %v", attachments, want)
+	}
+
+	report := &parser.Report{Packages: []parser.Package{{
+		Name: "example.com/a",
+		Tests: []*parser.Test{
+			{Name: "TestB/c", Output: []string{"[[ATTACHMENT|c.txt]]"}},
+		},
+	}}}
+	if err := addAttachments(report, attachments); err != nil {
+		t.Fatal(err)
+	}
+	pkg := report.Packages[0]
+	if !reflect.DeepEqual(pkg.Attachments, []string{"/logs/a.log"}) || !reflect.DeepEqual(pkg.Tests[0].Attachments, []string{"c.txt", "b.png"}) {
+		t.Errorf("unexpected attachments: package %v, test %v", pkg.Attachments, pkg.Tests[0].Attachments)
+	}
+}
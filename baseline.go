@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// missingErrorType is the error type of the testcases added for tests of the
+// baseline that have no result.
+const missingErrorType = "missing"
+
+var (
+	// regexListName matches the names of tests, examples and fuzz tests
+	// printed by go test -list. Benchmarks are listed as well, but only run
+	// with -bench.
+	regexListName = regexp.MustCompile(`^(?:Test|Example|Fuzz)\w*$`)
+	// regexListResult matches the result line that follows the tests of a
+	// package in go test -list output.
+	regexListResult = regexp.MustCompile(`^(?:ok|FAIL|\?)\s+(\S+)`)
+)
+
+// baselineTest is a test of a package that ran in the baseline.
+type baselineTest struct {
+	pkg, name string
+}
+
+// readBaseline reads the tests of the JUnit XML or JSON report, or of the go
+// test -list output, in filename. The tests of a report were filtered and
+// renamed when it was written, listed tests are filtered and renamed like the
+// current report, see listedTests. Tests of a report with result ERROR are
+// left out, they stand for a failed package, a test that didn't finish or a
+// missing result rather than a test that ran.
+func readBaseline(filename string) ([]baselineTest, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := maybeGunzip(f)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	start, _ := br.Peek(512)
+	if start = bytes.TrimSpace(start); !bytes.HasPrefix(start, []byte("<")) && !bytes.HasPrefix(start, []byte("{")) {
+		listed, err := parseTestList(br, *packageName)
+		if err != nil {
+			return nil, err
+		}
+		return listedTests(listed)
+	}
+
+	report, err := readReport(filename)
+	if err != nil {
+		return nil, err
+	}
+	var tests []baselineTest
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.Tests {
+			if test.Result != parser.ERROR {
+				tests = append(tests, baselineTest{pkg.Name, test.Name})
+			}
+		}
+	}
+	return tests, nil
+}
+
+// listedTests filters and renames the tests listed by go test -list like the
+// tests of the report, see transformReport.
+func listedTests(listed []baselineTest) ([]baselineTest, error) {
+	report := &parser.Report{}
+	for _, test := range listed {
+		pkg := findPackage(report, test.pkg)
+		if pkg == nil {
+			report.Packages = append(report.Packages, parser.Package{Name: test.pkg})
+			pkg = &report.Packages[len(report.Packages)-1]
+		}
+		pkg.Tests = append(pkg.Tests, &parser.Test{Name: test.name})
+	}
+	if err := report.FilterPackages(includePackages, excludePackages); err != nil {
+		return nil, fmt.Errorf("in package filter: %s", err)
+	}
+	if len(includeTests) > 0 || len(excludeTests) > 0 {
+		report.FilterTests(testFilter(includeTests, excludeTests, false))
+	}
+	if err := renameReport(report); err != nil {
+		return nil, err
+	}
+
+	var tests []baselineTest
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.Tests {
+			tests = append(tests, baselineTest{pkg.Name, test.Name})
+		}
+	}
+	return tests, nil
+}
+
+// parseTestList returns the tests listed in the go test -list output read
+// from r. Tests that are not followed by the result line of their package
+// belong to package pkgName.
+func parseTestList(r io.Reader, pkgName string) ([]baselineTest, error) {
+	var tests []baselineTest
+	var names []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if regexListName.MatchString(line) {
+			names = append(names, line)
+		} else if matches := regexListResult.FindStringSubmatch(line); matches != nil {
+			for _, name := range names {
+				tests = append(tests, baselineTest{matches[1], name})
+			}
+			names = nil
+		}
+	}
+	for _, name := range names {
+		tests = append(tests, baselineTest{pkgName, name})
+	}
+	return tests, s.Err()
+}
+
+// missingTests returns the tests of baseline that have no result in report.
+// Packages that failed to build are left out, their build error already
+// explains why their tests didn't run.
+func missingTests(report *parser.Report, baseline []baselineTest) []baselineTest {
+	found := map[baselineTest]bool{}
+	failed := map[string]bool{}
+	for _, pkg := range report.Packages {
+		if pkg.BuildError != nil {
+			failed[pkg.Name] = true
+		}
+		for _, test := range pkg.Tests {
+			found[baselineTest{pkg.Name, test.Name}] = true
+		}
+	}
+
+	var missing []baselineTest
+	for _, test := range baseline {
+		if !found[test] && !failed[test.pkg] {
+			missing = append(missing, test)
+			found[test] = true
+		}
+	}
+	return missing
+}
+
+// ranTests returns a copy of the packages of report with only the names of
+// their tests and their build errors. The tests that ran are compared with the
+// baseline after report was filtered and renamed, see markMissingTests.
+func ranTests(report *parser.Report) *parser.Report {
+	ran := &parser.Report{}
+	for _, pkg := range report.Packages {
+		names := parser.Package{Name: pkg.Name, BuildError: pkg.BuildError}
+		for _, test := range pkg.Tests {
+			names.Tests = append(names.Tests, &parser.Test{Name: test.Name})
+		}
+		ran.Packages = append(ran.Packages, names)
+	}
+	return ran
+}
+
+// markMissingTests adds the tests of the baseline in filename that have no
+// result in report to it as errors of type missing, and returns their number.
+// Report has been filtered and renamed, ran is the copy ranTests made before,
+// which is renamed here, so tests that were filtered out aren't missing.
+func markMissingTests(report, ran *parser.Report, filename string) (int, error) {
+	baseline, err := readBaseline(filename)
+	if err != nil {
+		return 0, err
+	}
+	if err := renameReport(ran); err != nil {
+		return 0, err
+	}
+
+	all := &parser.Report{Packages: append(append([]parser.Package{}, ran.Packages...), report.Packages...)}
+	missing := missingTests(all, baseline)
+	for _, m := range missing {
+		pkg := findPackage(report, m.pkg)
+		if pkg == nil {
+			report.Packages = append(report.Packages, parser.Package{Name: m.pkg})
+			pkg = &report.Packages[len(report.Packages)-1]
+		}
+		pkg.Tests = append(pkg.Tests, &parser.Test{
+			Name:      m.name,
+			Result:    parser.ERROR,
+			ErrorType: missingErrorType,
+			Output:    []string{fmt.Sprintf("%s ran in the baseline %s, but has no result", m.name, filename)},
+		})
+	}
+	if len(missing) > 0 {
+		report.LinkSubtests()
+	}
+	return len(missing), nil
+}
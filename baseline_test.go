@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestParseTestList(t *testing.T) {
+	in := `TestA
+TestB
+BenchmarkC
+ExampleD
+ok  	example.com/a	0.005s
+FuzzE
+?   	example.com/b	[no test files]
+TestF
+`
+	tests, err := parseTestList(strings.NewReader(in), "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []baselineTest{
+		{"example.com/a", "TestA"},
+		{"example.com/a", "TestB"},
+		{"example.com/a", "ExampleD"},
+		{"example.com/b", "FuzzE"},
+		{"main", "TestF"},
+	}
+	if !reflect.DeepEqual(tests, want) {
+		t.Errorf("parseTestList() == %v, want %v", tests, want)
+	}
+}
+
+func TestMarkMissingTests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	baseline := &parser.Report{Packages: []parser.Package{
+		{Name: "example.com/a", Tests: []*parser.Test{
+			{Name: "TestA", Result: parser.PASS},
+			{Name: "TestB", Result: parser.PASS},
+			{Name: "TestB/sub", Result: parser.PASS},
+		}},
+		{Name: "example.com/b", Tests: []*parser.Test{{Name: "TestC", Result: parser.PASS}}},
+		{Name: "example.com/c", Tests: []*parser.Test{{Name: "TestD", Result: parser.PASS}}},
+	}}
+	var buf bytes.Buffer
+	if err := formatter.WriteJUnitXML(baseline, formatter.Options{}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "baseline.xml")
+	if err := ioutil.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report := &parser.Report{Packages: []parser.Package{
+		{Name: "example.com/a", Tests: []*parser.Test{
+			{Name: "TestA", Result: parser.PASS},
+			{Name: "TestB", Result: parser.PASS},
+		}},
+		{Name: "example.com/c", BuildError: &parser.BuildError{Name: "[build failed]"}},
+	}}
+	n, err := markMissingTests(report, ranTests(report), file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("markMissingTests() == %d, want 2", n)
+	}
+
+	var missing []string
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.Tests {
+			if test.ErrorType == missingErrorType && test.Result == parser.ERROR {
+				missing = append(missing, pkg.Name+" "+test.Name)
+			}
+		}
+	}
+	want := []string{"example.com/a TestB/sub", "example.com/b TestC"}
+	if !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing tests == %v, want %v", missing, want)
+	}
+	if sub := report.Packages[0].Tests[2]; sub.Parent != report.Packages[0].Tests[1] {
+		t.Errorf("missing subtest is not linked to TestB")
+	}
+}
+
+func TestMarkMissingTestsRenamed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(prefix string) { *trimPrefix = prefix }(*trimPrefix)
+	*trimPrefix = "example.com/"
+
+	// written by an earlier run with -trim-prefix
+	earlier := &parser.Report{Packages: []parser.Package{
+		{Name: "example.com/a", Tests: []*parser.Test{
+			{Name: "TestA", Result: parser.PASS},
+			{Name: "TestB", Result: parser.PASS},
+			{Name: "Error", Result: parser.ERROR},
+			{Name: stalledTestName, Result: parser.ERROR, ErrorType: stalledErrorType},
+		}},
+	}}
+	if err := renameReport(earlier); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := formatter.WriteJUnitXML(earlier, formatter.Options{}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "baseline.xml")
+	if err := ioutil.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report := &parser.Report{Packages: []parser.Package{
+		{Name: "example.com/a", Tests: []*parser.Test{{Name: "TestA", Result: parser.PASS}}},
+	}}
+	ran := ranTests(report)
+	if err := renameReport(report); err != nil {
+		t.Fatal(err)
+	}
+	n, err := markMissingTests(report, ran, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tests := report.Packages[0].Tests; n != 1 || len(report.Packages) != 1 || tests[len(tests)-1].Name != "TestB" {
+		t.Errorf("markMissingTests() == %d, want only TestB of package a missing: %+v", n, report.Packages)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hexon/go-junit-report/formatter"
+)
+
+// batchExtensions are the extensions of the test output files converted in
+// batch mode.
+var batchExtensions = []string{".txt", ".log"}
+
+// formatExtensions are the extensions of report files written in batch mode
+// or to -out-dir and -module-out-dir, by format. Other formats are written to
+// .txt files, see formatExtension.
+var formatExtensions = map[string]string{
+	"junit":      ".xml",
+	"json":       ".json",
+	"buildkite":  ".json",
+	"otlp":       ".json",
+	"slowest":    ".md",
+	"prometheus": ".prom",
+}
+
+// batchFiles returns the paths relative to dir of all test output files in
+// the tree rooted at dir.
+func batchFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		for _, ext := range batchExtensions {
+			if filepath.Ext(path) == ext {
+				rel, err := filepath.Rel(dir, path)
+				if err != nil {
+					return err
+				}
+				files = append(files, rel)
+				break
+			}
+		}
+		return nil
+	})
+	return files, err
+}
+
+// batchOutputName returns the name of the report file in outDir for the test
+// output file rel, written in the given format.
+func batchOutputName(outDir, rel, format string) string {
+	return filepath.Join(outDir, strings.TrimSuffix(rel, filepath.Ext(rel))+formatExtension(format))
+}
+
+// formatExtension returns the extension of report files written in format.
+func formatExtension(format string) string {
+	if ext, ok := formatExtensions[format]; ok {
+		return ext
+	}
+	return ".txt"
+}
+
+// runBatch converts every test output file in the tree rooted at dir to a
+// report with the same relative path in outDir. It reports whether any of the
+// reports contains failed tests.
+func runBatch(dir, outDir string) (bool, error) {
+	if outDir == "" {
+		return false, errors.New("-batch requires -out-dir")
+	}
+	files, err := batchFiles(dir)
+	if err != nil {
+		return false, err
+	}
+	opts, err := formatOptions()
+	if err != nil {
+		return false, err
+	}
+	f, err := formatter.New(*format, opts)
+	if err != nil {
+		return false, err
+	}
+
+	failed := false
+	for _, rel := range files {
+		out := batchOutputName(outDir, rel, *format)
+		failures, err := batchFile(f, filepath.Join(dir, rel), out)
+		if err != nil {
+			return failed, fmt.Errorf("%s: %s", rel, err)
+		}
+		failed = failed || failures > 0
+	}
+	return failed, nil
+}
+
+// batchFile converts the test output in filename to a report written to out
+// and returns the number of failed tests.
+func batchFile(f formatter.Formatter, filename, out string) (int, error) {
+	in, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	report, err := parseInput(in)
+	if spillDir != "" {
+		defer func() {
+			os.RemoveAll(spillDir)
+			spillDir = ""
+		}()
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := transformReport(report); err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return 0, err
+	}
+	if err := writeReport(f, report, out); err != nil {
+		return 0, err
+	}
+	return report.Failures(), nil
+}
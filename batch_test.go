@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hexon/go-junit-report/formatter"
+)
+
+func TestBatchFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"a.txt", "b.md", "sub/c.log", "sub/deeper/d.txt"} {
+		path := filepath.Join(dir, "logs", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("=== RUN   TestOne\n--- FAIL: TestOne (0.01s)\nFAIL\tpkg\t0.01s\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := batchFiles(filepath.Join(dir, "logs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.txt", filepath.Join("sub", "c.log"), filepath.Join("sub", "deeper", "d.txt")}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("batchFiles() == %v, want %v", files, want)
+	}
+
+	f, err := formatter.New("junit", formatter.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := batchOutputName(filepath.Join(dir, "reports"), files[2], "junit")
+	if want := filepath.Join(dir, "reports", "sub", "deeper", "d.xml"); out != want {
+		t.Errorf("batchOutputName() == %q, want %q", out, want)
+	}
+	failures, err := batchFile(f, filepath.Join(dir, "logs", files[2]), out)
+	if err != nil {
+		t.Fatalf("batchFile: %s", err)
+	}
+	if failures != 1 {
+		t.Errorf("batchFile() == %d failures, want 1", failures)
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Errorf("report not written: %s", err)
+	}
+}
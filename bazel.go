@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+var (
+	// regexBazelExec and regexBazelExecuting match the header of the
+	// test.log files Bazel writes for every test target, which is followed
+	// by a line of dashes.
+	regexBazelExec      = regexp.MustCompile(`^exec \$\{PAGER:-[^}]*\} "\$0" \|\| exit 1$`)
+	regexBazelExecuting = regexp.MustCompile(`^Executing tests from ((?://|@)\S+)$`)
+	// regexBazelOutput matches the line starting the output of a test
+	// target printed by bazel test --test_output, e.g. "==== Test output for
+	// //pkg:foo_test (shard 1 of 2):". A line of equal signs ends it.
+	regexBazelOutput = regexp.MustCompile(`^=+ Test output for ((?://|@)\S+?)(?: \(([^)]*)\))?:$`)
+	regexBazelEnd    = regexp.MustCompile(`^={20,}$`)
+	regexBazelRule   = regexp.MustCompile(`^-{20,}$`)
+	// regexBazelSummary matches the result of a test target in the summary
+	// of bazel test, e.g. "//pkg:foo_test    PASSED in 0.4s".
+	regexBazelSummary = regexp.MustCompile(`^((?://|@)\S+)\s+(?:\(cached\) )?(?:PASSED|FAILED|FLAKY|TIMEOUT)\b.*\bin ([\d.]+)s$`)
+	// regexBazelAttempt matches the shard, run or attempt in the header of
+	// the output of a test target.
+	regexBazelAttempt = regexp.MustCompile(`\b(shard|run|attempt) (\d+) of \d+`)
+)
+
+// bazelSection is the go test output of a single run of a Bazel test target.
+type bazelSection struct {
+	target  string
+	key     string
+	attempt bool
+	output  strings.Builder
+}
+
+// parseBazel parses the output of bazel test, or test.log files written by
+// it, read from r, for -bazel. Bazel's framing is removed and the output of
+// every test target is parsed with opts, named after the target. The output
+// of shards and runs of a target is merged. Of repeated attempts of a flaky
+// target only the last is reported, with the number of attempts as
+// bazel.attempts property.
+func parseBazel(r io.Reader, opts []parser.Option) (*parser.Report, error) {
+	var sections []*bazelSection
+	var cur *bazelSection
+	durations := map[string]time.Duration{}
+	framed := false
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if line == "" && err == io.EOF {
+			break
+		}
+		plain := strings.TrimRight(line, "\r\n")
+
+		if regexBazelExec.MatchString(plain) {
+			framed, cur = true, nil
+		} else if matches := regexBazelExecuting.FindStringSubmatch(plain); matches != nil && cur == nil {
+			cur = &bazelSection{target: matches[1], key: matches[1]}
+			sections = append(sections, cur)
+		} else if matches := regexBazelOutput.FindStringSubmatch(plain); matches != nil {
+			framed = true
+			cur = &bazelSection{target: matches[1], key: matches[1]}
+			for _, m := range regexBazelAttempt.FindAllStringSubmatch(matches[2], -1) {
+				if m[1] == "attempt" {
+					cur.attempt = true
+				} else {
+					cur.key += " " + m[0]
+				}
+			}
+			sections = append(sections, cur)
+		} else if cur != nil && regexBazelEnd.MatchString(plain) {
+			cur = nil
+		} else if cur != nil && cur.output.Len() == 0 && regexBazelRule.MatchString(plain) {
+			// the line below the header of test.log
+		} else if cur != nil {
+			cur.output.WriteString(line)
+		} else if matches := regexBazelSummary.FindStringSubmatch(plain); matches != nil {
+			seconds, _ := strconv.ParseFloat(matches[2], 64)
+			durations[matches[1]] = time.Duration(seconds * float64(time.Second))
+		} else if !framed {
+			// output without framing, e.g. a single test.log without its
+			// header
+			cur = &bazelSection{}
+			sections = append(sections, cur)
+			cur.output.WriteString(line)
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return bazelReport(sections, durations, opts)
+}
+
+// bazelReport parses the output of sections and combines the reports of each
+// target.
+func bazelReport(sections []*bazelSection, durations map[string]time.Duration, opts []parser.Option) (*parser.Report, error) {
+	var keys, targets []string
+	byKey := map[string]*parser.Report{}
+	attempts := map[string]int{}
+	for _, s := range sections {
+		sectionOpts := opts
+		if s.target != "" {
+			sectionOpts = append(opts[:len(opts):len(opts)], parser.WithPackageName(s.target))
+		}
+		report, err := parser.ParseWithOptions(strings.NewReader(s.output.String()), sectionOpts...)
+		if err != nil {
+			return nil, err
+		}
+		if s.target != "" {
+			for i := range report.Packages {
+				report.Packages[i].Name = s.target
+			}
+		}
+
+		prev, ok := byKey[s.key]
+		switch {
+		case !ok:
+			keys = append(keys, s.key)
+			byKey[s.key] = report
+			attempts[s.key] = 1
+			if !containsString(targets, s.target) {
+				targets = append(targets, s.target)
+			}
+		case s.attempt || sharesTests(prev, report):
+			// the target was run again, e.g. with --flaky_test_attempts
+			byKey[s.key] = report
+			attempts[s.key]++
+		default:
+			// e.g. test.log files of shards, whose headers look the same
+			byKey[s.key] = parser.Merge(prev, report)
+		}
+	}
+
+	merged := &parser.Report{Packages: []parser.Package{}}
+	for _, target := range targets {
+		var reports []*parser.Report
+		n := 1
+		for _, key := range keys {
+			if key == target || strings.HasPrefix(key, target+" ") {
+				reports = append(reports, byKey[key])
+				if attempts[key] > n {
+					n = attempts[key]
+				}
+			}
+		}
+		report := parser.Merge(reports...)
+		for i := range report.Packages {
+			pkg := &report.Packages[i]
+			if d, ok := durations[target]; ok && pkg.Duration == 0 {
+				pkg.Duration = d
+				pkg.Time = int(d / time.Millisecond) // deprecated
+			}
+			if n > 1 {
+				pkg.Properties = append(pkg.Properties, parser.Property{Name: "bazel.attempts", Value: strconv.Itoa(n)})
+			}
+		}
+		merged.Packages = append(merged.Packages, report.Packages...)
+	}
+	return merged, nil
+}
+
+// sharesTests returns whether the reports a and b contain a test with the same
+// name in a package with the same name.
+func sharesTests(a, b *parser.Report) bool {
+	names := map[string]bool{}
+	for _, pkg := range a.Packages {
+		for _, test := range pkg.Tests {
+			names[pkg.Name+"\x00"+test.Name] = true
+		}
+	}
+	for _, pkg := range b.Packages {
+		for _, test := range pkg.Tests {
+			if names[pkg.Name+"\x00"+test.Name] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestParseBazel(t *testing.T) {
+	input := `exec ${PAGER:-/usr/bin/less} "$0" || exit 1
+Executing tests from //pkg/foo:foo_test
+-----------------------------------------------------------------------------
+=== RUN   TestA
+--- PASS: TestA (0.00s)
+=== RUN   TestB
+    foo_test.go:12: flaky
+--- FAIL: TestB (0.01s)
+FAIL
+exec ${PAGER:-/usr/bin/less} "$0" || exit 1
+Executing tests from //pkg/foo:foo_test
+-----------------------------------------------------------------------------
+=== RUN   TestA
+--- PASS: TestA (0.00s)
+=== RUN   TestB
+--- PASS: TestB (0.01s)
+PASS
+INFO: From Testing //pkg/bar:bar_test:
+==================== Test output for //pkg/bar:bar_test (shard 1 of 2):
+=== RUN   TestC
+--- PASS: TestC (0.00s)
+PASS
+================================================================================
+==================== Test output for //pkg/bar:bar_test (shard 2 of 2):
+=== RUN   TestD
+--- FAIL: TestD (0.00s)
+FAIL
+================================================================================
+//pkg/bar:bar_test                                                       FAILED in 0.3s
+`
+	report, err := parseBazel(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		pkg, test string
+		result    parser.Result
+	}
+	var got []result
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.Tests {
+			got = append(got, result{pkg.Name, test.Name, test.Result})
+		}
+	}
+	want := []result{
+		{"//pkg/foo:foo_test", "TestA", parser.PASS},
+		{"//pkg/foo:foo_test", "TestB", parser.PASS},
+		{"//pkg/bar:bar_test", "TestC", parser.PASS},
+		{"//pkg/bar:bar_test", "TestD", parser.FAIL},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseBazel() tests == %v, want %v", got, want)
+	}
+
+	wantProps := []parser.Property{{Name: "bazel.attempts", Value: "2"}}
+	if props := report.Packages[0].Properties; !reflect.DeepEqual(props, wantProps) {
+		t.Errorf("properties of %s == %v, want %v", report.Packages[0].Name, props, wantProps)
+	}
+	if d := report.Packages[1].Duration; d != 300*time.Millisecond {
+		t.Errorf("duration of %s == %s, want 300ms from the summary", report.Packages[1].Name, d)
+	}
+}
+
+func TestParseBazelUnframed(t *testing.T) {
+	input := "=== RUN   TestA\n--- PASS: TestA (0.00s)\nPASS\n"
+	report, err := parseBazel(strings.NewReader(input), []parser.Option{parser.WithPackageName("pkg")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Packages) != 1 || report.Packages[0].Name != "pkg" || len(report.Packages[0].Tests) != 1 {
+		t.Fatalf("parseBazel() == %+v, want a package with TestA", report.Packages)
+	}
+}
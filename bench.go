@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// benchRegression is a benchmark whose ns/op increased compared to the
+// baseline.
+type benchRegression struct {
+	test     *parser.Test
+	old, cur time.Duration
+}
+
+// percent returns the increase of the ns/op in percent.
+func (r benchRegression) percent() float64 {
+	return (float64(r.cur)/float64(r.old) - 1) * 100
+}
+
+// benchRegressions returns the benchmarks of report whose ns/op increased by
+// more than threshold percent compared to the benchmark with the same package
+// and name in baseline.
+func benchRegressions(report, baseline *parser.Report, threshold float64) []benchRegression {
+	old := map[string]time.Duration{}
+	for _, pkg := range baseline.Packages {
+		for _, test := range pkg.Tests {
+			if test.CPU > 0 {
+				old[pkg.Name+"\x00"+test.Name] = test.Duration
+			}
+		}
+	}
+
+	var regressions []benchRegression
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.Tests {
+			o, ok := old[pkg.Name+"\x00"+test.Name]
+			if test.CPU == 0 || !ok || o <= 0 {
+				continue
+			}
+			r := benchRegression{test, o, test.Duration}
+			if r.percent() > threshold {
+				regressions = append(regressions, r)
+			}
+		}
+	}
+	return regressions
+}
+
+// markBenchRegressions reports the benchmarks of report that regressed
+// compared to the go test -bench output in the -bench-baseline file as
+// failures, with the old and new ns/op added to their output. It returns the
+// number of regressed benchmarks.
+func markBenchRegressions(report *parser.Report, filename string, threshold float64) (int, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	baseline, err := parser.Parse(f, *packageName)
+	if err != nil {
+		return 0, err
+	}
+
+	regressions := benchRegressions(report, baseline, threshold)
+	for _, r := range regressions {
+		r.test.Result = parser.FAIL
+		r.test.Output = append(r.test.Output, fmt.Sprintf("ns/op regressed by %.1f%% compared to the baseline: %s -> %s",
+			r.percent(), formatNsPerOp(r.old), formatNsPerOp(r.cur)))
+	}
+	return len(regressions), nil
+}
+
+// formatNsPerOp formats the duration of a benchmark like go test does.
+func formatNsPerOp(d time.Duration) string {
+	if d < 100*time.Nanosecond {
+		return fmt.Sprintf("%.2f ns/op", float64(d))
+	}
+	return fmt.Sprintf("%d ns/op", int64(d))
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestBenchRegressions(t *testing.T) {
+	baseline, err := parser.Parse(strings.NewReader(`BenchmarkHash-4   	 1000000	      1000 ns/op
+BenchmarkSort-4   	  100000	     12000 ns/op
+BenchmarkTiny-4   	1000000000	         0.26 ns/op
+PASS
+ok  	pkg	3.000s
+`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := parser.Parse(strings.NewReader(`BenchmarkHash-4   	 1000000	      1101 ns/op
+BenchmarkSort-4   	  100000	     12500 ns/op
+BenchmarkTiny-4   	1000000000	         0.52 ns/op
+BenchmarkNew-4    	 1000000	      1000 ns/op
+PASS
+ok  	pkg	3.000s
+`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	regressions := benchRegressions(report, baseline, 10)
+	if len(regressions) != 1 || regressions[0].test.Name != "BenchmarkHash" {
+		t.Fatalf("benchRegressions() == %v, want BenchmarkHash", regressions)
+	}
+	if p := regressions[0].percent(); p < 10.09 || p > 10.11 {
+		t.Errorf("percent() == %f, want 10.1", p)
+	}
+}
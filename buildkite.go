@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// buildkiteUploadURL is the upload endpoint of Buildkite Test Analytics.
+var buildkiteUploadURL = "https://analytics-api.buildkite.com/v1/uploads"
+
+// buildkiteRunEnv returns the run environment of the Buildkite build
+// described by the BUILDKITE_* environment variables. Outside Buildkite the
+// start time is used as run key.
+func buildkiteRunEnv() formatter.BuildkiteRunEnv {
+	env := formatter.BuildkiteRunEnv{
+		CI:        "buildkite",
+		Key:       os.Getenv("BUILDKITE_BUILD_ID"),
+		Number:    os.Getenv("BUILDKITE_BUILD_NUMBER"),
+		JobID:     os.Getenv("BUILDKITE_JOB_ID"),
+		Branch:    os.Getenv("BUILDKITE_BRANCH"),
+		CommitSHA: os.Getenv("BUILDKITE_COMMIT"),
+		Message:   os.Getenv("BUILDKITE_MESSAGE"),
+		URL:       os.Getenv("BUILDKITE_BUILD_URL"),
+	}
+	if env.Key == "" {
+		env.CI = "generic"
+		env.Key = strconv.FormatInt(startTime.UnixNano(), 10)
+	}
+	return env
+}
+
+// uploadBuildkite posts report as Buildkite Test Analytics payload using the
+// API token of a test suite.
+func uploadBuildkite(report *parser.Report, opts formatter.Options, token string) error {
+	if token == "" {
+		return fmt.Errorf("BUILDKITE_ANALYTICS_TOKEN is not set")
+	}
+	var body bytes.Buffer
+	if err := formatter.WriteBuildkiteJSON(report, opts, &body); err != nil {
+		return err
+	}
+
+	return postJSON(buildkiteUploadURL, map[string]string{
+		"Authorization": fmt.Sprintf("Token token=%q", token),
+	}, &body)
+}
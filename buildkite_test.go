@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestBuildkiteFileNames(t *testing.T) {
+	defer func(f, module string) { *format, *modulePathFlag = f, module }(*format, *modulePathFlag)
+	*format, *modulePathFlag = "buildkite", "example.com/mod"
+
+	opts, err := formatOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := &parser.Report{Packages: []parser.Package{{
+		Name:  "example.com/mod/a",
+		Tests: []*parser.Test{{Name: "TestA", Result: parser.FAIL, Output: []string{"    a_test.go:5: boom"}}},
+	}}}
+	var buf bytes.Buffer
+	if err := formatter.WriteBuildkiteJSON(report, opts, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Data []struct {
+			FileName string `json:"file_name"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Data) != 1 || payload.Data[0].FileName != "a/a_test.go" {
+		t.Errorf("unexpected payload: %s", buf.String())
+	}
+}
+
+func TestUploadBuildkite(t *testing.T) {
+	var auth string
+	var payload struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		data, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(data, &payload)
+		w.WriteHeader(status)
+		w.Write([]byte("rejected\n"))
+	}))
+	defer server.Close()
+	defer func(url string) { buildkiteUploadURL = url }(buildkiteUploadURL)
+	buildkiteUploadURL = server.URL
+
+	report := &parser.Report{Packages: []parser.Package{{
+		Name:  "example.com/a",
+		Tests: []*parser.Test{{Name: "TestA", Result: parser.PASS}},
+	}}}
+	opts := formatter.Options{Buildkite: formatter.BuildkiteRunEnv{CI: "generic", Key: "1"}}
+
+	if err := uploadBuildkite(report, opts, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if auth != `Token token="secret"` || len(payload.Data) != 1 {
+		t.Errorf("unexpected upload: authorization %q, %d tests", auth, len(payload.Data))
+	}
+
+	status = http.StatusUnauthorized
+	if err := uploadBuildkite(report, opts, "secret"); err == nil || err.Error() != "401 Unauthorized: rejected" {
+		t.Errorf("uploadBuildkite() error = %v, want 401 Unauthorized: rejected", err)
+	}
+	if err := uploadBuildkite(report, opts, ""); err == nil {
+		t.Errorf("uploadBuildkite() without token did not return an error")
+	}
+}
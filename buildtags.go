@@ -0,0 +1,58 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// regexBuildTags matches the marker a test binary can print to report the
+// build tags it was built with, e.g. [[BUILDTAGS|integration,e2e]].
+var regexBuildTags = regexp.MustCompile(`\[\[BUILDTAGS\|([^\]\r\n]*)\]\]`)
+
+// addBuildTags adds the build tags the packages of report were tested with as
+// go.buildtags property. A [[BUILDTAGS|tags]] marker in the output of a
+// package takes precedence over tags, the -tags flag or the build tags of the
+// test command.
+func addBuildTags(report *parser.Report, tags string) {
+	for i := range report.Packages {
+		pkg := &report.Packages[i]
+		pkgTags := tags
+		for _, line := range pkg.Output {
+			if matches := regexBuildTags.FindStringSubmatch(line); matches != nil {
+				pkgTags = matches[1]
+			}
+		}
+		if pkgTags = normalizeBuildTags(pkgTags); pkgTags != "" {
+			pkg.Properties = append(pkg.Properties, parser.Property{Name: "go.buildtags", Value: pkgTags})
+		}
+	}
+}
+
+// commandBuildTags returns the value of the -tags flag in the arguments of a
+// go test command, or an empty string.
+func commandBuildTags(args []string) string {
+	for i, arg := range args {
+		if arg == "-args" || arg == "--" {
+			// the remaining arguments are passed to the test binary
+			break
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if name == "tags" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(name, "tags=") && strings.HasPrefix(arg, "-") {
+			return name[len("tags="):]
+		}
+	}
+	return ""
+}
+
+// normalizeBuildTags returns tags, which may be separated by commas or by
+// spaces like in older Go versions, separated by commas.
+func normalizeBuildTags(tags string) string {
+	return strings.Join(strings.FieldsFunc(tags, func(r rune) bool {
+		return r == ',' || r == ' '
+	}), ",")
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestCommandBuildTags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"go", "test", "-tags", "integration", "./..."}, "integration"},
+		{[]string{"go", "test", "--tags=a,b", "./..."}, "a,b"},
+		{[]string{"go", "test", "./...", "-args", "-tags=x"}, ""},
+		{[]string{"go", "test", "-v"}, ""},
+	}
+	for _, test := range tests {
+		if got := commandBuildTags(test.args); got != test.want {
+			t.Errorf("commandBuildTags(%q) == %q, want %q", test.args, got, test.want)
+		}
+	}
+}
+
+func TestAddBuildTags(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{
+		{Name: "a"},
+		{Name: "b", Output: []string{"[[BUILDTAGS|integration e2e]]"}},
+	}}
+	addBuildTags(report, "unit")
+
+	want := [][]parser.Property{
+		{{Name: "go.buildtags", Value: "unit"}},
+		{{Name: "go.buildtags", Value: "integration,e2e"}},
+	}
+	for i, pkg := range report.Packages {
+		if !reflect.DeepEqual(pkg.Properties, want[i]) {
+			t.Errorf("properties of %s == %v, want %v", pkg.Name, pkg.Properties, want[i])
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// failureCluster is a group of failed tests with the same fingerprint.
+type failureCluster struct {
+	fingerprint string
+	tests       []string // package and test names
+}
+
+var (
+	regexHex    = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+	regexNumber = regexp.MustCompile(`\d+`)
+	regexSpaces = regexp.MustCompile(`\s+`)
+)
+
+// clusterFailures groups all failed and errored tests in report by the
+// fingerprint of their output. Clusters are sorted by size, largest first.
+func clusterFailures(report *parser.Report) []failureCluster {
+	var clusters []failureCluster
+	index := map[string]int{}
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.AllTests() {
+			if test.Result != parser.FAIL && test.Result != parser.ERROR {
+				continue
+			}
+			fp := fingerprint(test)
+			i, ok := index[fp]
+			if !ok {
+				i = len(clusters)
+				index[fp] = i
+				clusters = append(clusters, failureCluster{fingerprint: fp})
+			}
+			clusters[i].tests = append(clusters[i].tests, pkg.Name+" "+test.Name)
+		}
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return len(clusters[i].tests) > len(clusters[j].tests)
+	})
+	return clusters
+}
+
+// errFingerprinted stops reading the output of a test once its fingerprint
+// is found.
+var errFingerprinted = errors.New("fingerprinted")
+
+// fingerprint returns a normalized version of the first non-empty output line
+// of test. File positions, addresses and other numbers are replaced so the
+// same failure in different tests or runs has the same fingerprint.
+func fingerprint(test *parser.Test) string {
+	fp := "(no output)"
+	// spilled output that can't be read is left out of the summary
+	test.EachOutputLine(func(line string) error {
+		line = regexLogPrefix.ReplaceAllString(strings.TrimSpace(line), "")
+		if line == "" {
+			return nil
+		}
+		line = regexHex.ReplaceAllString(line, "0x?")
+		line = regexNumber.ReplaceAllString(line, "N")
+		fp = regexSpaces.ReplaceAllString(line, " ")
+		return errFingerprinted
+	})
+	return fp
+}
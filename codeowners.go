@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// ownerRule is a line of a CODEOWNERS file.
+type ownerRule struct {
+	re      *regexp.Regexp
+	dirOnly bool
+	owners  []string
+}
+
+// parseCodeowners parses the rules of the CODEOWNERS file read from r. Section
+// headers of GitLab CODEOWNERS files are ignored.
+func parseCodeowners(r io.Reader) ([]ownerRule, error) {
+	var rules []ownerRule
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			continue
+		}
+		fields := strings.Fields(line)
+		var owners []string
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			owners = append(owners, owner)
+		}
+		rule, err := ownerPattern(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", n, err)
+		}
+		rule.owners = owners
+		rules = append(rules, rule)
+	}
+	return rules, s.Err()
+}
+
+// ownerPattern converts a gitignore style pattern of a CODEOWNERS file to a
+// rule. Patterns containing a slash other than a trailing one are relative to
+// the repository root, others match at any depth.
+func ownerPattern(pattern string) (ownerRule, error) {
+	var rule ownerRule
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var expr strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expr.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expr.WriteString("[^/]")
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			i++
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	prefix := "^"
+	if !anchored {
+		prefix = "^(?:.*/)?"
+	}
+	re, err := regexp.Compile(prefix + expr.String() + "$")
+	rule.re = re
+	return rule, err
+}
+
+// codeOwners returns the owners of path, a slash separated path relative to
+// the repository root, according to the last matching rule. A rule matching a
+// directory applies to everything in it. If dir is set, path is a directory.
+func codeOwners(rules []ownerRule, path string, dir bool) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		rule := rules[i]
+		for p, isDir := path, dir; ; p, isDir = parentDir(p), true {
+			if rule.re.MatchString(p) && (isDir || !rule.dirOnly) {
+				return rule.owners
+			}
+			if p == "" {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// parentDir returns the parent directory of a slash separated relative path,
+// or the empty path of the root.
+func parentDir(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// codeownersRoot returns the root of the repository of the CODEOWNERS file at
+// path, which is in the root, .github, .gitlab or docs directory.
+func codeownersRoot(path string) string {
+	dir := filepath.Dir(path)
+	switch filepath.Base(dir) {
+	case ".github", ".gitlab", "docs":
+		return filepath.Dir(dir)
+	}
+	return dir
+}
+
+// addOwners adds the owners of the directory of each package of report, as
+// listed in the CODEOWNERS file at path, as owner property to the package.
+// If failed is set, the owners of the files declaring failed tests are set
+// as their Owner too.
+func addOwners(report *parser.Report, path string, failed bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rules, err := parseCodeowners(f)
+	if err != nil {
+		return err
+	}
+	root, err := filepath.Abs(codeownersRoot(path))
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, pkg := range report.Packages {
+		if pkg.Name != "" {
+			names = append(names, pkg.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	listed, err := goList("", names...)
+	if err != nil {
+		return err
+	}
+	setOwners(report, rules, root, listed, failed)
+	return nil
+}
+
+// setOwners sets the owners of the packages of report, and of their failed
+// tests if failed is set, found in the repository at root.
+func setOwners(report *parser.Report, rules []ownerRule, root string, listed map[string]*goPackage, failed bool) {
+	// rel returns path relative to root, or false if it's outside root
+	rel := func(path string) (string, bool) {
+		r, err := filepath.Rel(root, path)
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			return "", false
+		}
+		if r == "." {
+			return "", true
+		}
+		return filepath.ToSlash(r), true
+	}
+
+	for i := range report.Packages {
+		pkg := &report.Packages[i]
+		gopkg := listed[pkg.Name]
+		if gopkg == nil || gopkg.Dir == "" {
+			continue
+		}
+		dir, ok := rel(gopkg.Dir)
+		if !ok {
+			continue
+		}
+		owners := codeOwners(rules, dir, true)
+		if len(owners) > 0 {
+			pkg.Properties = append(pkg.Properties, parser.Property{Name: "owner", Value: strings.Join(owners, " ")})
+		}
+		if !failed {
+			continue
+		}
+
+		files := testFuncFiles(gopkg)
+		for _, test := range pkg.Tests {
+			if test.Result != parser.FAIL && test.Result != parser.ERROR {
+				continue
+			}
+			testOwners := owners
+			if file, ok := files[topLevelName(test.Name)]; ok {
+				if !filepath.IsAbs(file) && gopkg.Module != nil {
+					file = filepath.Join(gopkg.Module.Dir, file)
+				}
+				if file, ok := rel(file); ok {
+					testOwners = codeOwners(rules, file, false)
+				}
+			}
+			test.Owner = strings.Join(testOwners, " ")
+		}
+	}
+}
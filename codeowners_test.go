@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+const testCodeowners = `# default owners
+*                  @org/platform
+[Backend]
+/internal/         @org/backend
+/internal/db/*_test.go @org/dba # tests only
+docs/              @org/docs
+**/legacy          @org/archaeologists
+/internal/unowned/
+`
+
+func TestCodeOwners(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(testCodeowners))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path  string
+		dir   bool
+		owner string
+	}{
+		{"", true, "@org/platform"},
+		{"cmd/tool", true, "@org/platform"},
+		{"internal", true, "@org/backend"},
+		{"internal/api", true, "@org/backend"},
+		{"internal/db", true, "@org/backend"},
+		{"internal/db/db_test.go", false, "@org/dba"},
+		{"internal/db/db.go", false, "@org/backend"},
+		{"pkg/docs", true, "@org/docs"},
+		{"pkg/docs.go", false, "@org/platform"},
+		{"a/b/legacy/c", true, "@org/archaeologists"},
+		{"internal/unowned/x", true, ""},
+	}
+	for _, test := range tests {
+		if owner := strings.Join(codeOwners(rules, test.path, test.dir), " "); owner != test.owner {
+			t.Errorf("codeOwners(%q, %t) == %q, want %q", test.path, test.dir, owner, test.owner)
+		}
+	}
+}
+
+func TestCodeownersRoot(t *testing.T) {
+	for path, want := range map[string]string{
+		"CODEOWNERS":                "repo",
+		".github/CODEOWNERS":        "repo",
+		"docs/CODEOWNERS":           "repo",
+		"internal/tools/CODEOWNERS": "repo/internal/tools",
+	} {
+		if root := codeownersRoot(filepath.Join("repo", path)); root != filepath.FromSlash(want) {
+			t.Errorf("codeownersRoot(%q) == %q, want %q", path, root, want)
+		}
+	}
+}
+
+func TestSetOwners(t *testing.T) {
+	root, err := ioutil.TempDir("", "codeowners")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "internal", "db")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "db_test.go"), []byte("package db\n\nfunc TestQuery(t *testing.T) {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := parseCodeowners(strings.NewReader(testCodeowners))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := &parser.Report{Packages: []parser.Package{
+		{Name: "example.com/internal/db", Tests: []*parser.Test{
+			{Name: "TestQuery/select", Result: parser.FAIL},
+			{Name: "TestConnect", Result: parser.FAIL},
+			{Name: "TestPing", Result: parser.PASS},
+		}},
+		{Name: "example.com/elsewhere"},
+	}}
+	listed := map[string]*goPackage{
+		"example.com/internal/db": {
+			Dir:         dir,
+			Module:      &goModule{Path: "example.com", Dir: root},
+			TestGoFiles: []string{"db_test.go"},
+		},
+		"example.com/elsewhere": {Dir: os.TempDir()},
+	}
+	setOwners(report, rules, root, listed, true)
+
+	if props := report.Packages[0].Properties; !reflect.DeepEqual(props, []parser.Property{{Name: "owner", Value: "@org/backend"}}) {
+		t.Errorf("package properties == %v, want owner @org/backend", props)
+	}
+	if props := report.Packages[1].Properties; len(props) != 0 {
+		t.Errorf("properties of package outside the repository == %v, want none", props)
+	}
+	var owners []string
+	for _, test := range report.Packages[0].Tests {
+		owners = append(owners, test.Owner)
+	}
+	if want := []string{"@org/dba", "@org/backend", ""}; !reflect.DeepEqual(owners, want) {
+		t.Errorf("test owners == %q, want %q", owners, want)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultConfigFiles are the configuration files that are used if they exist
+// in the current directory and no -config flag was given.
+var defaultConfigFiles = []string{".go-junit-report.yaml", ".go-junit-report.yml"}
+
+// configEntry is a single setting from a configuration file.
+type configEntry struct {
+	name   string
+	values []string
+	line   int
+}
+
+// loadConfig applies the settings in the configuration file to all flags in
+// fs that were not set on the command line. If filename is empty, the default
+// configuration files are tried.
+func loadConfig(fs *flag.FlagSet, filename string) error {
+	if filename == "" {
+		for _, name := range defaultConfigFiles {
+			if _, err := os.Stat(name); err == nil {
+				filename = name
+				break
+			}
+		}
+		if filename == "" {
+			return nil
+		}
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	entries, err := parseConfig(f)
+	if err != nil {
+		return fmt.Errorf("%s:%s", filename, err)
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	for _, entry := range entries {
+		if fs.Lookup(entry.name) == nil || entry.name == "config" {
+			return fmt.Errorf("%s:%d: unknown setting %q", filename, entry.line, entry.name)
+		}
+		if set[entry.name] {
+			continue
+		}
+		for _, value := range entry.values {
+			if err := fs.Set(entry.name, value); err != nil {
+				return fmt.Errorf("%s:%d: invalid value %q for %s: %s", filename, entry.line, value, entry.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parseConfig parses the subset of YAML used by configuration files: a flat
+// mapping of flag names to scalars, flow lists ([a, b]) or block lists.
+func parseConfig(r io.Reader) ([]configEntry, error) {
+	var entries []configEntry
+	var list *configEntry
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(stripComment(scanner.Text()), " \t")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if line[0] == ' ' || line[0] == '\t' {
+			item := strings.TrimSpace(line)
+			if list == nil || !strings.HasPrefix(item, "-") {
+				return nil, fmt.Errorf("%d: unexpected indentation", n)
+			}
+			value, err := unquote(strings.TrimSpace(item[1:]))
+			if err != nil {
+				return nil, fmt.Errorf("%d: %s", n, err)
+			}
+			list.values = append(list.values, value)
+			continue
+		}
+
+		idx := strings.Index(line, ":")
+		if idx < 1 {
+			return nil, fmt.Errorf("%d: expected name: value", n)
+		}
+		entry := configEntry{name: strings.TrimSpace(line[:idx]), line: n}
+		value := strings.TrimSpace(line[idx+1:])
+
+		list = nil
+		switch {
+		case value == "":
+			entries = append(entries, entry)
+			list = &entries[len(entries)-1]
+			continue
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			for _, item := range splitFlowList(value[1 : len(value)-1]) {
+				v, err := unquote(item)
+				if err != nil {
+					return nil, fmt.Errorf("%d: %s", n, err)
+				}
+				entry.values = append(entry.values, v)
+			}
+		default:
+			v, err := unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %s", n, err)
+			}
+			entry.values = []string{v}
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// stripComment removes a # comment from line, unless it's inside quotes.
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitFlowList splits the items of a flow list on commas outside quotes.
+func splitFlowList(s string) []string {
+	var items []string
+	var quote rune
+	start := 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" || len(items) > 0 {
+		items = append(items, last)
+	}
+	return items
+}
+
+// unquote returns the value of a plain, single quoted or double quoted scalar.
+func unquote(s string) (string, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		return strconv.Unquote(s)
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	}
+	return s, nil
+}
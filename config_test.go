@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	in := `# shared settings
+format: junit
+set-exit-code: true # fail the build
+package-name: "pkg # not a comment"
+prop:
+  - a=1
+  - 'b=it''s'
+prop-env: [CI, "GIT, COMMIT"]
+`
+	entries, err := parseConfig(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("parseConfig: %s", err)
+	}
+
+	expected := []configEntry{
+		{name: "format", values: []string{"junit"}, line: 2},
+		{name: "set-exit-code", values: []string{"true"}, line: 3},
+		{name: "package-name", values: []string{"pkg # not a comment"}, line: 4},
+		{name: "prop", values: []string{"a=1", "b=it's"}, line: 5},
+		{name: "prop-env", values: []string{"CI", "GIT, COMMIT"}, line: 8},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("parseConfig:\n got %#v\nwant %#v", entries, expected)
+	}
+
+	for _, in := range []string{"  - a", "no value", ": x"} {
+		if _, err := parseConfig(strings.NewReader(in)); err == nil {
+			t.Errorf("parseConfig(%q) expected error", in)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-junit-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "config.yaml")
+	config := "format: template\nset-exit-code: true\nprop: [a=1, b=2]\n"
+	if err := ioutil.WriteFile(filename, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	format := fs.String("format", "junit", "")
+	exitCode := fs.Bool("set-exit-code", false, "")
+	var props propertyFlag
+	fs.Var(&props, "prop", "")
+	if err := fs.Parse([]string{"-format", "json"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loadConfig(fs, filename); err != nil {
+		t.Fatalf("loadConfig: %s", err)
+	}
+	if *format != "json" {
+		t.Errorf("format = %q, command line value should take precedence", *format)
+	}
+	if !*exitCode {
+		t.Errorf("set-exit-code not set from config")
+	}
+	if len(props) != 2 {
+		t.Errorf("prop = %v, want 2 properties", props)
+	}
+
+	if err := ioutil.WriteFile(filename, []byte("unknown: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadConfig(fs, filename); err == nil {
+		t.Errorf("loadConfig with unknown setting expected error")
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// addCoverFuncProperties reads the `go tool cover -func` output in filename
+// and adds the coverage of every function and file to the package they belong
+// to. Per-file coverage is the unweighted mean of its function percentages,
+// since the statement counts are not part of the -func output.
+func addCoverFuncProperties(report *parser.Report, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	funcs, err := parser.ParseCoverFunc(f)
+	if err != nil {
+		return err
+	}
+
+	for i := range report.Packages {
+		pkg := &report.Packages[i]
+
+		var files []string
+		sums := map[string]float64{}
+		counts := map[string]int{}
+		for _, fn := range funcs {
+			if path.Dir(fn.File) != pkg.Name {
+				continue
+			}
+			file := path.Base(fn.File)
+			if counts[file] == 0 {
+				files = append(files, file)
+			}
+			sums[file] += fn.Pct
+			counts[file]++
+
+			pkg.Properties = append(pkg.Properties, parser.Property{
+				Name:  "coverage.func." + file + ":" + fn.Function,
+				Value: formatPct(fn.Pct),
+			})
+		}
+		for _, file := range files {
+			pkg.Properties = append(pkg.Properties, parser.Property{
+				Name:  "coverage.file." + file,
+				Value: formatPct(sums[file] / float64(counts[file])),
+			})
+		}
+	}
+	return nil
+}
+
+func formatPct(pct float64) string {
+	return strconv.FormatFloat(pct, 'f', 1, 64)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// addCoverProfile adds the location and the overall statement coverage of the
+// coverage profile filename, and the coverage of each package if it's in the
+// profile, as testsuite properties. The profile is also attached to all
+// packages.
+func addCoverProfile(report *parser.Report, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	profile, err := parser.ParseCoverProfile(f)
+	if err != nil {
+		return err
+	}
+
+	props := []parser.Property{{Name: "coverage.profile", Value: filename}}
+	if pct, ok := profile.Coverage(""); ok {
+		props = append(props, parser.Property{Name: "coverage.profile.pct", Value: formatPct(pct)})
+	}
+	for i := range report.Packages {
+		pkg := &report.Packages[i]
+		pkg.Properties = append(pkg.Properties, props...)
+		if pct, ok := profile.Coverage(pkg.Name); ok {
+			pkg.Properties = append(pkg.Properties, parser.Property{Name: "coverage.profile.package.pct", Value: formatPct(pct)})
+		}
+		report.Attach(pkg.Name, "", filename)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// datadogGitTags are the Datadog tags of the git metadata, with the
+// environment variables that override the values found with git.
+var datadogGitTags = []struct {
+	tag, env string
+	value    func(info gitInfo) string
+}{
+	{"git.repository_url", "DD_GIT_REPOSITORY_URL", func(info gitInfo) string { return info.RepositoryURL }},
+	{"git.commit.sha", "DD_GIT_COMMIT_SHA", func(info gitInfo) string { return info.Commit }},
+	{"git.branch", "DD_GIT_BRANCH", func(info gitInfo) string { return info.Branch }},
+	{"git.commit.message", "DD_GIT_COMMIT_MESSAGE", func(info gitInfo) string { return info.Message }},
+}
+
+// addDatadogTags adds the test framework, the Go version and the git metadata
+// of the current directory as dd_tags[...] properties to all packages, which
+// `datadog-ci junit upload` adds as tags to the test events.
+func addDatadogTags(report *parser.Report) error {
+	goVersion := *goVersionFlag
+	if goVersion == "" {
+		goVersion = runtime.Version()
+	}
+	tags := []parser.Property{
+		{Name: "test.framework", Value: "golang.org/pkg/testing"},
+		{Name: "runtime.name", Value: "go"},
+		{Name: "runtime.version", Value: goVersion},
+	}
+
+	info := gitMetadata(".")
+	for _, git := range datadogGitTags {
+		value := os.Getenv(git.env)
+		if value == "" {
+			value = git.value(info)
+		}
+		if value != "" {
+			tags = append(tags, parser.Property{Name: git.tag, Value: value})
+		}
+	}
+
+	for i := range tags {
+		tags[i].Name = "dd_tags[" + tags[i].Name + "]"
+	}
+	addProperties(report, tags)
+	return nil
+}
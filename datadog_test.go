@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestAddDatadogTags(t *testing.T) {
+	env := map[string]string{
+		"DD_GIT_REPOSITORY_URL": "https://example.com/repo.git",
+		"DD_GIT_COMMIT_SHA":     "abc123",
+		"DD_GIT_BRANCH":         "main",
+		"DD_GIT_COMMIT_MESSAGE": "Fix tests",
+	}
+	for name, value := range env {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Setenv(name, value)
+	}
+
+	report := &parser.Report{Packages: []parser.Package{{Name: "a"}, {Name: "b"}}}
+	if err := addDatadogTags(report); err != nil {
+		t.Fatal(err)
+	}
+	for _, pkg := range report.Packages {
+		props := map[string]string{}
+		for _, prop := range pkg.Properties {
+			props[prop.Name] = prop.Value
+		}
+		for name, want := range map[string]string{
+			"dd_tags[runtime.name]":       "go",
+			"dd_tags[git.repository_url]": "https://example.com/repo.git",
+			"dd_tags[git.commit.sha]":     "abc123",
+			"dd_tags[git.branch]":         "main",
+			"dd_tags[git.commit.message]": "Fix tests",
+		} {
+			if props[name] != want {
+				t.Errorf("package %s: property %s == %q, want %q", pkg.Name, name, props[name], want)
+			}
+		}
+	}
+}
+
+func TestGitMetadataOutsideRepository(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if info := gitMetadata(dir); info != (gitInfo{}) {
+		t.Errorf("gitMetadata() outside a repository = %+v, want no information", info)
+	}
+}
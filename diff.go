@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// diffOptions control which duration changes are reported as regressions.
+type diffOptions struct {
+	// slowdown is the factor by which a test must have become slower.
+	slowdown float64
+	// minSlowdown is the minimum increase of the duration of a test, so
+	// small absolute changes of fast tests are ignored.
+	minSlowdown time.Duration
+}
+
+// testResult is the combined result of all runs of a test in a report.
+type testResult struct {
+	name     string
+	failed   bool
+	passed   bool
+	duration time.Duration
+}
+
+// slowdown is a test that became slower.
+type slowdown struct {
+	name     string
+	old, cur time.Duration
+}
+
+// reportDiff contains the differences between two reports.
+type reportDiff struct {
+	newFailures []string
+	fixed       []string
+	added       []string
+	removed     []string
+	slower      []slowdown
+}
+
+// regressed reports whether tests started failing or became slower.
+func (d reportDiff) regressed() bool {
+	return len(d.newFailures) > 0 || len(d.slower) > 0
+}
+
+// diffReports compares the tests of report cur with those of report old.
+func diffReports(old, cur *parser.Report, opts diffOptions) reportDiff {
+	oldResults, _ := testResults(old)
+	curResults, order := testResults(cur)
+
+	var d reportDiff
+	for _, name := range order {
+		c := curResults[name]
+		o, ok := oldResults[name]
+		if !ok {
+			d.added = append(d.added, name)
+		}
+		if c.failed && (!ok || !o.failed) {
+			d.newFailures = append(d.newFailures, name)
+		}
+		if ok && o.failed && !c.failed && c.passed {
+			d.fixed = append(d.fixed, name)
+		}
+		if ok && o.duration > 0 && c.duration-o.duration >= opts.minSlowdown &&
+			float64(c.duration) >= float64(o.duration)*opts.slowdown {
+			d.slower = append(d.slower, slowdown{name, o.duration, c.duration})
+		}
+	}
+
+	_, oldOrder := testResults(old)
+	for _, name := range oldOrder {
+		if _, ok := curResults[name]; !ok {
+			d.removed = append(d.removed, name)
+		}
+	}
+	return d
+}
+
+// testResults returns the results of all tests in report by their package
+// and test name, and the names in the order they first appear.
+func testResults(report *parser.Report) (map[string]*testResult, []string) {
+	results := map[string]*testResult{}
+	var order []string
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.AllTests() {
+			name := pkg.Name + " " + test.Name
+			r, ok := results[name]
+			if !ok {
+				r = &testResult{name: name}
+				results[name] = r
+				order = append(order, name)
+			}
+			switch test.Result {
+			case parser.FAIL, parser.ERROR:
+				r.failed = true
+			case parser.PASS:
+				r.passed = true
+			}
+			if test.Duration > r.duration {
+				r.duration = test.Duration
+			}
+		}
+	}
+	return results, order
+}
+
+// writeDiff writes the differences as plain text, or as markdown if markdown
+// is set.
+func writeDiff(w io.Writer, d reportDiff, markdown bool) {
+	section := func(title string, n int) {
+		if markdown {
+			fmt.Fprintf(w, "### %s (%d)\n\n", title, n)
+		} else {
+			fmt.Fprintf(w, "%s (%d):\n", title, n)
+		}
+	}
+	item := func(format string, args ...interface{}) {
+		if markdown {
+			fmt.Fprintf(w, "- "+format+"\n", args...)
+		} else {
+			fmt.Fprintf(w, "  "+format+"\n", args...)
+		}
+	}
+	end := func() {
+		if markdown {
+			fmt.Fprintln(w)
+		}
+	}
+
+	lists := []struct {
+		title string
+		tests []string
+	}{
+		{"Newly failing tests", d.newFailures},
+		{"Newly passing tests", d.fixed},
+		{"Added tests", d.added},
+		{"Removed tests", d.removed},
+	}
+	changes := false
+	for _, list := range lists {
+		if len(list.tests) == 0 {
+			continue
+		}
+		changes = true
+		section(list.title, len(list.tests))
+		for _, test := range list.tests {
+			item("%s", test)
+		}
+		end()
+	}
+	if len(d.slower) > 0 {
+		changes = true
+		section("Slower tests", len(d.slower))
+		for _, s := range d.slower {
+			item("%s: %s -> %s (%.1fx)", s.name, s.old, s.cur, float64(s.cur)/float64(s.old))
+		}
+		end()
+	}
+	if !changes {
+		fmt.Fprintln(w, "No changes")
+	}
+}
+
+// compareReport compares report with the report in filename and writes the
+// differences to w.
+func compareReport(w io.Writer, report *parser.Report, filename string, opts diffOptions, markdown bool) (reportDiff, error) {
+	old, err := readReport(filename)
+	if err != nil {
+		return reportDiff{}, err
+	}
+	d := diffReports(old, report, opts)
+	writeDiff(w, d, markdown)
+	return d, nil
+}
+
+// compare compares report with the -compare report and writes the result to
+// stderr or the -compare-out file. It returns whether there are regressions.
+func compare(report *parser.Report) (bool, error) {
+	var w io.WriteCloser = nopCloser{os.Stderr}
+	if *compareOut != "" {
+		f, err := createOutput(*compareOut)
+		if err != nil {
+			return false, err
+		}
+		w = f
+	}
+
+	d, err := compareReport(w, report, *compareFile, diffOptions{
+		slowdown:    *compareSlowdown,
+		minSlowdown: *compareMinSlowdown,
+	}, *compareMarkdown)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return d.regressed(), err
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestDiffReports(t *testing.T) {
+	old := &parser.Report{Packages: []parser.Package{{Name: "pkg", Tests: []*parser.Test{
+		{Name: "TestFixed", Result: parser.FAIL},
+		{Name: "TestBroken", Result: parser.PASS},
+		{Name: "TestRemoved", Result: parser.PASS},
+		{Name: "TestSlow", Result: parser.PASS, Duration: 100 * time.Millisecond},
+		{Name: "TestFast", Result: parser.PASS, Duration: 10 * time.Millisecond},
+		{Name: "TestStillFailing", Result: parser.FAIL},
+	}}}}
+	cur := &parser.Report{Packages: []parser.Package{{Name: "pkg", Tests: []*parser.Test{
+		{Name: "TestFixed", Result: parser.PASS},
+		{Name: "TestBroken", Result: parser.ERROR},
+		{Name: "TestSlow", Result: parser.PASS, Duration: 250 * time.Millisecond},
+		{Name: "TestFast", Result: parser.PASS, Duration: 50 * time.Millisecond},
+		{Name: "TestStillFailing", Result: parser.FAIL},
+		{Name: "TestNew", Result: parser.FAIL},
+	}}}}
+
+	d := diffReports(old, cur, diffOptions{slowdown: 2, minSlowdown: 100 * time.Millisecond})
+	if !d.regressed() {
+		t.Errorf("regressed() == false, want true")
+	}
+
+	var buf bytes.Buffer
+	writeDiff(&buf, d, false)
+	expected := `Newly failing tests (2):
+  pkg TestBroken
+  pkg TestNew
+Newly passing tests (1):
+  pkg TestFixed
+Added tests (1):
+  pkg TestNew
+Removed tests (1):
+  pkg TestRemoved
+Slower tests (1):
+  pkg TestSlow: 100ms -> 250ms (2.5x)
+`
+	if buf.String() != expected {
+		t.Errorf("writeDiff text:\n%s\nwant:\n%s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	writeDiff(&buf, reportDiff{removed: []string{"pkg TestA"}}, true)
+	expected = "### Removed tests (1)\n\n- pkg TestA\n\n"
+	if buf.String() != expected {
+		t.Errorf("writeDiff markdown:\n%q\nwant:\n%q", buf.String(), expected)
+	}
+
+	d = diffReports(cur, cur, diffOptions{slowdown: 2})
+	if d.regressed() {
+		t.Errorf("comparing a report with itself regressed: %+v", d)
+	}
+	buf.Reset()
+	writeDiff(&buf, d, false)
+	if buf.String() != "No changes\n" {
+		t.Errorf("writeDiff without changes == %q", buf.String())
+	}
+}
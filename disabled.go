@@ -0,0 +1,149 @@
+package main
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// addDisabledTests finds all Test functions in the module at dir that are in
+// files excluded by build constraints for the current platform, and adds them
+// as skipped tests to report. Packages that are not part of the report yet
+// are added if they contain disabled tests.
+func addDisabledTests(report *parser.Report, dir string) error {
+	listed, err := goList(dir, "./...")
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for path := range listed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		tests := disabledTests(listed[path])
+		if len(tests) == 0 {
+			continue
+		}
+
+		pkg := findPackage(report, path)
+		if pkg == nil {
+			report.Packages = append(report.Packages, parser.Package{Name: path})
+			pkg = &report.Packages[len(report.Packages)-1]
+		}
+		pkg.Tests = append(pkg.Tests, tests...)
+	}
+	return nil
+}
+
+// disabledTests returns a skipped test for each Test function declared in the
+// ignored test files of pkg.
+func disabledTests(pkg *goPackage) []*parser.Test {
+	var tests []*parser.Test
+	for _, name := range pkg.IgnoredGoFiles {
+		if !strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		funcs, err := testFuncs(filepath.Join(pkg.Dir, name))
+		if err != nil {
+			continue
+		}
+		for _, fn := range funcs {
+			tests = append(tests, &parser.Test{
+				Name:   fn,
+				Result: parser.SKIP,
+				Output: []string{"disabled by build constraints in " + name},
+			})
+		}
+	}
+	return tests
+}
+
+// testFuncs returns the names of the test functions declared in the Go source
+// file at path.
+func testFuncs(path string) ([]string, error) {
+	f, err := goparser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	testingName := ""
+	for _, imp := range f.Imports {
+		if imp.Path.Value == `"testing"` {
+			testingName = "testing"
+			if imp.Name != nil {
+				testingName = imp.Name.Name
+			}
+		}
+	}
+
+	var funcs []string
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && isTestFunc(fn, testingName) {
+			funcs = append(funcs, fn.Name.Name)
+		}
+	}
+	return funcs, nil
+}
+
+// isTestFunc reports whether fn is a test function, using the same rules as go
+// test: a function named Test, optionally followed by a name that doesn't
+// start with a lowercase letter, other than TestMain, with a single
+// *testing.T parameter and no results. testingName is the name the testing
+// package is imported as, "." for a dot import.
+func isTestFunc(fn *ast.FuncDecl, testingName string) bool {
+	if fn.Recv != nil || fn.Name.Name == "TestMain" || !isTestName(fn.Name.Name) {
+		return false
+	}
+	if fn.Type.Results != nil && len(fn.Type.Results.List) > 0 {
+		return false
+	}
+	params := fn.Type.Params.List
+	if len(params) != 1 || len(params[0].Names) > 1 {
+		return false
+	}
+	ptr, ok := params[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	switch typ := ptr.X.(type) {
+	case *ast.SelectorExpr:
+		pkg, ok := typ.X.(*ast.Ident)
+		return ok && pkg.Name == testingName && typ.Sel.Name == "T"
+	case *ast.Ident:
+		return testingName == "." && typ.Name == "T"
+	}
+	return false
+}
+
+// isTestName reports whether name is the name of a test function: Test,
+// optionally followed by a name that doesn't start with a lowercase letter.
+func isTestName(name string) bool {
+	if !strings.HasPrefix(name, "Test") {
+		return false
+	}
+	if len(name) == len("Test") {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len("Test"):])
+	return !unicode.IsLower(r)
+}
+
+// findPackage returns the package in report with the given name, or nil.
+func findPackage(report *parser.Report, name string) *parser.Package {
+	for i := range report.Packages {
+		if report.Packages[i].Name == name {
+			return &report.Packages[i]
+		}
+	}
+	return nil
+}
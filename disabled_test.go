@@ -0,0 +1,101 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestIsTestName(t *testing.T) {
+	tests := map[string]bool{
+		"Test":        true,
+		"TestFoo":     true,
+		"Test_foo":    true,
+		"Testfoo":     false,
+		"BenchmarkX":  false,
+		"helperTestX": false,
+	}
+	for name, want := range tests {
+		if got := isTestName(name); got != want {
+			t.Errorf("isTestName(%q) == %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestTestFuncs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "disabled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := `package foo
+
+import tt "testing"
+
+func TestMain(m *tt.M) {}
+
+func TestA(t *tt.T) {}
+
+func TestNoParams() {}
+
+func TestTwo(t *tt.T, n int) {}
+
+func TestBench(b *tt.B) {}
+
+func TestResult(t *tt.T) error { return nil }
+
+type s struct{}
+
+func (s) TestMethod(t *tt.T) {}
+
+func Testlower(t *tt.T) {}
+`
+	file := filepath.Join(dir, "a_test.go")
+	if err := ioutil.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	funcs, err := testFuncs(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"TestA"}; !reflect.DeepEqual(funcs, want) {
+		t.Errorf("testFuncs() == %q, want %q", funcs, want)
+	}
+
+	src = "package foo\n\nimport . \"testing\"\n\nfunc TestDot(t *T) {}\n"
+	if err := ioutil.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if funcs, err := testFuncs(file); err != nil || !reflect.DeepEqual(funcs, []string{"TestDot"}) {
+		t.Errorf("testFuncs() with a dot import == %q, %v, want TestDot", funcs, err)
+	}
+}
+
+func TestDisabledTests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "disabled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := "// +build ignore\n\npackage foo\n\nimport \"testing\"\n\nfunc TestIgnored(t *testing.T) {}\n\nfunc helper() {}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "ignored_test.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := disabledTests(&goPackage{
+		Dir:            dir,
+		IgnoredGoFiles: []string{"ignored_test.go", "ignored.go"},
+	})
+	if len(tests) != 1 {
+		t.Fatalf("disabledTests() returned %d tests, want 1", len(tests))
+	}
+	if tests[0].Name != "TestIgnored" || tests[0].Result != parser.SKIP {
+		t.Errorf("disabledTests() == %s %v, want TestIgnored SKIP", tests[0].Name, tests[0].Result)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// listFlag is a repeatable flag of comma separated values.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// regexpFlag is a repeatable flag of regular expressions.
+type regexpFlag []*regexp.Regexp
+
+func (r *regexpFlag) String() string {
+	var res []string
+	for _, re := range *r {
+		res = append(res, re.String())
+	}
+	return strings.Join(res, ",")
+}
+
+func (r *regexpFlag) Set(value string) error {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return err
+	}
+	*r = append(*r, re)
+	return nil
+}
+
+// testFilter returns a function that keeps tests whose full name matches one
+// of the include regexps, if there are any, and none of the exclude regexps.
+// If failuresOnly is set, only failed tests are kept.
+func testFilter(include, exclude []*regexp.Regexp, failuresOnly bool) func(*parser.Test) bool {
+	return func(test *parser.Test) bool {
+		if failuresOnly && test.Result != parser.FAIL && test.Result != parser.ERROR {
+			return false
+		}
+		if len(include) > 0 && !matchesAny(include, test.Name) {
+			return false
+		}
+		return !matchesAny(exclude, test.Name)
+	}
+}
+
+func matchesAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// applySubtestMode changes how parent tests of subtests are reported, mode is
+// one of the values accepted by -subtest-mode.
+func applySubtestMode(report *parser.Report, mode string) error {
+	switch mode {
+	case "", "all":
+	case "exclude-parents":
+		report.ExcludeParents()
+	case "ignore-parent-results":
+		report.IgnoreParentResults()
+	default:
+		return fmt.Errorf("unknown subtest mode %q", mode)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestListFlag(t *testing.T) {
+	var list listFlag
+	for _, value := range []string{"a/*, b/**", "", "c,"} {
+		if err := list.Set(value); err != nil {
+			t.Fatalf("Set(%q) returned error: %s", value, err)
+		}
+	}
+	want := listFlag{"a/*", "b/**", "c"}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("list == %v, want %v", list, want)
+	}
+}
+
+func TestTestFilter(t *testing.T) {
+	tests := []*parser.Test{
+		{Name: "TestUnit", Result: parser.PASS},
+		{Name: "TestUnit/sub", Result: parser.FAIL},
+		{Name: "TestIntegrationDB", Result: parser.FAIL},
+		{Name: "TestBuild", Result: parser.ERROR},
+	}
+	re := regexp.MustCompile
+
+	filters := []struct {
+		include, exclude []*regexp.Regexp
+		failuresOnly     bool
+		want             []string
+	}{
+		{nil, nil, false, []string{"TestUnit", "TestUnit/sub", "TestIntegrationDB", "TestBuild"}},
+		{nil, []*regexp.Regexp{re("^TestIntegration")}, false, []string{"TestUnit", "TestUnit/sub", "TestBuild"}},
+		{[]*regexp.Regexp{re("Unit"), re("DB$")}, []*regexp.Regexp{re("/")}, false, []string{"TestUnit", "TestIntegrationDB"}},
+		{nil, nil, true, []string{"TestUnit/sub", "TestIntegrationDB", "TestBuild"}},
+		{[]*regexp.Regexp{re("^TestUnit")}, nil, true, []string{"TestUnit/sub"}},
+	}
+	for _, f := range filters {
+		keep := testFilter(f.include, f.exclude, f.failuresOnly)
+		var got []string
+		for _, test := range tests {
+			if keep(test) {
+				got = append(got, test.Name)
+			}
+		}
+		if !reflect.DeepEqual(got, f.want) {
+			t.Errorf("testFilter(%v, %v, %v) kept %v, want %v", f.include, f.exclude, f.failuresOnly, got, f.want)
+		}
+	}
+}
+
+func TestApplySubtestMode(t *testing.T) {
+	for _, mode := range []string{"", "all", "exclude-parents", "ignore-parent-results"} {
+		if err := applySubtestMode(&parser.Report{}, mode); err != nil {
+			t.Errorf("applySubtestMode(%q) returned error: %s", mode, err)
+		}
+	}
+	if err := applySubtestMode(&parser.Report{}, "parents"); err == nil {
+		t.Errorf("applySubtestMode with unknown mode did not return an error")
+	}
+}
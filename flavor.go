@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// flavors adjust the JUnit XML for the importers of CI systems, which read
+// different parts of the report.
+var flavors = map[string]func(opts *formatter.Options){
+	// Azure DevOps shows the failure message and the standard output of
+	// testcases, and sorts test runs by their timestamps.
+	"azure": func(opts *formatter.Options) {
+		opts.Timestamp = startTime
+		opts.MessageLength = 1000
+		opts.TestcaseSystemOut = true
+	},
+	// CircleCI splits tests by timings per file, which it reads from the
+	// file attribute or the classname of testcases.
+	"circleci": func(opts *formatter.Options) {
+		opts.FileAttr = true
+		opts.FileClassname = true
+	},
+	// Datadog CI Visibility shows the failure message and reads the git
+	// metadata from dd_tags properties, see addDatadogTags.
+	"datadog": func(opts *formatter.Options) {
+		opts.MessageLength = 1000
+	},
+	// GitLab links testcases to their file and shows short failure messages
+	// in merge requests.
+	"gitlab": func(opts *formatter.Options) {
+		opts.MessageLength = 200
+		opts.TestcaseSystemOut = true
+		opts.FileAttr = true
+	},
+}
+
+// flavorReports add information to the report that some flavors need.
+var flavorReports = map[string]func(report *parser.Report) error{
+	// the file of every test, not only of those that logged their location
+	"circleci": addTestFiles,
+	"datadog":  addDatadogTags,
+}
+
+// startTime is the time go-junit-report was started.
+var startTime = time.Now()
+
+// applyFlavor changes opts for the named flavor, an empty name leaves opts
+// unchanged.
+func applyFlavor(opts *formatter.Options, flavor string) error {
+	if flavor == "" {
+		return nil
+	}
+	apply, ok := flavors[flavor]
+	if !ok {
+		return fmt.Errorf("unknown flavor %q, use one of %s", flavor, strings.Join(flavorNames(), ", "))
+	}
+	apply(opts)
+	return nil
+}
+
+// flavorNames returns the sorted names of all flavors.
+func flavorNames() []string {
+	var names []string
+	for name := range flavors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
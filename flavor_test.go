@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hexon/go-junit-report/formatter"
+)
+
+func TestApplyFlavor(t *testing.T) {
+	for _, name := range flavorNames() {
+		var opts formatter.Options
+		if err := applyFlavor(&opts, name); err != nil {
+			t.Errorf("applyFlavor(%q): %s", name, err)
+		}
+		if opts == (formatter.Options{}) {
+			t.Errorf("flavor %q does not change any options", name)
+		}
+	}
+
+	var opts formatter.Options
+	if err := applyFlavor(&opts, ""); err != nil || opts != (formatter.Options{}) {
+		t.Errorf("applyFlavor without flavor changed options: %+v, %v", opts, err)
+	}
+	if err := applyFlavor(&opts, "unknown"); err == nil {
+		t.Errorf("applyFlavor did not return an error for an unknown flavor")
+	}
+}
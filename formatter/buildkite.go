@@ -0,0 +1,148 @@
+package formatter
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/acarl005/stripansi"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// BuildkiteRunEnv describes the build in which tests were run, as reported to
+// Buildkite Test Analytics. Key identifies the build, tests uploaded with the
+// same key are combined into one run.
+type BuildkiteRunEnv struct {
+	CI        string `json:"CI"`
+	Key       string `json:"key"`
+	Number    string `json:"number,omitempty"`
+	JobID     string `json:"job_id,omitempty"`
+	Branch    string `json:"branch,omitempty"`
+	CommitSHA string `json:"commit_sha,omitempty"`
+	Message   string `json:"message,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// buildkitePayload is the JSON upload format of Buildkite Test Analytics.
+type buildkitePayload struct {
+	Format string          `json:"format"`
+	RunEnv BuildkiteRunEnv `json:"run_env"`
+	Data   []buildkiteTest `json:"data"`
+}
+
+type buildkiteTest struct {
+	ID              string              `json:"id"`
+	Scope           string              `json:"scope"`
+	Name            string              `json:"name"`
+	Identifier      string              `json:"identifier"`
+	Location        string              `json:"location,omitempty"`
+	FileName        string              `json:"file_name,omitempty"`
+	Result          string              `json:"result"`
+	FailureReason   string              `json:"failure_reason,omitempty"`
+	FailureExpanded []buildkiteExpanded `json:"failure_expanded,omitempty"`
+	History         buildkiteSpan       `json:"history"`
+}
+
+type buildkiteExpanded struct {
+	Expanded  []string `json:"expanded"`
+	Backtrace []string `json:"backtrace"`
+}
+
+// buildkiteSpan is a section of the time line of a test, in seconds since the
+// start of the test.
+type buildkiteSpan struct {
+	Section  string          `json:"section"`
+	StartAt  float64         `json:"start_at"`
+	EndAt    float64         `json:"end_at"`
+	Duration float64         `json:"duration"`
+	Children []buildkiteSpan `json:"children"`
+}
+
+var buildkiteResults = map[parser.Result]string{
+	parser.PASS:  "passed",
+	parser.FAIL:  "failed",
+	parser.ERROR: "failed",
+	parser.SKIP:  "skipped",
+}
+
+// WriteBuildkiteJSON writes the report as Buildkite Test Analytics JSON
+// payload to w, with the run environment opts.Buildkite. Test ids are derived
+// from the run key and the test names, so writing the same report twice
+// results in the same payload.
+func WriteBuildkiteJSON(report *parser.Report, opts Options, w io.Writer) error {
+	payload := buildkitePayload{
+		Format: "json",
+		RunEnv: opts.Buildkite,
+		Data:   []buildkiteTest{},
+	}
+	for _, pkg := range report.Packages {
+		seen := map[string]int{}
+		for _, test := range pkg.AllTests() {
+			bt, err := buildkiteTestOf(test, pkg.Name, seen[test.Name], opts)
+			if err != nil {
+				return err
+			}
+			seen[test.Name]++
+			payload.Data = append(payload.Data, bt)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(payload)
+}
+
+// buildkiteTestOf converts the n-th occurrence of test in the package pkgName.
+func buildkiteTestOf(test *parser.Test, pkgName string, n int, opts Options) (buildkiteTest, error) {
+	seconds := test.Duration.Seconds()
+	bt := buildkiteTest{
+		ID:         buildkiteID(opts.Buildkite.Key, pkgName, test.Name, n),
+		Scope:      pkgName,
+		Name:       test.Name,
+		Identifier: pkgName + " " + test.Name,
+		FileName:   testFile(test, pkgName, opts),
+		Result:     buildkiteResults[test.Result],
+		History: buildkiteSpan{
+			Section:  "top",
+			EndAt:    seconds,
+			Duration: seconds,
+			Children: []buildkiteSpan{},
+		},
+	}
+	bt.Location = bt.FileName
+	if test.Result != parser.FAIL && test.Result != parser.ERROR {
+		return bt, nil
+	}
+
+	var output []string
+	err := test.EachOutputLine(func(line string) error {
+		if opts.StripANSIEscape {
+			line = stripansi.Strip(line)
+		}
+		output = append(output, line)
+		return nil
+	})
+	if err != nil {
+		return bt, err
+	}
+	for _, line := range output {
+		if line = strings.TrimSpace(line); line != "" {
+			bt.FailureReason = shortenMessage(line, opts.MessageLength)
+			break
+		}
+	}
+	bt.FailureExpanded = []buildkiteExpanded{{Expanded: output, Backtrace: []string{}}}
+	return bt, nil
+}
+
+// buildkiteID returns a name based (version 5) UUID for the n-th occurrence
+// of a test in a run.
+func buildkiteID(key, pkgName, name string, n int) string {
+	sum := sha1.Sum([]byte(strings.Join([]string{key, pkgName, name, strconv.Itoa(n)}, "\x00")))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
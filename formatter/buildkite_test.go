@@ -0,0 +1,67 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestWriteBuildkiteJSON(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{{
+		Name: "example.com/mod/a",
+		Tests: []*parser.Test{
+			{Name: "TestA", Result: parser.PASS, Duration: 1500 * time.Millisecond},
+			{Name: "TestB", Result: parser.FAIL, Output: []string{"", "    a_test.go:5: boom", "    more"}},
+			{Name: "TestC", Result: parser.SKIP, File: "c\x1b_test.go"},
+			{Name: "TestA", Result: parser.PASS},
+		},
+	}}}
+	opts := Options{Module: "example.com/mod", Buildkite: BuildkiteRunEnv{CI: "buildkite", Key: "build"}}
+
+	var buf bytes.Buffer
+	if err := WriteBuildkiteJSON(report, opts, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var payload buildkitePayload
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Format != "json" || payload.RunEnv != opts.Buildkite || len(payload.Data) != 4 {
+		t.Fatalf("unexpected payload: %s", buf.String())
+	}
+
+	a, b, c := payload.Data[0], payload.Data[1], payload.Data[2]
+	if a.Result != "passed" || a.History.Duration != 1.5 || a.Identifier != "example.com/mod/a TestA" {
+		t.Errorf("unexpected passed test: %+v", a)
+	}
+	if b.Result != "failed" || b.FailureReason != "a_test.go:5: boom" || b.FileName != "a/a_test.go" || len(b.FailureExpanded) != 1 || len(b.FailureExpanded[0].Expanded) != 3 {
+		t.Errorf("unexpected failed test: %+v", b)
+	}
+	if c.Result != "skipped" || c.FailureReason != "" || c.FileName != "c\x1b_test.go" {
+		t.Errorf("unexpected skipped test: %+v", c)
+	}
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ids := map[string]bool{}
+	for _, test := range payload.Data {
+		if !uuid.MatchString(test.ID) {
+			t.Errorf("test id %q is not a version 5 UUID", test.ID)
+		}
+		ids[test.ID] = true
+	}
+	if len(ids) != len(payload.Data) {
+		t.Errorf("test ids are not unique: %v", ids)
+	}
+
+	var again bytes.Buffer
+	if err := WriteBuildkiteJSON(report, opts, &again); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != buf.String() {
+		t.Errorf("payload changed when written again")
+	}
+}
@@ -0,0 +1,89 @@
+package formatter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// ANSI escape codes used by the console formatter.
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorBold   = "\x1b[1m"
+)
+
+// WriteConsole writes a human readable summary of the report to w: the test
+// counts of each package, the output of failed tests and the totals. If
+// opts.Color is set, results are highlighted with ANSI colors.
+func WriteConsole(report *parser.Report, opts Options, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	paint := func(color, s string) string {
+		if !opts.Color {
+			return s
+		}
+		return color + s + colorReset
+	}
+
+	var total struct {
+		tests, passed, failed, errors, skipped int
+		duration                               time.Duration
+	}
+	for _, pkg := range report.Packages {
+		tests, failed, errors, skipped := pkg.Counts()
+		passed := tests - failed - errors - skipped
+		total.tests += tests
+		total.passed += passed
+		total.failed += failed
+		total.errors += errors
+		total.skipped += skipped
+		total.duration += pkg.Duration
+
+		status := paint(colorGreen, "ok  ")
+		if failed+errors > 0 {
+			status = paint(colorRed, "FAIL")
+		}
+		counts := fmt.Sprintf("%d passed", passed)
+		if failed > 0 {
+			counts += ", " + paint(colorRed, fmt.Sprintf("%d failed", failed))
+		}
+		if errors > 0 {
+			counts += ", " + paint(colorRed, fmt.Sprintf("%d errors", errors))
+		}
+		if skipped > 0 {
+			counts += ", " + paint(colorYellow, fmt.Sprintf("%d skipped", skipped))
+		}
+		fmt.Fprintf(bw, "%s %s (%.3fs): %s\n", status, pkg.Name, pkg.Duration.Seconds(), counts)
+	}
+
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.AllTests() {
+			if test.Result != parser.FAIL && test.Result != parser.ERROR {
+				continue
+			}
+			fmt.Fprintf(bw, "\n%s %s %s (%.3fs)\n", paint(colorRed, "--- "+test.Result.String()+":"), paint(colorBold, test.Name), pkg.Name, test.Duration.Seconds())
+			err := test.EachOutputLine(func(line string) error {
+				_, err := fmt.Fprintf(bw, "    %s\n", formatOutput([]string{line}, opts.StripANSIEscape))
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("%d packages, %d tests: %d passed, %d failed, %d errors, %d skipped in %.3fs",
+		len(report.Packages), total.tests, total.passed, total.failed, total.errors, total.skipped, total.duration.Seconds())
+	if total.failed+total.errors > 0 {
+		summary = paint(colorRed, summary)
+	} else {
+		summary = paint(colorGreen, summary)
+	}
+	fmt.Fprintf(bw, "\n%s\n", summary)
+	return bw.Flush()
+}
@@ -0,0 +1,52 @@
+package formatter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestWriteConsole(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{
+		{Name: "a", Duration: 1500 * time.Millisecond, Tests: []*parser.Test{
+			{Name: "TestPass", Result: parser.PASS, Output: []string{"hidden"}},
+			{Name: "TestSkip", Result: parser.SKIP},
+		}},
+		{Name: "b", Duration: 250 * time.Millisecond, Tests: []*parser.Test{
+			{Name: "TestFail", Result: parser.FAIL, Duration: 100 * time.Millisecond, Output: []string{"b_test.go:10: \x1b[31mboom\x1b[0m"}},
+			{Name: "[build failed]", Result: parser.ERROR, Output: []string{"syntax error"}},
+		}},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteConsole(report, Options{StripANSIEscape: true}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `ok   a (1.500s): 1 passed, 1 skipped
+FAIL b (0.250s): 0 passed, 1 failed, 1 errors
+
+--- FAIL: TestFail b (0.100s)
+    b_test.go:10: boom
+
+--- ERROR: [build failed] b (0.000s)
+    syntax error
+
+2 packages, 4 tests: 1 passed, 1 failed, 1 errors, 1 skipped in 1.750s
+`
+	if buf.String() != expected {
+		t.Errorf("WriteConsole:\n%s\nwant:\n%s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	if err := WriteConsole(report, Options{Color: true}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{colorGreen + "ok  " + colorReset, colorRed + "FAIL" + colorReset, colorYellow + "1 skipped" + colorReset} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("colored output does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
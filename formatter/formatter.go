@@ -3,9 +3,13 @@ package formatter
 import (
 	"bufio"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"path"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,8 +20,14 @@ import (
 
 // JUnitTestSuites is a collection of JUnit test suites.
 type JUnitTestSuites struct {
-	XMLName xml.Name         `xml:"testsuites"`
-	Suites  []JUnitTestSuite `xml:"testsuite"`
+	XMLName   xml.Name         `xml:"testsuites"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Errors    int              `xml:"errors,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      string           `xml:"time,attr"`
+	Suites    []JUnitTestSuite `xml:"testsuite"`
+	SystemErr string           `xml:"system-err,omitempty"`
 }
 
 // JUnitTestSuite is a single JUnit test suite which may contain many
@@ -30,8 +40,11 @@ type JUnitTestSuite struct {
 	Skipped    int             `xml:"skipped,attr"`
 	Time       string          `xml:"time,attr"`
 	Name       string          `xml:"name,attr"`
+	Coverage   string          `xml:"coverage,attr,omitempty"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
 	Properties []JUnitProperty `xml:"properties>property,omitempty"`
 	TestCases  []JUnitTestCase `xml:"testcase"`
+	SystemOut  string          `xml:"system-out,omitempty"`
 }
 
 // JUnitTestCase is a single test case with its result.
@@ -40,10 +53,24 @@ type JUnitTestCase struct {
 	Classname   string            `xml:"classname,attr"`
 	Name        string            `xml:"name,attr"`
 	Time        string            `xml:"time,attr"`
+	Timestamp   string            `xml:"timestamp,attr,omitempty"`
+	File        string            `xml:"file,attr,omitempty"`
+	Properties  *JUnitProperties  `xml:"properties,omitempty"`
 	SkipMessage *JUnitSkipMessage `xml:"skipped,omitempty"`
 	Error       *JUnitError       `xml:"error,omitempty"`
 	Failure     *JUnitFailure     `xml:"failure,omitempty"`
-	SystemOut   string            `xml:",comment"` // A <system-out> element exists in <testsuite> but not in <testcase>
+	// Stdout is the output of skipped tests besides the skip reason, and
+	// with Options.TestcaseSystemOut that of passed tests, which is otherwise
+	// written as comment with consecutive hyphens separated by spaces.
+	Stdout    *JUnitOutput `xml:"system-out,omitempty"`
+	SystemOut string       `xml:",comment"`
+}
+
+// JUnitOutput contains the output of a testcase.
+type JUnitOutput struct {
+	Contents string `xml:",chardata"`
+	// CDATA is written as a CDATA section instead of Contents.
+	CDATA string `xml:",cdata"`
 }
 
 // JUnitSkipMessage contains the reason why a testcase was skipped.
@@ -51,6 +78,11 @@ type JUnitSkipMessage struct {
 	Message string `xml:"message,attr"`
 }
 
+// JUnitProperties contains the properties of a testcase.
+type JUnitProperties struct {
+	Properties []JUnitProperty `xml:"property"`
+}
+
 // JUnitProperty represents a key/value pair used to define properties.
 type JUnitProperty struct {
 	Name  string `xml:"name,attr"`
@@ -62,6 +94,8 @@ type JUnitError struct {
 	Message  string `xml:"message,attr"`
 	Type     string `xml:"type,attr"`
 	Contents string `xml:",chardata"`
+	// CDATA is written as a CDATA section instead of Contents.
+	CDATA string `xml:",cdata"`
 }
 
 // JUnitFailure contains data related to a failed test.
@@ -69,104 +103,983 @@ type JUnitFailure struct {
 	Message  string `xml:"message,attr"`
 	Type     string `xml:"type,attr"`
 	Contents string `xml:",chardata"`
+	// CDATA is written as a CDATA section instead of Contents.
+	CDATA string `xml:",cdata"`
+}
+
+// Options control how a report is formatted. Not all options apply to all
+// formatters.
+type Options struct {
+	// NoXMLHeader omits the XML declaration.
+	NoXMLHeader bool
+	// XMLEncoding is the encoding named in the XML declaration, UTF-8 if
+	// empty. Reports are always encoded in UTF-8, it only changes how the
+	// encoding is spelled, e.g. utf-8 for ingesters comparing it verbatim,
+	// see CheckXMLDeclaration.
+	XMLEncoding string
+	// XMLStandalone, if "yes" or "no", is added as standalone attribute to
+	// the XML declaration.
+	XMLStandalone string
+	// XMLDoctype, if set, is written as document type declaration after
+	// the XML declaration, e.g. testsuites SYSTEM "junit.dtd" for
+	// <!DOCTYPE testsuites SYSTEM "junit.dtd">.
+	XMLDoctype string
+	// Compact writes JUnit reports without indentation and line breaks
+	// between elements.
+	Compact bool
+	// Indent is the string elements of JUnit reports are indented with per
+	// level, a tab if empty.
+	Indent string
+	// Validate checks JUnit reports against JUnitSchema before they are
+	// written, and fails instead of writing invalid reports.
+	Validate bool
+	// GoVersion is the value of the go.version property, the version of the
+	// running Go runtime is used if empty.
+	GoVersion string
+	// GoOS, GoArch and NumCPU are the values of the go.os, go.arch and
+	// runtime.numcpu properties, which are left out if they are not set.
+	// Packages that have these properties, e.g. because they were read
+	// from a report with ParseJUnit, keep their own values.
+	GoOS   string
+	GoArch string
+	NumCPU int
+	// FullPackageClassname uses the full package name as the testcase
+	// classname instead of just the last path element.
+	FullPackageClassname bool
+	// ModuleClassname uses the module path and the import path relative to
+	// it as testcase classname, e.g. example.com/mod:pkg/name, which tells
+	// apart packages with the same import path below different modules. It
+	// has no effect if Module is empty, packages outside of Module use their
+	// full import path.
+	ModuleClassname bool
+	// SuiteClassname reports the methods of testify suites, which run as
+	// subtests named TestMySuite/TestSomething, as testcase TestSomething
+	// with the suite added to the classname, e.g. name.TestMySuite. Subtests
+	// whose name doesn't start with Test are not affected.
+	SuiteClassname bool
+	// GroupSubtests groups subtests under their top-level test. With
+	// "classname", the top-level test is added to the classname of its
+	// testcase and of those of its subtests, e.g. name/TestTable, and the
+	// names of the subtests are their path below it. With "suite", they
+	// are written as a separate testsuite named after the package and the
+	// top-level test, e.g. example.com/name/TestTable, and the package
+	// testsuite is left out if no tests or output remain. Top-level tests
+	// without subtests are not affected.
+	GroupSubtests string
+	// StripANSIEscape removes terminal escape codes from test output.
+	StripANSIEscape bool
+	// CDATA writes the output of tests and errors, package output and the
+	// standard error of the test command in CDATA sections instead of
+	// escaping it. The output of passed tests written as comment is not
+	// affected.
+	CDATA bool
+	// CoverageAttr adds the statement coverage percentage as coverage
+	// attribute to testsuites, in addition to the coverage property.
+	CoverageAttr bool
+	// SuiteStats adds test duration and output size statistics as testsuite
+	// properties.
+	SuiteStats bool
+	// Slowest adds the names and durations of the N slowest tests of each
+	// testsuite as properties. The slowest formatter lists this many
+	// packages and tests, 10 if it's zero.
+	Slowest int
+	// HistogramBuckets is the comma separated list of ascending upper bounds
+	// of the duration buckets of the histogram formatter, see
+	// ParseHistogramBuckets. DefaultHistogramBuckets are used if it's empty.
+	HistogramBuckets string
+	// Duration selects the testcase time: the duration reported by go test
+	// if empty or "go", "wall" for Test.WallDuration or "active" for
+	// Test.ActiveDuration.
+	Duration string
+	// PausedProperty adds the time a test was paused by t.Parallel, if
+	// known, as paused property to its testcase.
+	PausedProperty bool
+
+	// Color highlights results with ANSI colors in the console formatter.
+	Color bool
+
+	// SuiteNameFormat is the format of testsuite names, see SuiteName.
+	SuiteNameFormat string
+	// Module is the path of the module containing the tested packages, if
+	// known.
+	Module string
+
+	// Timestamp, if set, is written as the time testsuites and testcases were
+	// run. Testcases of tests with a known start time use that instead.
+	Timestamp time.Time
+	// MessageLength, if not zero, makes the first line of output of a failed
+	// test its failure message, instead of a generic message. Failure and skip
+	// messages are shortened to at most MessageLength bytes.
+	MessageLength int
+	// TestcaseSystemOut writes the output of passed tests as <system-out>
+	// element of their testcase instead of as a comment.
+	TestcaseSystemOut bool
+	// FileAttr adds the file of each test as file attribute to testcases, if
+	// it's known. The file is Test.File if set, otherwise it's taken from the
+	// first location logged by the test and is relative to Module if the
+	// package is part of it.
+	FileAttr bool
+	// FileClassname uses the file of each test as testcase classname instead
+	// of the package, if it's known.
+	FileClassname bool
+	// SourceURL is a template of links to the source of failures, in which
+	// {file} and {line} are replaced by the first location logged by a failed
+	// test, with the file relative to Module as for FileAttr. The link is
+	// added as source.url property to the testcase and to its failure output.
+	SourceURL string
+
+	// InvalidCharPlaceholder replaces characters that are not allowed in
+	// XML 1.0, such as most control characters, in JUnit reports. They are
+	// removed if it's empty.
+	InvalidCharPlaceholder string
+
+	// Buildkite describes the build in Buildkite Test Analytics payloads.
+	Buildkite BuildkiteRunEnv
+	// TraceParent is the W3C traceparent of the span that OpenTelemetry
+	// spans of packages are added to.
+	TraceParent string
+
+	// BuildErrors controls how build errors of packages are written in JUnit
+	// reports: as a testcase with an error ("testcase", the default), as an
+	// error element of the testsuite ("suite") or both ("both").
+	BuildErrors string
+
+	// Template is the text/template file used by the template formatter.
+	Template string
 }
 
 // JUnitReportXML writes a JUnit xml representation of the given report to w
 // in the format described at http://windyroad.org/dl/Open%20Source/JUnit.xsd
 func JUnitReportXML(report *parser.Report, noXMLHeader bool, goVersion string, fullPackageClassname bool, stripANSIEscape bool, w io.Writer) error {
-	suites := JUnitTestSuites{}
+	return WriteJUnitXML(report, Options{
+		NoXMLHeader:          noXMLHeader,
+		GoVersion:            goVersion,
+		FullPackageClassname: fullPackageClassname,
+		StripANSIEscape:      stripANSIEscape,
+	}, w)
+}
 
-	// convert Report to JUnit test suites
+// WriteJUnitXML writes a JUnit xml representation of the given report to w,
+// configured by opts. Testsuites and testcases are encoded one by one as they
+// are converted, so the converted report is never held in memory as a whole.
+func WriteJUnitXML(report *parser.Report, opts Options, w io.Writer) error {
+	writer := bufio.NewWriter(w)
+
+	if !opts.NoXMLHeader {
+		writer.WriteString(xmlDeclaration(opts))
+	}
+	if opts.XMLDoctype != "" {
+		writer.WriteString("<!DOCTYPE " + opts.XMLDoctype + ">\n")
+	}
+
+	enc := xml.NewEncoder(writer)
+	if !opts.Compact {
+		indent := opts.Indent
+		if indent == "" {
+			indent = "\t"
+		}
+		enc.Indent("", indent)
+	}
+
+	// the totals of all testsuites, for tools that don't add them up
+	var tests, failures, errs, skipped int
+	var duration time.Duration
 	for _, pkg := range report.Packages {
-		ts := JUnitTestSuite{
-			Tests:      len(pkg.Tests),
-			Failures:   0,
-			Errors:     0,
-			Time:       formatTime(pkg.Duration),
-			Name:       pkg.Name,
-			Properties: []JUnitProperty{},
-			TestCases:  []JUnitTestCase{},
-		}
-
-		classname := pkg.Name
-		if !fullPackageClassname {
-			if idx := strings.LastIndex(classname, "/"); idx > -1 && idx < len(pkg.Name) {
-				classname = pkg.Name[idx+1:]
+		t, f, e, s := suiteCounts(pkg, opts)
+		tests += t
+		failures += f
+		errs += e
+		skipped += s
+		duration += pkg.Duration
+	}
+	root := xml.StartElement{
+		Name: xml.Name{Local: "testsuites"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "tests"}, Value: strconv.Itoa(tests)},
+			{Name: xml.Name{Local: "failures"}, Value: strconv.Itoa(failures)},
+			{Name: xml.Name{Local: "errors"}, Value: strconv.Itoa(errs)},
+			{Name: xml.Name{Local: "skipped"}, Value: strconv.Itoa(skipped)},
+			{Name: xml.Name{Local: "time"}, Value: formatTime(duration)},
+		},
+	}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+	for _, pkg := range report.Packages {
+		for _, suite := range testSuites(pkg, opts) {
+			if err := encodeSuite(enc, writer, suite.pkg, suite.test, opts); err != nil {
+				return err
 			}
 		}
+	}
+	if len(report.Stderr) > 0 {
+		if err := encodeOutput(enc, "system-err", report.Stderr, opts); err != nil {
+			return err
+		}
+	}
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+
+	writer.WriteByte('\n')
+	return writer.Flush()
+}
+
+// xmlDeclaration returns the XML declaration of JUnit reports, followed by a
+// newline like xml.Header.
+func xmlDeclaration(opts Options) string {
+	if opts.XMLEncoding == "" && opts.XMLStandalone == "" {
+		return xml.Header
+	}
+	encoding := opts.XMLEncoding
+	if encoding == "" {
+		encoding = "UTF-8"
+	}
+	decl := `<?xml version="1.0" encoding="` + encoding + `"`
+	if opts.XMLStandalone != "" {
+		decl += ` standalone="` + opts.XMLStandalone + `"`
+	}
+	return decl + "?>\n"
+}
+
+// CheckXMLDeclaration returns an error if encoding or standalone are not
+// valid values of Options.XMLEncoding and Options.XMLStandalone. As reports
+// are always encoded in UTF-8, encoding must be a spelling of UTF-8.
+func CheckXMLDeclaration(encoding, standalone string) error {
+	switch encoding {
+	case "", "UTF-8", "utf-8", "utf8":
+	default:
+		return fmt.Errorf("unsupported encoding %q, reports are encoded in UTF-8, use UTF-8, utf-8 or utf8", encoding)
+	}
+	switch standalone {
+	case "", "yes", "no":
+		return nil
+	}
+	return fmt.Errorf("invalid standalone value %q, use yes or no", standalone)
+}
+
+// testSuite is a package, or a part of it, that is written as a testsuite.
+type testSuite struct {
+	pkg parser.Package
+	// top-level test whose subtests are the tests of pkg, if the package
+	// is split by Options.GroupSubtests
+	test string
+}
 
-		// properties
-		if goVersion == "" {
-			// if goVersion was not specified as a flag, fall back to version reported by runtime
-			goVersion = runtime.Version()
+// testSuites returns the testsuites pkg is written as. With GroupSubtests
+// "suite", every top-level test with subtests is written as a separate
+// testsuite with its subtests, the package suite contains the other tests. The
+// package suite is left out if that leaves it empty, without tests, output or
+// build error.
+func testSuites(pkg parser.Package, opts Options) []testSuite {
+	if opts.GroupSubtests != "suite" {
+		return []testSuite{{pkg: pkg}}
+	}
+	rest := pkg
+	rest.Tests = []*parser.Test{}
+	var groups []testSuite
+	index := map[*parser.Test]int{}
+	for _, test := range pkg.Tests {
+		top := test
+		for top.Parent != nil {
+			top = top.Parent
+		}
+		if len(top.Subtests) == 0 {
+			rest.Tests = append(rest.Tests, test)
+			continue
 		}
-		ts.Properties = append(ts.Properties, JUnitProperty{"go.version", goVersion})
-		if pkg.CoveragePct != "" {
-			ts.Properties = append(ts.Properties, JUnitProperty{"coverage.statements.pct", pkg.CoveragePct})
+		i, ok := index[top]
+		if !ok {
+			i = len(groups)
+			index[top] = i
+			groups = append(groups, testSuite{
+				pkg:  parser.Package{Name: pkg.Name, Duration: top.Duration, Time: top.Time},
+				test: top.Name,
+			})
 		}
+		groups[i].pkg.Tests = append(groups[i].pkg.Tests, test)
+	}
+	if len(groups) > 0 && len(rest.Tests) == 0 && len(rest.Output) == 0 && len(rest.Attachments) == 0 && rest.BuildError == nil {
+		return groups
+	}
+	return append([]testSuite{{pkg: rest}}, groups...)
+}
 
-		// individual test cases
-		for _, test := range pkg.Tests {
-			testCase := JUnitTestCase{
-				Classname: classname,
-				Name:      test.Name,
-				Time:      formatTime(test.Duration),
-				Failure:   nil,
-			}
+// encodeSuite encodes pkg as a JUnit testsuite, or the tests of the top-level
+// test of pkg if it's not empty. Spilled test output is written directly to w,
+// the writer of enc.
+func encodeSuite(enc *xml.Encoder, w io.Writer, pkg parser.Package, test string, opts Options) error {
+	name := SuiteName(opts.SuiteNameFormat, pkg.Name, opts.Module)
+	if test != "" {
+		name += "/" + test
+	}
+	ts := JUnitTestSuite{
+		Time: formatTime(pkg.Duration),
+		Name: opts.xmlText(name),
+	}
+	ts.Tests, ts.Failures, ts.Errors, ts.Skipped = suiteCounts(pkg, opts)
+	if pkg.CoveragePct != "" && opts.CoverageAttr {
+		ts.Coverage = strconv.FormatFloat(pkg.Coverage, 'f', -1, 64)
+	}
 
-			switch test.Result {
-			case parser.SKIP:
-				ts.Skipped++
-				testCase.SkipMessage = &JUnitSkipMessage{
-					Message: formatOutput(test.Output, stripANSIEscape),
-				}
-			case parser.ERROR:
-				ts.Errors++
-				testCase.Error = &JUnitError{
-					Message:  "Error",
-					Type:     "",
-					Contents: formatOutput(test.Output, stripANSIEscape),
-				}
-			case parser.FAIL:
-				ts.Failures++
-				testCase.Failure = &JUnitFailure{
-					Message:  "Failed",
-					Type:     "",
-					Contents: formatOutput(test.Output, stripANSIEscape),
-				}
-			case parser.PASS:
-				testCase.SystemOut = formatOutput(test.Output, stripANSIEscape)
-			}
+	start := xml.StartElement{
+		Name: xml.Name{Local: "testsuite"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "tests"}, Value: strconv.Itoa(ts.Tests)},
+			{Name: xml.Name{Local: "failures"}, Value: strconv.Itoa(ts.Failures)},
+			{Name: xml.Name{Local: "errors"}, Value: strconv.Itoa(ts.Errors)},
+			{Name: xml.Name{Local: "skipped"}, Value: strconv.Itoa(ts.Skipped)},
+			{Name: xml.Name{Local: "time"}, Value: ts.Time},
+			{Name: xml.Name{Local: "name"}, Value: ts.Name},
+		},
+	}
+	if ts.Coverage != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "coverage"}, Value: ts.Coverage})
+	}
+	if !opts.Timestamp.IsZero() {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "timestamp"}, Value: formatTimestamp(opts.Timestamp)})
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
 
-			ts.TestCases = append(ts.TestCases, testCase)
+	properties := struct {
+		Properties []JUnitProperty `xml:"property"`
+	}{suiteProperties(pkg, opts)}
+	if err := enc.EncodeElement(properties, xml.StartElement{Name: xml.Name{Local: "properties"}}); err != nil {
+		return err
+	}
+	if pkg.BuildError != nil && (opts.BuildErrors == "suite" || opts.BuildErrors == "both") {
+		output := opts.xmlText(formatOutput(pkg.BuildError.Output, opts.StripANSIEscape))
+		suiteError := JUnitError{Message: opts.xmlText(pkg.BuildError.Name), Type: pkg.BuildError.Type, Contents: output}
+		if opts.CDATA {
+			suiteError.Contents, suiteError.CDATA = "", output
+		}
+		if err := enc.EncodeElement(suiteError, xml.StartElement{Name: xml.Name{Local: "error"}}); err != nil {
+			return err
+		}
+	}
+
+	classname := opts.xmlText(packageClassname(pkg.Name, opts))
+
+	for _, test := range opts.suiteTests(pkg) {
+		var err error
+		if test.SpillFile != "" {
+			err = encodeSpilledCase(enc, w, test, pkg.Name, classname, opts)
+		} else {
+			err = enc.Encode(testCase(test, pkg.Name, classname, opts))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	output := pkg.Output
+	if markers := attachmentMarkers(pkg.Attachments, strings.Join(pkg.Output, "\n")); len(markers) > 0 {
+		output = append(append([]string(nil), output...), markers...)
+	}
+	if len(output) > 0 {
+		if err := encodeOutput(enc, "system-out", output, opts); err != nil {
+			return err
 		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// suiteTests returns the tests of pkg that are written as testcases.
+func (o Options) suiteTests(pkg parser.Package) []*parser.Test {
+	if o.BuildErrors == "suite" {
+		return pkg.Tests
+	}
+	return pkg.AllTests()
+}
 
-		suites.Suites = append(suites.Suites, ts)
+// suiteCounts returns the number of testcases of pkg and of its failures,
+// errors and skipped tests. Build errors count as errors, even if they aren't
+// written as testcase.
+func suiteCounts(pkg parser.Package, opts Options) (tests, failures, errs, skipped int) {
+	cases := opts.suiteTests(pkg)
+	failures, errs, skipped = countResults(cases)
+	if pkg.BuildError != nil && opts.BuildErrors == "suite" {
+		errs++
 	}
+	return len(cases), failures, errs, skipped
+}
 
-	// to xml
-	bytes, err := xml.MarshalIndent(suites, "", "\t")
+// CheckBuildErrors returns an error if mode is not a valid value of
+// Options.BuildErrors.
+func CheckBuildErrors(mode string) error {
+	switch mode {
+	case "", "testcase", "suite", "both":
+		return nil
+	}
+	return fmt.Errorf("unknown mode %q, use testcase, suite or both", mode)
+}
+
+// CheckDuration returns an error if kind is not a valid value of
+// Options.Duration.
+func CheckDuration(kind string) error {
+	switch kind {
+	case "", "go", "wall", "active":
+		return nil
+	}
+	return fmt.Errorf("unknown duration %q, use go, wall or active", kind)
+}
+
+// countResults returns the number of failed, errored and skipped tests.
+func countResults(tests []*parser.Test) (failures, errs, skipped int) {
+	for _, test := range tests {
+		switch test.Result {
+		case parser.SKIP:
+			skipped++
+		case parser.ERROR:
+			errs++
+		case parser.FAIL:
+			failures++
+		}
+	}
+	return failures, errs, skipped
+}
+
+// encodeOutput encodes output as an element with the given name.
+func encodeOutput(enc *xml.Encoder, name string, output []string, opts Options) error {
+	var contents interface{} = opts.xmlText(formatOutput(output, opts.StripANSIEscape))
+	if opts.CDATA {
+		contents = struct {
+			Text string `xml:",cdata"`
+		}{contents.(string)}
+	}
+	return enc.EncodeElement(contents, xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+// packageClassname returns the classname of the testcases of package pkgName.
+func packageClassname(pkgName string, opts Options) string {
+	if opts.ModuleClassname && opts.Module != "" {
+		if path := relativePath(pkgName, opts.Module); path != pkgName {
+			return opts.Module + ":" + path
+		}
+		return pkgName
+	}
+	if !opts.FullPackageClassname {
+		if idx := strings.LastIndex(pkgName, "/"); idx > -1 && idx < len(pkgName) {
+			return pkgName[idx+1:]
+		}
+	}
+	return pkgName
+}
+
+// encodeSpilledCase encodes a testcase for a test whose output was partially
+// spilled to disk. The output of failures and errors is streamed from the
+// spill file, for other results it is read into memory as it's written to an
+// attribute or comment.
+func encodeSpilledCase(enc *xml.Encoder, w io.Writer, test *parser.Test, pkgName, classname string, opts Options) error {
+	if test.Result != parser.FAIL && test.Result != parser.ERROR {
+		output, err := test.AllOutput()
+		if err != nil {
+			return err
+		}
+		t := *test
+		t.Output = output
+		return enc.Encode(testCase(&t, pkgName, classname, opts))
+	}
+
+	stub := &parser.Test{Name: test.Name, Duration: test.Duration, Result: test.Result, ErrorType: test.ErrorType, Start: test.Start, End: test.End, Paused: test.Paused, CPU: test.CPU, File: test.File, Attachments: test.Attachments, Subtests: test.Subtests}
+	if opts.MessageLength > 0 {
+		// only the first line is needed for the message
+		line, err := firstOutputLine(test)
+		if err != nil {
+			return err
+		}
+		stub.Output = []string{line}
+	}
+	tc := testCase(stub, pkgName, classname, opts)
+	setTestFile(&tc, test, pkgName, opts)
+	start := xml.StartElement{
+		Name: xml.Name{Local: "testcase"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "classname"}, Value: tc.Classname},
+			{Name: xml.Name{Local: "name"}, Value: tc.Name},
+			{Name: xml.Name{Local: "time"}, Value: tc.Time},
+		},
+	}
+	if tc.Timestamp != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "timestamp"}, Value: tc.Timestamp})
+	}
+	if tc.File != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "file"}, Value: tc.File})
+	}
+	result := xml.StartElement{Name: xml.Name{Local: "failure"}}
+	message, typ := "", ""
+	if tc.Failure != nil {
+		message, typ = tc.Failure.Message, tc.Failure.Type
+	} else {
+		result.Name.Local = "error"
+		message, typ = tc.Error.Message, tc.Error.Type
+	}
+	result.Attr = []xml.Attr{
+		{Name: xml.Name{Local: "message"}, Value: message},
+		{Name: xml.Name{Local: "type"}, Value: typ},
+	}
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if tc.Properties != nil {
+		if err := enc.EncodeElement(tc.Properties, xml.StartElement{Name: xml.Name{Local: "properties"}}); err != nil {
+			return err
+		}
+	}
+	if err := enc.EncodeToken(result); err != nil {
+		return err
+	}
+	// the encoder doesn't escape newlines in character data tokens like it
+	// does when marshaling, so the output is escaped and written directly
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+	write := func(line string) error {
+		return xml.EscapeText(w, []byte(line))
+	}
+	if opts.CDATA {
+		write = func(line string) error {
+			_, err := io.WriteString(w, escapeCDATA(line))
+			return err
+		}
+		if _, err := io.WriteString(w, "<![CDATA["); err != nil {
+			return err
+		}
+	}
+	first := true
+	err := test.EachOutputLine(func(line string) error {
+		if !first {
+			line = "\n" + line
+		}
+		first = false
+		if opts.StripANSIEscape {
+			line = stripansi.Strip(line)
+		}
+		return write(opts.xmlText(line))
+	})
 	if err != nil {
 		return err
 	}
+	if opts.CDATA {
+		if _, err := io.WriteString(w, "]]>"); err != nil {
+			return err
+		}
+	}
+	if err := enc.EncodeToken(result.End()); err != nil {
+		return err
+	}
+	if tc.Stdout != nil {
+		if err := enc.EncodeElement(tc.Stdout, xml.StartElement{Name: xml.Name{Local: "system-out"}}); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
 
-	writer := bufio.NewWriter(w)
+// suiteProperties returns the testsuite properties of pkg.
+func suiteProperties(pkg parser.Package, opts Options) []JUnitProperty {
+	goVersion := opts.GoVersion
+	if goVersion == "" {
+		// if goVersion was not specified as a flag, fall back to version reported by runtime
+		goVersion = runtime.Version()
+	}
 
-	if !noXMLHeader {
-		writer.WriteString(xml.Header)
+	numCPU := ""
+	if opts.NumCPU > 0 {
+		numCPU = strconv.Itoa(opts.NumCPU)
 	}
+	props := []JUnitProperty{{"go.version", goVersion}}
+	props = appendPlatformProperty(props, pkg, "go.os", opts.GoOS)
+	props = appendPlatformProperty(props, pkg, "go.arch", opts.GoArch)
+	props = appendPlatformProperty(props, pkg, "runtime.numcpu", numCPU)
+	if pkg.CoveragePct != "" {
+		props = append(props, JUnitProperty{"coverage.statements.pct", pkg.CoveragePct})
+	}
+	for _, prop := range pkg.Properties {
+		if !platformProperties[prop.Name] {
+			props = append(props, JUnitProperty{prop.Name, prop.Value})
+		}
+	}
+	if opts.SuiteStats {
+		props = append(props, suiteStats(pkg, opts.StripANSIEscape)...)
+	}
+	if opts.Slowest > 0 {
+		props = append(props, slowestProperties(pkg, opts.Slowest)...)
+	}
+	for i, prop := range props {
+		props[i] = JUnitProperty{opts.xmlText(prop.Name), opts.xmlText(prop.Value)}
+	}
+	return props
+}
 
-	writer.Write(bytes)
-	writer.WriteByte('\n')
-	writer.Flush()
+// platformProperties are the names of the properties describing the machine
+// the tests ran on.
+var platformProperties = map[string]bool{"go.os": true, "go.arch": true, "runtime.numcpu": true}
+
+// appendPlatformProperty appends the platform property name to props, with
+// the values pkg has for it, e.g. when it was read from a report of another
+// machine, or with value if it has none and value is not empty.
+func appendPlatformProperty(props []JUnitProperty, pkg parser.Package, name, value string) []JUnitProperty {
+	found := false
+	for _, prop := range pkg.Properties {
+		if prop.Name == name {
+			props = append(props, JUnitProperty{name, prop.Value})
+			found = true
+		}
+	}
+	if !found && value != "" {
+		props = append(props, JUnitProperty{name, value})
+	}
+	return props
+}
+
+// testCase converts test of the package pkgName to a JUnit testcase.
+func testCase(test *parser.Test, pkgName, classname string, opts Options) JUnitTestCase {
+	name := test.Name
+	if suite, method := suiteMethod(name); opts.SuiteClassname && suite != "" {
+		classname += "." + opts.xmlText(suite)
+		name = method
+	} else if top, sub := topLevelTest(test); opts.GroupSubtests == "classname" && top != "" {
+		classname += "/" + opts.xmlText(top)
+		name = sub
+	}
+	duration := test.Duration
+	switch opts.Duration {
+	case "wall":
+		duration = test.WallDuration()
+	case "active":
+		duration = test.ActiveDuration()
+	}
+	tc := JUnitTestCase{
+		Classname: classname,
+		Name:      opts.xmlText(name),
+		Time:      formatTime(duration),
+	}
+	if !test.Start.IsZero() {
+		tc.Timestamp = formatTestTimestamp(test.Start)
+	} else if !opts.Timestamp.IsZero() {
+		tc.Timestamp = formatTimestamp(opts.Timestamp)
+	}
+	setTestFile(&tc, test, pkgName, opts)
+	var props []JUnitProperty
+	if test.CPU > 0 {
+		props = append(props, JUnitProperty{Name: "cpu", Value: strconv.Itoa(test.CPU)})
+	}
+	if opts.PausedProperty && test.Paused > 0 {
+		props = append(props, JUnitProperty{Name: "paused", Value: formatTime(test.Paused)})
+	}
+	if test.Quarantined {
+		props = append(props, JUnitProperty{Name: "quarantined", Value: "true"})
+	}
+	if test.FailureIgnored {
+		props = append(props, JUnitProperty{Name: "failure-ignored", Value: "true"})
+	}
+	if test.Owner != "" {
+		props = append(props, JUnitProperty{Name: "owner", Value: test.Owner})
+	}
+	url := opts.xmlText(sourceURL(test, pkgName, opts))
+	if url != "" {
+		props = append(props, JUnitProperty{Name: "source.url", Value: url})
+	}
+	if len(props) > 0 {
+		tc.Properties = &JUnitProperties{props}
+	}
+	output := opts.xmlText(formatOutput(test.Output, opts.StripANSIEscape))
+	if source := "Source: " + url; url != "" && output != source && !strings.HasSuffix(output, "\n"+source) {
+		// the output of a test read from a report already ends with it
+		output = strings.Join(append(nonEmpty(output), source), "\n")
+	}
+	var stdout string
+
+	switch test.Result {
+	case parser.SKIP:
+		// only the reason is used as message, the rest of the output is
+		// written as <system-out> of the testcase
+		reason, rest := test.SkipReason()
+		if test.Quarantined {
+			// the failure of a quarantined test is kept in the output
+			reason, rest = []string{"Quarantined failure"}, test.Output
+		}
+		tc.SkipMessage = &JUnitSkipMessage{
+			Message: shortenMessage(opts.xmlText(formatOutput(reason, opts.StripANSIEscape)), opts.MessageLength),
+		}
+		stdout = opts.xmlText(formatOutput(rest, opts.StripANSIEscape))
+	case parser.ERROR:
+		tc.Error = &JUnitError{
+			Message:  failureMessage(test, "Error", opts),
+			Type:     test.ErrorType,
+			Contents: output,
+		}
+		if opts.CDATA {
+			tc.Error.Contents, tc.Error.CDATA = "", output
+		}
+	case parser.FAIL:
+		tc.Failure = &JUnitFailure{
+			Message:  failureMessage(test, "Failed", opts),
+			Type:     "",
+			Contents: output,
+		}
+		if opts.CDATA {
+			tc.Failure.Contents, tc.Failure.CDATA = "", output
+		}
+	case parser.PASS:
+		if opts.TestcaseSystemOut {
+			stdout = output
+		} else {
+			tc.SystemOut = escapeComment(output)
+		}
+	}
+	if markers := attachmentMarkers(test.Attachments, stdout); len(markers) > 0 {
+		stdout = opts.xmlText(strings.Join(append(nonEmpty(stdout), markers...), "\n"))
+	}
+	if stdout != "" {
+		tc.Stdout = &JUnitOutput{Contents: stdout}
+		if opts.CDATA {
+			tc.Stdout.Contents, tc.Stdout.CDATA = "", stdout
+		}
+	}
+	return tc
+}
+
+// topLevelTest splits the name of a subtest, e.g. TestTable/case/sub, into
+// its top-level test and its path below it. Top-level tests with subtests are
+// returned as both. It returns empty strings for other tests.
+func topLevelTest(test *parser.Test) (top, sub string) {
+	if i := strings.Index(test.Name, "/"); i >= 0 {
+		return test.Name[:i], test.Name[i+1:]
+	}
+	if len(test.Subtests) > 0 {
+		return test.Name, test.Name
+	}
+	return "", ""
+}
+
+// CheckGroupSubtests returns an error if mode is not a valid value of
+// Options.GroupSubtests.
+func CheckGroupSubtests(mode string) error {
+	switch mode {
+	case "", "classname", "suite":
+		return nil
+	}
+	return fmt.Errorf("unknown mode %q, use classname or suite", mode)
+}
+
+// suiteMethod splits the name of a subtest that is a method of a testify
+// suite, e.g. TestMySuite/TestSomething, into the suite and the method with
+// its subtests. It returns empty strings for other tests.
+func suiteMethod(name string) (suite, method string) {
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) < 2 || !strings.HasPrefix(parts[1], "Test") {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+// attachmentMarkers returns the [[ATTACHMENT|path]] markers of the Jenkins
+// attachments plugin for the attachments that don't occur in output yet.
+func attachmentMarkers(attachments []string, output string) []string {
+	var markers []string
+	for _, path := range attachments {
+		marker := "[[ATTACHMENT|" + path + "]]"
+		if !strings.Contains(output, marker) {
+			markers = append(markers, marker)
+		}
+	}
+	return markers
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+// failureMessage returns the message of a failed test, the first line of its
+// output if Options.MessageLength is set, or def.
+func failureMessage(test *parser.Test, def string, opts Options) string {
+	if opts.MessageLength <= 0 {
+		return def
+	}
+	line, _ := firstOutputLine(test)
+	if line == "" {
+		return def
+	}
+	if opts.StripANSIEscape {
+		line = stripansi.Strip(line)
+	}
+	return shortenMessage(opts.xmlText(line), opts.MessageLength)
+}
 
-	return nil
+// setTestFile sets the file attribute or classname of tc to the file of test
+// if requested by opts.
+func setTestFile(tc *JUnitTestCase, test *parser.Test, pkgName string, opts Options) {
+	if !opts.FileAttr && !opts.FileClassname {
+		return
+	}
+	file := opts.xmlText(testFile(test, pkgName, opts))
+	if opts.FileAttr {
+		tc.File = file
+	}
+	if opts.FileClassname && file != "" {
+		tc.Classname = file
+	}
+}
+
+var regexLogLocation = regexp.MustCompile(`^\s*(\S+\.go):(\d+): `)
+
+// testFile returns test.File or the file of the first location logged by test,
+// relative to the module if pkgName is part of it, or an empty string if the
+// test didn't log anything.
+func testFile(test *parser.Test, pkgName string, opts Options) string {
+	if test.File != "" {
+		return test.File
+	}
+	file, _ := logLocation(test, pkgName, opts)
+	return file
+}
+
+// logLocation returns the file and line of the first location logged by test,
+// with the file relative to the module if pkgName is part of it, or empty
+// strings if the test didn't log anything.
+func logLocation(test *parser.Test, pkgName string, opts Options) (file, line string) {
+	test.EachOutputLine(func(s string) error {
+		if opts.StripANSIEscape {
+			s = stripansi.Strip(s)
+		}
+		if matches := regexLogLocation.FindStringSubmatch(s); matches != nil {
+			file, line = matches[1], matches[2]
+			return errFound
+		}
+		return nil
+	})
+	if file == "" || path.IsAbs(file) || strings.Contains(file, "/") {
+		return file, line
+	}
+	return path.Join(relativePath(pkgName, opts.Module), file), line
+}
+
+// sourceURL returns the link to the first location logged by a failed test
+// made from opts.SourceURL, or an empty string if there's none.
+func sourceURL(test *parser.Test, pkgName string, opts Options) string {
+	if opts.SourceURL == "" || (test.Result != parser.FAIL && test.Result != parser.ERROR) {
+		return ""
+	}
+	file, line := logLocation(test, pkgName, opts)
+	if file == "" || path.IsAbs(file) {
+		return ""
+	}
+	url := strings.Replace(opts.SourceURL, "{file}", file, -1)
+	return strings.Replace(url, "{line}", line, -1)
+}
+
+// errFound stops the iteration of output lines once a line is found.
+var errFound = errors.New("found")
+
+// firstOutputLine returns the first non-empty line of output of test, without
+// leading and trailing whitespace.
+func firstOutputLine(test *parser.Test) (string, error) {
+	var first string
+	err := test.EachOutputLine(func(line string) error {
+		if first = strings.TrimSpace(line); first != "" {
+			return errFound
+		}
+		return nil
+	})
+	if err == errFound {
+		err = nil
+	}
+	return first, err
+}
+
+// shortenMessage shortens message to at most n bytes, ending it with "..." if
+// it was shortened. Messages are not shortened if n is zero.
+func shortenMessage(message string, n int) string {
+	if n <= 0 || len(message) <= n {
+		return message
+	}
+	if n <= 3 {
+		return truncate(message, n)
+	}
+	return truncate(message, n-3) + "..."
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05")
+}
+
+// formatTestTimestamp formats the start time of a test with milliseconds, to
+// tell apart tests that ran in parallel.
+func formatTestTimestamp(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05.000")
+}
+
+// suiteStats returns properties describing the test durations and output size
+// of pkg.
+func suiteStats(pkg parser.Package, stripANSIEscape bool) []JUnitProperty {
+	var slowest *parser.Test
+	var total time.Duration
+	var outputBytes, outputLines int
+	for _, test := range pkg.Tests {
+		if slowest == nil || test.Duration > slowest.Duration {
+			slowest = test
+		}
+		total += test.Duration
+		// errors reading spilled output are returned when the output of
+		// the test is written
+		first := true
+		test.EachOutputLine(func(line string) error {
+			if !first {
+				outputBytes++
+			}
+			first = false
+			outputBytes += len(formatOutput([]string{line}, stripANSIEscape))
+			outputLines++
+			return nil
+		})
+	}
+
+	var props []JUnitProperty
+	if slowest != nil {
+		mean := total / time.Duration(len(pkg.Tests))
+		props = append(props,
+			JUnitProperty{"tests.slowest", slowest.Name},
+			JUnitProperty{"tests.slowest_ms", formatMillis(slowest.Duration)},
+			JUnitProperty{"tests.mean_ms", formatMillis(mean)},
+		)
+	}
+	props = append(props,
+		JUnitProperty{"output.bytes", fmt.Sprint(outputBytes)},
+		JUnitProperty{"output.lines", fmt.Sprint(outputLines)},
+	)
+	return props
+}
+
+func formatMillis(d time.Duration) string {
+	return fmt.Sprintf("%.3f", float64(d)/float64(time.Millisecond))
 }
 
 func formatTime(d time.Duration) string {
 	return fmt.Sprintf("%.9f", d.Seconds())
 }
 
+// escapeCDATA escapes s for use in a CDATA section, by splitting the section
+// at each "]]>" in s.
+func escapeCDATA(s string) string {
+	return strings.Replace(s, "]]>", "]]]]><![CDATA[>", -1)
+}
+
+// xmlText returns s with the characters that are not allowed in XML replaced
+// by the placeholder of o.
+func (o Options) xmlText(s string) string {
+	return sanitizeXML(s, o.InvalidCharPlaceholder)
+}
+
 func formatOutput(lines []string, stripANSIEscape bool) string {
 	joined := strings.Join(lines, "\n")
 	if stripANSIEscape {
@@ -3,7 +3,14 @@ package formatter
 import (
 	"bytes"
 	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
 )
 
 func TestSuites_Unmarshal(t *testing.T) {
@@ -62,3 +69,544 @@ func TestSuites_Unmarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestXMLDeclaration(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, xml.Header + "<testsuites"},
+		{Options{NoXMLHeader: true}, "<testsuites"},
+		{Options{XMLEncoding: "utf-8", XMLStandalone: "yes"}, `<?xml version="1.0" encoding="utf-8" standalone="yes"?>` + "\n<testsuites"},
+		{Options{NoXMLHeader: true, XMLDoctype: `testsuites SYSTEM "junit.dtd"`}, `<!DOCTYPE testsuites SYSTEM "junit.dtd">` + "\n<testsuites"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := WriteJUnitXML(&parser.Report{}, test.opts, &buf); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(buf.String(), test.want) {
+			t.Errorf("report with %+v starts with %q, want %q", test.opts, buf.String(), test.want)
+		}
+	}
+
+	for _, invalid := range [][2]string{{"UTF 8", ""}, {"ISO-8859-1", ""}, {"UTF-16", ""}, {"", "true"}} {
+		if err := CheckXMLDeclaration(invalid[0], invalid[1]); err == nil {
+			t.Errorf("CheckXMLDeclaration(%q, %q) returned no error", invalid[0], invalid[1])
+		}
+	}
+	for _, encoding := range []string{"", "UTF-8", "utf-8", "utf8"} {
+		if err := CheckXMLDeclaration(encoding, ""); err != nil {
+			t.Errorf("CheckXMLDeclaration(%q) returned error: %s", encoding, err)
+		}
+	}
+}
+
+func TestIndent(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{{Name: "a", Tests: []*parser.Test{{Name: "TestA", Result: parser.PASS}}}}}
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{Options{NoXMLHeader: true}, "\n\t<testsuite "},
+		{Options{NoXMLHeader: true, Indent: "  "}, "\n  <testsuite "},
+		{Options{NoXMLHeader: true, Compact: true, Indent: "  "}, `time="0.000000000"><testsuite `},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := WriteJUnitXML(report, test.opts, &buf); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), test.want) {
+			t.Errorf("report with %+v does not contain %q:\n%s", test.opts, test.want, buf.String())
+		}
+		if test.opts.Compact && strings.Count(buf.String(), "\n") != 1 {
+			t.Errorf("compact report has more than one line:\n%s", buf.String())
+		}
+	}
+}
+
+func TestSuiteStats(t *testing.T) {
+	pkg := parser.Package{
+		Name: "package/name",
+		Tests: []*parser.Test{
+			{Name: "TestA", Duration: 10 * time.Millisecond, Output: []string{"line one", "line two"}},
+			{Name: "TestB", Duration: 30 * time.Millisecond, Output: []string{"x"}},
+		},
+	}
+
+	want := []JUnitProperty{
+		{"tests.slowest", "TestB"},
+		{"tests.slowest_ms", "30.000"},
+		{"tests.mean_ms", "20.000"},
+		{"output.bytes", "18"},
+		{"output.lines", "3"},
+	}
+	if got := suiteStats(pkg, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("suiteStats() = %v, want %v", got, want)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestWriteJUnitXMLError(t *testing.T) {
+	tests := make([]*parser.Test, 1000)
+	for i := range tests {
+		tests[i] = &parser.Test{Name: fmt.Sprintf("Test%d", i), Output: []string{strings.Repeat("x", 100)}}
+	}
+	report := &parser.Report{Packages: []parser.Package{{Name: "pkg", Tests: tests}}}
+	if err := WriteJUnitXML(report, Options{}, failingWriter{}); err == nil {
+		t.Errorf("WriteJUnitXML did not return the write error")
+	}
+}
+
+func TestWriteJUnitXMLCDATA(t *testing.T) {
+	output := []string{"panic: <nil> & friends", "\tdata[x[0]]>1"}
+	report := &parser.Report{Packages: []parser.Package{{
+		Name: "pkg",
+		Tests: []*parser.Test{
+			{Name: "TestFail", Result: parser.FAIL, Output: output},
+			{Name: "TestError", Result: parser.ERROR, Output: output},
+			{Name: "TestPass", Result: parser.PASS, Output: output},
+		},
+	}}}
+
+	var buf bytes.Buffer
+	if err := WriteJUnitXML(report, Options{CDATA: true, TestcaseSystemOut: true}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "<![CDATA[panic: <nil> & friends\n\tdata[x[0]]]]><![CDATA[>1]]>"
+	if n := strings.Count(buf.String(), want); n != 3 {
+		t.Fatalf("output contains %d CDATA sections %q, want 3:\n%s", n, want, buf.String())
+	}
+	if !strings.Contains(buf.String(), "<system-out>"+want+"</system-out>") {
+		t.Errorf("testcase output is not written as CDATA section:\n%s", buf.String())
+	}
+
+	parsed, err := ParseJUnit(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range parsed.Packages[0].Tests {
+		if !reflect.DeepEqual(test.Output, output) {
+			t.Errorf("output of %s == %q, want %q", test.Name, test.Output, output)
+		}
+	}
+}
+
+func TestTestCaseOptions(t *testing.T) {
+	opts := Options{
+		Timestamp:         time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		MessageLength:     16,
+		TestcaseSystemOut: true,
+	}
+	tests := []struct {
+		test *parser.Test
+		want JUnitTestCase
+	}{
+		{
+			&parser.Test{Name: "TestFail", Result: parser.FAIL, Output: []string{"", "a_test.go:1: expected 1, got 2", "more"}},
+			JUnitTestCase{Failure: &JUnitFailure{Message: "a_test.go:1: ...", Contents: "\na_test.go:1: expected 1, got 2\nmore"}},
+		},
+		{
+			&parser.Test{Name: "TestError", Result: parser.ERROR},
+			JUnitTestCase{Error: &JUnitError{Message: "Error"}},
+		},
+		{
+			&parser.Test{Name: "TestSkip", Result: parser.SKIP, Output: []string{"a_test.go:2: log", "a_test.go:3: no network"}},
+			JUnitTestCase{SkipMessage: &JUnitSkipMessage{Message: "a_test.go:3: ..."}, Stdout: &JUnitOutput{Contents: "a_test.go:2: log"}},
+		},
+		{
+			&parser.Test{Name: "TestPass", Result: parser.PASS, Output: []string{"a_test.go:4: ok"}},
+			JUnitTestCase{Stdout: &JUnitOutput{Contents: "a_test.go:4: ok"}},
+		},
+	}
+	for _, test := range tests {
+		want := test.want
+		want.Classname, want.Name, want.Time = "pkg", test.test.Name, "0.000000000"
+		want.Timestamp = "2020-01-02T03:04:05"
+		if got := testCase(test.test, "example.com/pkg", "pkg", opts); !reflect.DeepEqual(got, want) {
+			t.Errorf("testCase(%s) == %+v, want %+v", test.test.Name, got, want)
+		}
+	}
+}
+
+func TestTestFile(t *testing.T) {
+	tests := []struct {
+		output      []string
+		pkg, module string
+		want        string
+	}{
+		{nil, "example.com/mod/a", "example.com/mod", ""},
+		{[]string{"not a location", "a_test.go:10: failed"}, "example.com/mod/a", "example.com/mod", "a/a_test.go"},
+		{[]string{"a_test.go:10: failed"}, "example.com/mod", "example.com/mod", "a_test.go"},
+		{[]string{"a_test.go:10: failed"}, "other.com/a", "example.com/mod", "other.com/a/a_test.go"},
+		{[]string{"/src/mod/a/a_test.go:10: failed"}, "example.com/mod/a", "example.com/mod", "/src/mod/a/a_test.go"},
+	}
+	for _, test := range tests {
+		got := testFile(&parser.Test{Output: test.output}, test.pkg, Options{Module: test.module})
+		if got != test.want {
+			t.Errorf("testFile(%q, %q, %q) == %q, want %q", test.output, test.pkg, test.module, got, test.want)
+		}
+	}
+}
+
+func TestFileClassname(t *testing.T) {
+	opts := Options{FileAttr: true, FileClassname: true}
+	tc := testCase(&parser.Test{Name: "TestA", File: "a/a_test.go"}, "example.com/mod/a", "a", opts)
+	if tc.Classname != "a/a_test.go" || tc.File != "a/a_test.go" {
+		t.Errorf("testcase classname == %q, file == %q, want a/a_test.go", tc.Classname, tc.File)
+	}
+	tc = testCase(&parser.Test{Name: "TestB"}, "example.com/mod/a", "a", opts)
+	if tc.Classname != "a" || tc.File != "" {
+		t.Errorf("testcase without file: classname == %q, file == %q, want a and none", tc.Classname, tc.File)
+	}
+}
+
+func TestSuiteClassname(t *testing.T) {
+	tests := []struct {
+		name, wantClass, wantName string
+	}{
+		{"TestMySuite/TestSomething", "a.TestMySuite", "TestSomething"},
+		{"TestMySuite/TestSomething/case", "a.TestMySuite", "TestSomething/case"},
+		{"TestMySuite", "a", "TestMySuite"},
+		{"TestTable/case_1", "a", "TestTable/case_1"},
+	}
+	for _, test := range tests {
+		tc := testCase(&parser.Test{Name: test.name}, "example.com/a", "a", Options{SuiteClassname: true})
+		if tc.Classname != test.wantClass || tc.Name != test.wantName {
+			t.Errorf("testcase of %s: classname == %q, name == %q, want %q and %q", test.name, tc.Classname, tc.Name, test.wantClass, test.wantName)
+		}
+	}
+}
+
+func TestGroupSubtests(t *testing.T) {
+	tests := []*parser.Test{{Name: "TestTable"}, {Name: "TestTable/case_1"}, {Name: "TestTable/case_1/sub"}, {Name: "TestFlat"}}
+	(&parser.Report{Packages: []parser.Package{{Tests: tests}}}).LinkSubtests()
+	want := [][2]string{
+		{"a/TestTable", "TestTable"},
+		{"a/TestTable", "case_1"},
+		{"a/TestTable", "case_1/sub"},
+		{"a", "TestFlat"},
+	}
+	for i, test := range tests {
+		tc := testCase(test, "example.com/a", "a", Options{GroupSubtests: "classname"})
+		if got := [2]string{tc.Classname, tc.Name}; got != want[i] {
+			t.Errorf("testcase of %s: classname and name == %q, want %q", test.Name, got, want[i])
+		}
+	}
+	if err := CheckGroupSubtests("package"); err == nil {
+		t.Errorf("CheckGroupSubtests(%q) returned no error", "package")
+	}
+}
+
+func TestModuleClassname(t *testing.T) {
+	tests := []struct {
+		pkgName, module, want string
+	}{
+		{"example.com/mod/pkg/a", "example.com/mod", "example.com/mod:pkg/a"},
+		{"example.com/mod", "example.com/mod", "example.com/mod:."},
+		{"example.com/other/a", "example.com/mod", "example.com/other/a"},
+		{"example.com/mod/pkg/a", "", "a"},
+	}
+	for _, test := range tests {
+		opts := Options{ModuleClassname: true, Module: test.module}
+		if got := packageClassname(test.pkgName, opts); got != test.want {
+			t.Errorf("packageClassname(%q) with module %q == %q, want %q", test.pkgName, test.module, got, test.want)
+		}
+	}
+}
+
+func TestGroupSubtestsSuite(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{{
+		Name: "example.com/a",
+		Tests: []*parser.Test{
+			{Name: "TestTable", Result: parser.FAIL, Duration: time.Second},
+			{Name: "TestFlat", Result: parser.PASS},
+			{Name: "TestTable/case_1", Result: parser.FAIL},
+		},
+	}}}
+	report.LinkSubtests()
+
+	var buf bytes.Buffer
+	if err := WriteJUnitXML(report, Options{GroupSubtests: "suite"}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<testsuites tests="3" failures="2" errors="0" skipped="0" time="0.000000000">`,
+		`<testsuite tests="1" failures="0" errors="0" skipped="0" time="0.000000000" name="example.com/a">`,
+		`<testsuite tests="2" failures="2" errors="0" skipped="0" time="1.000000000" name="example.com/a/TestTable">`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report does not contain %q:\n%s", want, buf.String())
+		}
+	}
+
+	// the package suite is left out if all its tests are in subtest suites
+	report = &parser.Report{Packages: []parser.Package{{
+		Name: "example.com/a",
+		Tests: []*parser.Test{
+			{Name: "TestTable", Result: parser.PASS},
+			{Name: "TestTable/case_1", Result: parser.PASS},
+		},
+	}}}
+	report.LinkSubtests()
+	buf.Reset()
+	if err := WriteJUnitXML(report, Options{GroupSubtests: "suite"}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "<testsuite "); n != 1 || strings.Contains(buf.String(), `name="example.com/a">`) {
+		t.Errorf("report with only subtest suites contains %d testsuites, want only example.com/a/TestTable:\n%s", n, buf.String())
+	}
+}
+
+func TestDuration(t *testing.T) {
+	start := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	test := &parser.Test{Name: "TestA", Duration: 100 * time.Millisecond, Start: start, End: start.Add(400 * time.Millisecond), Paused: 300 * time.Millisecond}
+	for kind, want := range map[string]string{"go": "0.100000000", "wall": "0.400000000", "active": "0.100000000"} {
+		if err := CheckDuration(kind); err != nil {
+			t.Errorf("CheckDuration(%q) error: %s", kind, err)
+		}
+		if tc := testCase(test, "example.com/a", "a", Options{Duration: kind}); tc.Time != want {
+			t.Errorf("testcase time with duration %s == %s, want %s", kind, tc.Time, want)
+		}
+	}
+	if err := CheckDuration("cpu"); err == nil {
+		t.Errorf("CheckDuration(%q) returned no error", "cpu")
+	}
+
+	tc := testCase(test, "example.com/a", "a", Options{PausedProperty: true})
+	want := &JUnitProperties{[]JUnitProperty{{Name: "paused", Value: "0.300000000"}}}
+	if !reflect.DeepEqual(tc.Properties, want) {
+		t.Errorf("testcase properties == %+v, want %+v", tc.Properties, want)
+	}
+}
+
+func TestAttachmentMarkers(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{{
+		Name:        "example.com/a",
+		Output:      []string{"[[ATTACHMENT|setup.log]]"},
+		Attachments: []string{"setup.log", "a.log"},
+		Tests: []*parser.Test{
+			{Name: "TestA", Result: parser.FAIL, Output: []string{"[[ATTACHMENT|a.png]]"}, Attachments: []string{"a.png"}},
+			{Name: "TestB", Result: parser.PASS, Output: []string{"[[ATTACHMENT|b.png]]"}, Attachments: []string{"b.png"}},
+		},
+	}}}
+
+	var buf bytes.Buffer
+	if err := WriteJUnitXML(report, Options{TestcaseSystemOut: true}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"</failure>\n\t\t\t<system-out>[[ATTACHMENT|a.png]]</system-out>",
+		"<system-out>[[ATTACHMENT|b.png]]</system-out>",
+		"<system-out>[[ATTACHMENT|setup.log]]&#xA;[[ATTACHMENT|a.log]]</system-out>",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestPlatformProperties(t *testing.T) {
+	pkg := parser.Package{Name: "a"}
+	opts := Options{GoVersion: "1.0", GoOS: "linux", GoArch: "arm64", NumCPU: 8}
+	want := []JUnitProperty{
+		{"go.version", "1.0"},
+		{"go.os", "linux"},
+		{"go.arch", "arm64"},
+		{"runtime.numcpu", "8"},
+	}
+	if got := suiteProperties(pkg, opts); !reflect.DeepEqual(got, want) {
+		t.Errorf("suiteProperties() == %v, want %v", got, want)
+	}
+	if got := suiteProperties(pkg, Options{GoVersion: "1.0"}); len(got) != 1 {
+		t.Errorf("suiteProperties() without platform == %v, want only go.version", got)
+	}
+
+	var buf bytes.Buffer
+	report := &parser.Report{Packages: []parser.Package{pkg}}
+	if err := WriteJUnitXML(report, opts, &buf); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseJUnit(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := Options{GoVersion: "1.0", GoOS: "windows", GoArch: "amd64", NumCPU: 2}
+	if got := suiteProperties(parsed.Packages[0], other); !reflect.DeepEqual(got, want) {
+		t.Errorf("suiteProperties() of parsed package == %v, want %v", got, want)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{{
+		Name:       "example.com/a",
+		Tests:      []*parser.Test{},
+		BuildError: &parser.BuildError{Name: "[build failed]", Output: []string{"a.go:1: undefined: x"}},
+	}}}
+
+	tests := []struct {
+		mode                      string
+		suiteError, testcaseError bool
+		tests                     string
+	}{
+		{"", false, true, `tests="1"`},
+		{"testcase", false, true, `tests="1"`},
+		{"suite", true, false, `tests="0"`},
+		{"both", true, true, `tests="1"`},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := WriteJUnitXML(report, Options{BuildErrors: test.mode}, &buf); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		suiteError := strings.Contains(out, `<error message="[build failed]" type="">a.go:1: undefined: x</error>`)
+		testcaseError := strings.Contains(out, `<testcase classname="a" name="[build failed]"`)
+		if suiteError != test.suiteError || testcaseError != test.testcaseError {
+			t.Errorf("mode %q: suite error %v, testcase %v, want %v, %v:\n%s", test.mode, suiteError, testcaseError, test.suiteError, test.testcaseError, out)
+		}
+		if !strings.Contains(out, test.tests+` failures="0" errors="1"`) {
+			t.Errorf("mode %q: counts are not %s with 1 error:\n%s", test.mode, test.tests, out)
+		}
+
+		parsed, err := ParseJUnit(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pkg := parsed.Packages[0]; !reflect.DeepEqual(pkg.BuildError, report.Packages[0].BuildError) || len(pkg.Tests) != 0 {
+			t.Errorf("mode %q: parsed build error %+v and %d tests", test.mode, pkg.BuildError, len(pkg.Tests))
+		}
+	}
+
+	if err := CheckBuildErrors("unknown"); err == nil {
+		t.Errorf("CheckBuildErrors did not return an error for an unknown mode")
+	}
+}
+
+func TestVetErrorType(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{{
+		Name:       "example.com/a",
+		Tests:      []*parser.Test{},
+		BuildError: &parser.BuildError{Name: "[build failed]", Output: []string{"./a.go:3:2: unreachable code"}, Type: "vet"},
+	}}}
+
+	for _, mode := range []string{"testcase", "suite"} {
+		var buf bytes.Buffer
+		if err := WriteJUnitXML(report, Options{BuildErrors: mode}, &buf); err != nil {
+			t.Fatal(err)
+		}
+		if out := buf.String(); !strings.Contains(out, `type="vet">./a.go:3:2: unreachable code</error>`) {
+			t.Errorf("mode %q: no error with type vet:\n%s", mode, out)
+		}
+		parsed, err := ParseJUnit(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := parsed.Packages[0].BuildError; got == nil || got.Type != "vet" {
+			t.Errorf("mode %q: parsed build error %+v, want type vet", mode, got)
+		}
+	}
+}
+
+func TestQuarantined(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{{
+		Name: "example.com/a",
+		Tests: []*parser.Test{
+			{Name: "TestFlaky", Result: parser.SKIP, Quarantined: true, Output: []string{"a_test.go:12: timed out"}},
+		},
+	}}}
+
+	var buf bytes.Buffer
+	if err := WriteJUnitXML(report, Options{}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<property name="quarantined" value="true"></property>`,
+		`<skipped message="Quarantined failure"></skipped>`,
+		`<system-out>a_test.go:12: timed out</system-out>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report does not contain %s:\n%s", want, out)
+		}
+	}
+
+	parsed, err := ParseJUnit(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if test := parsed.Packages[0].Tests[0]; !test.Quarantined || !reflect.DeepEqual(test.Output, []string{"a_test.go:12: timed out"}) {
+		t.Errorf("parsed test %+v, want the quarantined test", test)
+	}
+}
+
+func TestFailureIgnored(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{{
+		Name:  "example.com/a",
+		Tests: []*parser.Test{{Name: "TestFetch", Result: parser.PASS, FailureIgnored: true}},
+	}}}
+
+	var buf bytes.Buffer
+	if err := WriteJUnitXML(report, Options{}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := `<property name="failure-ignored" value="true"></property>`; !strings.Contains(buf.String(), want) {
+		t.Errorf("report does not contain %s:\n%s", want, buf.String())
+	}
+	parsed, err := ParseJUnit(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if test := parsed.Packages[0].Tests[0]; !test.FailureIgnored || test.Result != parser.PASS {
+		t.Errorf("parsed test %+v, want the passed test with an ignored failure", test)
+	}
+}
+
+func TestSourceURL(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{{
+		Name: "example.com/mod/pkg",
+		Tests: []*parser.Test{
+			{Name: "TestFail", Result: parser.FAIL, Output: []string{"    pkg_test.go:12: got 1, want 2"}},
+			{Name: "TestPass", Result: parser.PASS, Output: []string{"    pkg_test.go:20: ok"}},
+			{Name: "TestPanic", Result: parser.FAIL, Output: []string{"panic: boom"}},
+		},
+	}}}
+	opts := Options{Module: "example.com/mod", SourceURL: "https://example.com/blob/main/{file}#L{line}"}
+
+	var buf bytes.Buffer
+	if err := WriteJUnitXML(report, opts, &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	url := "https://example.com/blob/main/pkg/pkg_test.go#L12"
+	for _, want := range []string{
+		`<property name="source.url" value="` + url + `"></property>`,
+		"got 1, want 2&#xA;Source: " + url + "</failure>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report does not contain %q:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "source.url"); n != 1 {
+		t.Errorf("report contains %d source.url properties, want 1:\n%s", n, out)
+	}
+
+	// merging the report writes it again
+	parsed, err := ParseJUnit(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again bytes.Buffer
+	if err := WriteJUnitXML(parsed, opts, &again); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(again.String(), "Source: "); n != 1 {
+		t.Errorf("report written again contains %d Source lines, want 1:\n%s", n, again.String())
+	}
+}
@@ -0,0 +1,97 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// DefaultHistogramBuckets are the upper bounds of the duration buckets of
+// histograms if Options.HistogramBuckets is empty.
+var DefaultHistogramBuckets = []time.Duration{10 * time.Millisecond, 100 * time.Millisecond, time.Second, 10 * time.Second}
+
+// ParseHistogramBuckets parses a comma separated list of ascending
+// durations, such as 10ms,1s, for Options.HistogramBuckets.
+func ParseHistogramBuckets(list string) ([]time.Duration, error) {
+	var buckets []time.Duration
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		if len(buckets) > 0 && d <= buckets[len(buckets)-1] {
+			return nil, fmt.Errorf("bucket %s is not longer than the previous one", s)
+		}
+		buckets = append(buckets, d)
+	}
+	return buckets, nil
+}
+
+// DurationHistogram returns the number of tests in each bucket of durations
+// shorter than the ascending upper bounds in buckets, plus the number of
+// tests that took longer in the last element. Tests with subtests are not
+// counted, as their duration includes that of their subtests.
+func DurationHistogram(tests []*parser.Test, buckets []time.Duration) []int {
+	parents := map[string]bool{}
+	for _, test := range tests {
+		if i := strings.LastIndex(test.Name, "/"); i > 0 {
+			parents[test.Name[:i]] = true
+		}
+	}
+	counts := make([]int, len(buckets)+1)
+	for _, test := range tests {
+		if parents[test.Name] {
+			continue
+		}
+		i := 0
+		for i < len(buckets) && test.Duration >= buckets[i] {
+			i++
+		}
+		counts[i]++
+	}
+	return counts
+}
+
+// WriteHistogramMarkdown writes a markdown table of the number of tests per
+// duration bucket of each package in report, and of all packages, to w.
+func WriteHistogramMarkdown(report *parser.Report, buckets []time.Duration, w io.Writer) error {
+	if len(buckets) == 0 {
+		buckets = DefaultHistogramBuckets
+	}
+	var err error
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	printf("## Test durations\n\n| Package |")
+	for _, b := range buckets {
+		printf(" < %s |", b)
+	}
+	printf(" >= %s |\n| --- |%s\n", buckets[len(buckets)-1], strings.Repeat(" ---: |", len(buckets)+1))
+
+	total := make([]int, len(buckets)+1)
+	row := func(name string, counts []int) {
+		printf("| %s |", name)
+		for _, n := range counts {
+			printf(" %d |", n)
+		}
+		printf("\n")
+	}
+	for _, pkg := range report.Packages {
+		counts := DurationHistogram(pkg.Tests, buckets)
+		for i, n := range counts {
+			total[i] += n
+		}
+		row(markdownCell(pkg.Name), counts)
+	}
+	row("**All packages**", total)
+	return err
+}
@@ -0,0 +1,53 @@
+package formatter
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestDurationHistogram(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{
+		{Name: "a", Tests: []*parser.Test{
+			{Name: "TestFast", Duration: 5 * time.Millisecond},
+			{Name: "TestTable", Duration: 3 * time.Second},
+			{Name: "TestTable/slow", Duration: 2 * time.Second},
+			{Name: "TestTable/fast", Duration: time.Second},
+		}},
+		{Name: "b|c", Tests: []*parser.Test{
+			{Name: "TestHuge", Duration: time.Minute},
+			{Name: "TestBound", Duration: 100 * time.Millisecond},
+		}},
+	}}
+
+	if counts := DurationHistogram(report.Packages[0].Tests, DefaultHistogramBuckets); !reflect.DeepEqual(counts, []int{1, 0, 0, 2, 0}) {
+		t.Errorf("DurationHistogram() == %v, want [1 0 0 2 0]", counts)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteHistogramMarkdown(report, nil, &buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `## Test durations
+
+| Package | < 10ms | < 100ms | < 1s | < 10s | >= 10s |
+| --- | ---: | ---: | ---: | ---: | ---: |
+| a | 1 | 0 | 0 | 2 | 0 |
+| b\|c | 0 | 0 | 1 | 0 | 1 |
+| **All packages** | 1 | 0 | 1 | 2 | 1 |
+`
+	if buf.String() != expected {
+		t.Errorf("WriteHistogramMarkdown() ==\n%s\nwant\n%s", buf.String(), expected)
+	}
+
+	buckets, err := ParseHistogramBuckets("1ms, 1s")
+	if err != nil || !reflect.DeepEqual(buckets, []time.Duration{time.Millisecond, time.Second}) {
+		t.Errorf("ParseHistogramBuckets() == %v, %v", buckets, err)
+	}
+	if _, err := ParseHistogramBuckets("1s,1s"); err == nil {
+		t.Errorf("ParseHistogramBuckets() returned no error for buckets that are not ascending")
+	}
+}
@@ -0,0 +1,16 @@
+package formatter
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// WriteJSON writes the report as indented JSON to w. See the parser package
+// for a description of the encoding.
+func WriteJSON(report *parser.Report, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -0,0 +1,220 @@
+package formatter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// The junit* types are used to read JUnit XML written by other tools, which
+// may use elements that WriteJUnitXML doesn't, such as <system-out> in
+// testcases or nested testsuites.
+type junitSuites struct {
+	Suites    []junitSuite `xml:"testsuite"`
+	SystemErr string       `xml:"system-err"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []JUnitProperty `xml:"properties>property"`
+	TestCases  []junitCase     `xml:"testcase"`
+	Suites     []junitSuite    `xml:"testsuite"`
+	Error      *junitMessage   `xml:"error"`
+	SystemOut  string          `xml:"system-out"`
+}
+
+type junitCase struct {
+	Name       string          `xml:"name,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []JUnitProperty `xml:"properties>property"`
+	Skipped    *junitMessage   `xml:"skipped"`
+	Error      *junitMessage   `xml:"error"`
+	Failure    *junitMessage   `xml:"failure"`
+	SystemOut  string          `xml:"system-out"`
+	Comment    string          `xml:",comment"`
+}
+
+type junitMessage struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",chardata"`
+}
+
+// ParseJUnit reads a JUnit XML report, as written by WriteJUnitXML or other
+// tools, and returns it as a Report. Each testsuite becomes a package, nested
+// testsuites are flattened. The go.version property is dropped, as it's added
+// again when the report is written. The go.os, go.arch and runtime.numcpu
+// properties are kept, they are written instead of those of Options.
+func ParseJUnit(r io.Reader) (*parser.Report, error) {
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no testsuites or testsuite element found")
+		} else if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		var suites junitSuites
+		switch start.Name.Local {
+		case "testsuites":
+			err = dec.DecodeElement(&suites, &start)
+		case "testsuite":
+			suites.Suites = make([]junitSuite, 1)
+			err = dec.DecodeElement(&suites.Suites[0], &start)
+		default:
+			return nil, fmt.Errorf("unexpected root element <%s>", start.Name.Local)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		report := &parser.Report{}
+		if suites.SystemErr != "" {
+			report.Stderr = splitOutput(suites.SystemErr)
+		}
+		addSuites(report, suites.Suites)
+		report.LinkSubtests()
+		return report, nil
+	}
+}
+
+func addSuites(report *parser.Report, suites []junitSuite) {
+	for _, suite := range suites {
+		if len(suite.TestCases) > 0 || len(suite.Suites) == 0 {
+			report.Packages = append(report.Packages, suitePackage(suite))
+		}
+		addSuites(report, suite.Suites)
+	}
+}
+
+// regexBuildError matches the names of testcases written for build errors.
+var regexBuildError = regexp.MustCompile(`^\[\w+ failed\]$`)
+
+func suitePackage(suite junitSuite) parser.Package {
+	duration := parseJUnitTime(suite.Time)
+	pkg := parser.Package{
+		Name:     suite.Name,
+		Duration: duration,
+		Tests:    []*parser.Test{},
+		Time:     int(duration / time.Millisecond),
+	}
+	if suite.SystemOut != "" {
+		pkg.Output = splitOutput(suite.SystemOut)
+	}
+
+	for _, prop := range suite.Properties {
+		switch prop.Name {
+		case "go.version":
+		case "coverage.statements.pct":
+			pkg.CoveragePct = prop.Value
+			pkg.Coverage, _ = strconv.ParseFloat(prop.Value, 64)
+		default:
+			pkg.Properties = append(pkg.Properties, parser.Property{Name: prop.Name, Value: prop.Value})
+		}
+	}
+
+	if suite.Error != nil {
+		pkg.BuildError = &parser.BuildError{Name: suite.Error.Message, Output: splitOutput(suite.Error.Contents), Type: suite.Error.Type}
+	}
+
+	for _, tc := range suite.TestCases {
+		if tc.Error != nil && regexBuildError.MatchString(tc.Name) {
+			// written for the build error of the package
+			if pkg.BuildError == nil {
+				pkg.BuildError = &parser.BuildError{Name: tc.Name, Output: splitOutput(tc.Error.Contents), Type: tc.Error.Type}
+			}
+			continue
+		}
+		test := &parser.Test{
+			Name:     tc.Name,
+			Duration: parseJUnitTime(tc.Time),
+		}
+		test.Time = int(test.Duration / time.Millisecond)
+		if tc.Timestamp != "" {
+			// fractional seconds are accepted even though the layout
+			// has none
+			test.Start, _ = time.Parse("2006-01-02T15:04:05", tc.Timestamp)
+		}
+		for _, prop := range tc.Properties {
+			switch prop.Name {
+			case "cpu":
+				test.CPU, _ = strconv.Atoi(prop.Value)
+			case "quarantined":
+				test.Quarantined = prop.Value == "true"
+			case "failure-ignored":
+				test.FailureIgnored = prop.Value == "true"
+			case "owner":
+				test.Owner = prop.Value
+			}
+		}
+
+		output := tc.SystemOut
+		switch {
+		case tc.Failure != nil:
+			test.Result = parser.FAIL
+			output = joinOutput(tc.Failure.Contents, output)
+		case tc.Error != nil:
+			test.Result = parser.ERROR
+			test.ErrorType = tc.Error.Type
+			output = joinOutput(tc.Error.Contents, output)
+		case tc.Skipped != nil:
+			test.Result = parser.SKIP
+			// the skip message is the last output of the test, except
+			// for quarantined tests, whose output is the failure
+			if test.Quarantined {
+				output = joinOutput(output, tc.Comment, tc.Skipped.Contents)
+			} else {
+				output = joinOutput(output, tc.Comment, tc.Skipped.Message, tc.Skipped.Contents)
+			}
+		default:
+			test.Result = parser.PASS
+			output = joinOutput(output, tc.Comment)
+		}
+		test.Output = splitOutput(output)
+
+		pkg.Tests = append(pkg.Tests, test)
+	}
+	return pkg
+}
+
+// parseJUnitTime parses a time attribute in seconds, invalid values result in
+// a zero duration.
+func parseJUnitTime(s string) time.Duration {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(f*float64(time.Second) + 0.5)
+}
+
+// joinOutput joins the non-empty parts with newlines.
+func joinOutput(parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, "\n")
+}
+
+func splitOutput(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,171 @@
+package formatter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestParseJUnitRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("../testdata/*-report.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			report, err := ParseJUnit(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ParseJUnit: %s", err)
+			}
+
+			var buf bytes.Buffer
+			opts := Options{
+				GoVersion:   "1.0",
+				NoXMLHeader: !bytes.HasPrefix(data, []byte("<?xml")),
+			}
+			if err := WriteJUnitXML(report, opts, &buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != string(data) {
+				t.Errorf("report changed after reading it back:\n%s\nwant:\n%s", buf.String(), data)
+			}
+		})
+	}
+}
+
+func TestParseJUnit(t *testing.T) {
+	in := `<?xml version="1.0"?>
+<testsuite name="other" time="1.5">
+	<properties><property name="go.version" value="go1.0"></property><property name="go.os" value="plan9"></property><property name="k" value="v"></property></properties>
+	<testcase name="TestA" time="0.25"><system-out>line 1
+line 2</system-out></testcase>
+	<testcase name="TestA/b" time="x"><skipped message="not now"/></testcase>
+	<testcase name="TestC"><failure message="Failed">boom</failure><system-out>log</system-out></testcase>
+	<testsuite name="nested"><testcase name="TestD"><error>broken</error></testcase></testsuite>
+</testsuite>`
+
+	report, err := ParseJUnit(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Packages) != 2 || report.Packages[0].Name != "other" || report.Packages[1].Name != "nested" {
+		t.Fatalf("unexpected packages: %+v", report.Packages)
+	}
+
+	pkg := report.Packages[0]
+	if pkg.Duration.Seconds() != 1.5 || len(pkg.Properties) != 2 || pkg.Properties[0].Name != "go.os" || pkg.Properties[1].Name != "k" {
+		t.Errorf("unexpected package: %+v", pkg)
+	}
+
+	expected := []struct {
+		result parser.Result
+		output string
+	}{
+		{parser.PASS, "line 1\nline 2"},
+		{parser.SKIP, "not now"},
+		{parser.FAIL, "boom\nlog"},
+	}
+	for i, want := range expected {
+		test := pkg.Tests[i]
+		if test.Result != want.result || strings.Join(test.Output, "\n") != want.output {
+			t.Errorf("test %s == %v %q, want %v %q", test.Name, test.Result, test.Output, want.result, want.output)
+		}
+	}
+	if pkg.Tests[0].Duration.Seconds() != 0.25 || pkg.Tests[1].Duration != 0 {
+		t.Errorf("unexpected test durations: %s, %s", pkg.Tests[0].Duration, pkg.Tests[1].Duration)
+	}
+	if pkg.Tests[1].Parent != pkg.Tests[0] {
+		t.Errorf("subtest TestA/b is not linked to TestA")
+	}
+	if test := report.Packages[1].Tests[0]; test.Result != parser.ERROR {
+		t.Errorf("nested test result == %v, want ERROR", test.Result)
+	}
+
+	for _, in := range []string{"", "<html></html>", "<testsuites><testsuite>"} {
+		if _, err := ParseJUnit(strings.NewReader(in)); err == nil {
+			t.Errorf("ParseJUnit(%q) did not return an error", in)
+		}
+	}
+}
+
+func TestWriteJUnitXMLSpilled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tmpl := filepath.Join(dir, "output.tmpl")
+	if err := ioutil.WriteFile(tmpl, []byte("{{range .Packages}}{{range .Tests}}{{output .}}\n{{end}}{{end}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob("../testdata/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			report, err := parser.Parse(bytes.NewReader(data), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			spilled, err := parser.ParseSpill(bytes.NewReader(data), "", dir, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer spilled.RemoveSpillFiles()
+
+			for _, opts := range []Options{
+				{GoVersion: "1.0"},
+				{GoVersion: "1.0", StripANSIEscape: true},
+				{GoVersion: "1.0", CDATA: true},
+				{GoVersion: "1.0", CDATA: true, TestcaseSystemOut: true},
+				{GoVersion: "1.0", MessageLength: 20, TestcaseSystemOut: true, FileAttr: true, Timestamp: time.Unix(0, 0)},
+				{GoVersion: "1.0", FileClassname: true},
+				{GoVersion: "1.0", SuiteStats: true},
+			} {
+				var want, got bytes.Buffer
+				if err := WriteJUnitXML(report, opts, &want); err != nil {
+					t.Fatal(err)
+				}
+				if err := WriteJUnitXML(spilled, opts, &got); err != nil {
+					t.Fatal(err)
+				}
+				if got.String() != want.String() {
+					t.Errorf("spilled report differs:\n%s\nwant:\n%s", got.String(), want.String())
+				}
+			}
+
+			for _, name := range Names() {
+				f, err := New(name, Options{GoVersion: "1.0", Template: tmpl, Timestamp: time.Unix(0, 0), TraceParent: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				var want, got bytes.Buffer
+				if err := f.Write(report, &want); err != nil {
+					t.Fatal(err)
+				}
+				if err := f.Write(spilled, &got); err != nil {
+					t.Fatal(err)
+				}
+				if got.String() != want.String() {
+					t.Errorf("spilled %s output differs:\n%s\nwant:\n%s", name, got.String(), want.String())
+				}
+			}
+		})
+	}
+}
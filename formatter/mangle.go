@@ -0,0 +1,67 @@
+package formatter
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"regexp"
+)
+
+// Mangler rewrites package and test names to satisfy the limits some CI
+// systems put on them. A nil *Mangler leaves names unchanged.
+type Mangler struct {
+	// Replacements are applied first, in order.
+	Replacements []Replacement
+	// Disallowed matches characters that are replaced by Placeholder.
+	Disallowed  *regexp.Regexp
+	Placeholder string
+	// MaxLength is the maximum length of a name in bytes, 0 means no limit.
+	// Longer names are shortened and get a hash of the full name appended so
+	// they stay unique.
+	MaxLength int
+}
+
+// Replacement replaces all matches of Pattern by With, which may refer to
+// submatches as described for regexp.Regexp.Expand.
+type Replacement struct {
+	Pattern *regexp.Regexp
+	With    string
+}
+
+// hashLength is the length of the hash suffix added to shortened names,
+// including the separator.
+const hashLength = 9
+
+// Mangle returns the mangled version of name.
+func (m *Mangler) Mangle(name string) string {
+	if m == nil {
+		return name
+	}
+
+	for _, r := range m.Replacements {
+		name = r.Pattern.ReplaceAllString(name, r.With)
+	}
+	if m.Disallowed != nil {
+		name = m.Disallowed.ReplaceAllLiteralString(name, m.Placeholder)
+	}
+	if m.MaxLength > 0 && len(name) > m.MaxLength {
+		sum := sha1.Sum([]byte(name))
+		hash := "-" + hex.EncodeToString(sum[:])[:hashLength-1]
+		if m.MaxLength <= hashLength {
+			// no room for the name, only for (a part of) the hash
+			return truncate(hash[1:], m.MaxLength)
+		}
+		name = truncate(name, m.MaxLength-hashLength) + hash
+	}
+	return name
+}
+
+// truncate shortens s to at most n bytes without splitting UTF-8 sequences.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n]
+}
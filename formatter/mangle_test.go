@@ -0,0 +1,52 @@
+package formatter
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestMangle(t *testing.T) {
+	m := &Mangler{
+		Replacements: []Replacement{{regexp.MustCompile(`^github\.com/([^/]+)/`), "$1:"}},
+		Disallowed:   regexp.MustCompile(`[^A-Za-z0-9_./:-]`),
+		Placeholder:  "_",
+		MaxLength:    24,
+	}
+
+	tests := []struct {
+		in, out string
+	}{
+		{"TestOne", "TestOne"},
+		{"github.com/org/repo/pkg", "org:repo/pkg"},
+		{"TestTable/with spaces & symbols", "TestTable/with_-215b8886"},
+		{"TestTable/with spaces & others", "TestTable/with_-9ea4cb82"},
+		{"Test/ünïcode", "Test/_n_code"},
+	}
+	for _, test := range tests {
+		if out := m.Mangle(test.in); out != test.out {
+			t.Errorf("Mangle(%q) == %q, want %q", test.in, out, test.out)
+		}
+		if len(m.Mangle(test.in)) > m.MaxLength {
+			t.Errorf("Mangle(%q) is longer than %d bytes", test.in, m.MaxLength)
+		}
+	}
+
+	var nilMangler *Mangler
+	if out := nilMangler.Mangle("Test name"); out != "Test name" {
+		t.Errorf("nil Mangle() == %q, want name unchanged", out)
+	}
+
+	short := &Mangler{MaxLength: 4}
+	if out := short.Mangle("TestLongName"); len(out) != 4 {
+		t.Errorf("Mangle() with MaxLength 4 == %q, want 4 bytes", out)
+	}
+}
+
+func TestMangleMaxLengthHash(t *testing.T) {
+	for n := 1; n <= hashLength+1; n++ {
+		m := &Mangler{MaxLength: n}
+		if out := m.Mangle("TestAVeryLongName"); len(out) > n {
+			t.Errorf("Mangle() with MaxLength %d == %q, longer than %d bytes", n, out, n)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package formatter
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/acarl005/stripansi"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// OTLP span kinds and status codes.
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOK         = 1
+	otlpStatusError      = 2
+)
+
+// otlpRequest is the JSON encoding of an OTLP trace export request, see
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes"`
+	Events            []otlpEvent     `json:"events,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string          `json:"timeUnixNano"`
+	Name         string          `json:"name"`
+	Attributes   []otlpAttribute `json:"attributes"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+func otlpString(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: value}}
+}
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+var otlpResults = map[parser.Result]string{
+	parser.PASS:  "pass",
+	parser.FAIL:  "fail",
+	parser.ERROR: "error",
+	parser.SKIP:  "skip",
+}
+
+// WriteOTLPJSON writes the report as OTLP JSON trace export request to w, with
+// a span for each package and test. Test spans are children of the span of
+// their package or parent test.
+//
+// Tests parsed from go test -json output have start and end times, which are
+// used for their spans. Otherwise packages are assumed to start at
+// opts.Timestamp, or so that the longest running package ends now if it's not
+// set, and tests are assumed to run one after another. If opts.TraceParent is
+// a W3C traceparent header the package spans are added to its trace as
+// children of its span, otherwise a new trace is started.
+func WriteOTLPJSON(report *parser.Report, opts Options, w io.Writer) error {
+	traceID, parentID, err := parseTraceParent(opts.TraceParent)
+	if err != nil {
+		return err
+	}
+	start := opts.Timestamp
+	if start.IsZero() {
+		// assume the longest running package just finished
+		start = time.Now()
+		for _, pkg := range report.Packages {
+			if t := time.Now().Add(-pkg.Duration); t.Before(start) {
+				start = t
+			}
+		}
+	}
+
+	var spans []otlpSpan
+	for _, pkg := range report.Packages {
+		s := newOTLPSpanBuilder(traceID, pkg.Name, opts)
+		pkgStart, pkgEnd := packageTimes(pkg, start)
+		pkgSpan := s.span("package "+pkg.Name, parentID, pkgStart, pkgEnd)
+		pkgSpan.Attributes = append(pkgSpan.Attributes, otlpString("test.suite.name", pkg.Name))
+		pkgSpan.Status.Code = otlpStatusOK
+		for _, test := range pkg.AllTests() {
+			if test.Result == parser.FAIL || test.Result == parser.ERROR {
+				pkgSpan.Status = otlpStatus{Code: otlpStatusError, Message: "tests failed"}
+			}
+		}
+		spans = append(spans, pkgSpan)
+
+		next := pkgStart
+		for _, test := range pkg.AllTests() {
+			span, err := s.testSpan(test, pkgSpan.SpanID, &next)
+			if err != nil {
+				return err
+			}
+			spans = append(spans, span)
+		}
+	}
+
+	req := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{otlpString("service.name", "go-junit-report")}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/hexon/go-junit-report"},
+			Spans: spans,
+		}},
+	}}}
+	if req.ResourceSpans[0].ScopeSpans[0].Spans == nil {
+		req.ResourceSpans[0].ScopeSpans[0].Spans = []otlpSpan{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(req)
+}
+
+// packageTimes returns the start and end time of the span of pkg. It starts at
+// the earliest start time of its tests if they are known, or at start.
+func packageTimes(pkg parser.Package, start time.Time) (time.Time, time.Time) {
+	var end time.Time
+	known := false
+	for _, test := range pkg.AllTests() {
+		if test.Start.IsZero() {
+			continue
+		}
+		if !known || test.Start.Before(start) {
+			start = test.Start
+		}
+		if testEnd := testEndTime(test); testEnd.After(end) {
+			end = testEnd
+		}
+		known = true
+	}
+	if pkgEnd := start.Add(pkg.Duration); pkgEnd.After(end) {
+		end = pkgEnd
+	}
+	return start, end
+}
+
+// testEndTime returns the end time of a test with a known start time.
+func testEndTime(test *parser.Test) time.Time {
+	return test.Start.Add(test.WallDuration())
+}
+
+// otlpSpanBuilder creates the spans of the tests of a package.
+type otlpSpanBuilder struct {
+	traceID string
+	pkg     string
+	opts    Options
+	// ends are the end times of the last subtest of each test, or its start
+	// time if it has no subtests yet
+	ends    map[*parser.Test]time.Time
+	spanIDs map[*parser.Test]string
+	seen    map[string]int
+}
+
+func newOTLPSpanBuilder(traceID, pkg string, opts Options) *otlpSpanBuilder {
+	return &otlpSpanBuilder{
+		traceID: traceID,
+		pkg:     pkg,
+		opts:    opts,
+		ends:    map[*parser.Test]time.Time{},
+		spanIDs: map[*parser.Test]string{},
+		seen:    map[string]int{},
+	}
+}
+
+func (s *otlpSpanBuilder) span(name, parentID string, start, end time.Time) otlpSpan {
+	n := s.seen[name]
+	s.seen[name]++
+	sum := sha1.Sum([]byte(strings.Join([]string{s.traceID, s.pkg, name, strconv.Itoa(n)}, "\x00")))
+	return otlpSpan{
+		TraceID:           s.traceID,
+		SpanID:            hex.EncodeToString(sum[:8]),
+		ParentSpanID:      parentID,
+		Name:              name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: otlpTime(start),
+		EndTimeUnixNano:   otlpTime(end),
+		Attributes:        []otlpAttribute{},
+	}
+}
+
+// testSpan returns the span of test, which starts at its start time if it's
+// known, otherwise at next if it's a top-level test, or after the previous
+// subtest of its parent. next is advanced to the end of top-level tests.
+func (s *otlpSpanBuilder) testSpan(test *parser.Test, pkgSpanID string, next *time.Time) (otlpSpan, error) {
+	start, parentID := *next, pkgSpanID
+	id, hasParent := s.spanIDs[test.Parent]
+	if hasParent {
+		start, parentID = s.ends[test.Parent], id
+	}
+	end := start.Add(test.Duration)
+	if !test.Start.IsZero() {
+		start, end = test.Start, testEndTime(test)
+	}
+	s.ends[test] = start
+	if hasParent {
+		s.ends[test.Parent] = end
+	} else {
+		*next = end
+	}
+
+	span := s.span(test.Name, parentID, start, end)
+	s.spanIDs[test] = span.SpanID
+	span.Attributes = append(span.Attributes,
+		otlpString("test.suite.name", s.pkg),
+		otlpString("test.case.name", test.Name),
+		otlpString("test.case.result.status", otlpResults[test.Result]),
+	)
+	span.Status.Code = otlpStatusOK
+	if test.Result != parser.FAIL && test.Result != parser.ERROR {
+		return span, nil
+	}
+
+	var lines []string
+	err := test.EachOutputLine(func(line string) error {
+		if s.opts.StripANSIEscape {
+			line = stripansi.Strip(line)
+		}
+		lines = append(lines, line)
+		return nil
+	})
+	if err != nil {
+		return span, err
+	}
+	message, _ := firstOutputLine(test)
+	if s.opts.StripANSIEscape {
+		message = stripansi.Strip(message)
+	}
+	span.Status = otlpStatus{Code: otlpStatusError, Message: shortenMessage(message, s.opts.MessageLength)}
+	if len(lines) > 0 {
+		span.Events = []otlpEvent{{
+			TimeUnixNano: otlpTime(end),
+			Name:         "test.output",
+			Attributes:   []otlpAttribute{otlpString("test.output", strings.Join(lines, "\n"))},
+		}}
+	}
+	return span, nil
+}
+
+// parseTraceParent returns the trace and span id of a W3C traceparent header,
+// or a new random trace id if it's empty.
+func parseTraceParent(header string) (traceID, spanID string, err error) {
+	if header == "" {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return "", "", err
+		}
+		return hex.EncodeToString(id), "", nil
+	}
+	parts := strings.Split(header, "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", fmt.Errorf("invalid traceparent %q", header)
+	}
+	for _, id := range parts[1:3] {
+		if _, err := hex.DecodeString(id); err != nil || strings.Trim(id, "0") == "" {
+			return "", "", fmt.Errorf("invalid traceparent %q", header)
+		}
+	}
+	return strings.ToLower(parts[1]), strings.ToLower(parts[2]), nil
+}
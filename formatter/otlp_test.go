@@ -0,0 +1,121 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestWriteOTLPJSON(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{{
+		Name:     "example.com/a",
+		Duration: 3 * time.Second,
+		Tests: []*parser.Test{
+			{Name: "TestA", Result: parser.FAIL, Duration: 2 * time.Second},
+			{Name: "TestA/b", Result: parser.PASS, Duration: time.Second},
+			{Name: "TestA/c", Result: parser.FAIL, Duration: time.Second, Output: []string{"    a_test.go:5: boom"}},
+			{Name: "TestD", Result: parser.SKIP, Duration: time.Second},
+		},
+	}}}
+	report.LinkSubtests()
+	opts := Options{
+		Timestamp:   time.Unix(100, 0),
+		TraceParent: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+	}
+
+	var buf bytes.Buffer
+	if err := WriteOTLPJSON(report, opts, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var req otlpRequest
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		t.Fatal(err)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 5 {
+		t.Fatalf("got %d spans, want 5:\n%s", len(spans), buf.String())
+	}
+
+	pkg, a, b, c, d := spans[0], spans[1], spans[2], spans[3], spans[4]
+	expected := []struct {
+		span       otlpSpan
+		parent     string
+		start, end string
+		status     int
+	}{
+		{pkg, "b7ad6b7169203331", "100000000000", "103000000000", otlpStatusError},
+		{a, pkg.SpanID, "100000000000", "102000000000", otlpStatusError},
+		{b, a.SpanID, "100000000000", "101000000000", otlpStatusOK},
+		{c, a.SpanID, "101000000000", "102000000000", otlpStatusError},
+		{d, pkg.SpanID, "102000000000", "103000000000", otlpStatusOK},
+	}
+	for _, want := range expected {
+		span := want.span
+		if span.TraceID != "0af7651916cd43dd8448eb211c80319c" || span.ParentSpanID != want.parent ||
+			span.StartTimeUnixNano != want.start || span.EndTimeUnixNano != want.end || span.Status.Code != want.status {
+			t.Errorf("unexpected span %s: %+v", span.Name, span)
+		}
+	}
+	if c.Status.Message != "a_test.go:5: boom" || len(c.Events) != 1 || c.Events[0].Attributes[0].Value.StringValue != "    a_test.go:5: boom" {
+		t.Errorf("unexpected failure of %s: %+v, %+v", c.Name, c.Status, c.Events)
+	}
+	if len(a.Events) != 0 {
+		t.Errorf("span of %s without output has events: %+v", a.Name, a.Events)
+	}
+}
+
+func TestWriteOTLPJSONTestTimes(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{{
+		Name:     "example.com/a",
+		Duration: 3 * time.Second,
+		Tests: []*parser.Test{
+			{Name: "TestA", Result: parser.PASS, Duration: time.Second, Start: time.Unix(50, 0), End: time.Unix(54, 0)},
+			{Name: "TestB", Result: parser.PASS, Duration: time.Second, Start: time.Unix(51, 0), End: time.Unix(52, 0)},
+		},
+	}}}
+	report.LinkSubtests()
+
+	var buf bytes.Buffer
+	if err := WriteOTLPJSON(report, Options{Timestamp: time.Unix(100, 0)}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var req otlpRequest
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		t.Fatal(err)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3:\n%s", len(spans), buf.String())
+	}
+	expected := []struct{ start, end string }{
+		{"50000000000", "54000000000"},
+		{"50000000000", "54000000000"},
+		{"51000000000", "52000000000"},
+	}
+	for i, want := range expected {
+		if spans[i].StartTimeUnixNano != want.start || spans[i].EndTimeUnixNano != want.end {
+			t.Errorf("span %s: got %s-%s, want %s-%s", spans[i].Name,
+				spans[i].StartTimeUnixNano, spans[i].EndTimeUnixNano, want.start, want.end)
+		}
+	}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	traceID, spanID, err := parseTraceParent("")
+	if err != nil || len(traceID) != 32 || spanID != "" {
+		t.Errorf("parseTraceParent(\"\") = %q, %q, %v, want a new trace id", traceID, spanID, err)
+	}
+	for _, header := range []string{
+		"00-0af7651916cd43dd8448eb211c80319c",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b71692033-01",
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319x-b7ad6b7169203331-01",
+	} {
+		if _, _, err := parseTraceParent(header); err == nil {
+			t.Errorf("parseTraceParent(%q) did not return an error", header)
+		}
+	}
+}
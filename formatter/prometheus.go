@@ -0,0 +1,58 @@
+package formatter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// prometheusMetrics are the metrics written by WritePrometheus for each
+// package.
+var prometheusMetrics = []struct {
+	name, typ, help string
+	value           func(pkg parser.Package) float64
+}{
+	{"go_test_tests_total", "counter", "Number of tests run.", func(pkg parser.Package) float64 {
+		tests, _, _, _ := pkg.Counts()
+		return float64(tests)
+	}},
+	{"go_test_failures_total", "counter", "Number of failed tests.", func(pkg parser.Package) float64 {
+		_, failures, _, _ := pkg.Counts()
+		return float64(failures)
+	}},
+	{"go_test_errors_total", "counter", "Number of tests that could not be run, such as build failures.", func(pkg parser.Package) float64 {
+		_, _, errs, _ := pkg.Counts()
+		return float64(errs)
+	}},
+	{"go_test_skipped_total", "counter", "Number of skipped tests.", func(pkg parser.Package) float64 {
+		_, _, _, skipped := pkg.Counts()
+		return float64(skipped)
+	}},
+	{"go_test_duration_seconds", "gauge", "Duration of the package tests in seconds.", func(pkg parser.Package) float64 {
+		return pkg.Duration.Seconds()
+	}},
+}
+
+// WritePrometheus writes per-package test counts and durations to w in the
+// Prometheus text exposition format, labeled with the package name.
+func WritePrometheus(report *parser.Report, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, metric := range prometheusMetrics {
+		fmt.Fprintf(bw, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", metric.name, metric.typ)
+		for _, pkg := range report.Packages {
+			value := strconv.FormatFloat(metric.value(pkg), 'g', -1, 64)
+			fmt.Fprintf(bw, "%s{package=\"%s\"} %s\n", metric.name, prometheusLabel(pkg.Name), value)
+		}
+	}
+	return bw.Flush()
+}
+
+// prometheusLabel escapes a label value.
+func prometheusLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
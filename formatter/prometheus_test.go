@@ -0,0 +1,44 @@
+package formatter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{
+		{
+			Name:     "example.com/a",
+			Duration: 1500 * time.Millisecond,
+			Tests: []*parser.Test{
+				{Name: "TestA", Result: parser.PASS},
+				{Name: "TestB", Result: parser.FAIL},
+				{Name: "TestC", Result: parser.SKIP},
+			},
+		},
+		{Name: `example.com/"b"`},
+	}}
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(report, &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE go_test_tests_total counter\n",
+		"go_test_tests_total{package=\"example.com/a\"} 3\n",
+		"go_test_failures_total{package=\"example.com/a\"} 1\n",
+		"go_test_errors_total{package=\"example.com/a\"} 0\n",
+		"go_test_skipped_total{package=\"example.com/a\"} 1\n",
+		"# TYPE go_test_duration_seconds gauge\n",
+		"go_test_duration_seconds{package=\"example.com/a\"} 1.5\n",
+		"go_test_tests_total{package=\"example.com/\\\"b\\\"\"} 0\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// Formatter writes a report in a particular output format.
+type Formatter interface {
+	Write(report *parser.Report, w io.Writer) error
+}
+
+// Factory creates a Formatter configured by opts.
+type Factory func(opts Options) (Formatter, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a formatter available under the given name. It panics if
+// a formatter with the same name was already registered.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[name]; ok {
+		panic("formatter: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// New returns the formatter registered under name, configured by opts.
+func New(name string, opts Options) (Formatter, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q", name)
+	}
+	return factory(opts)
+}
+
+// Names returns the sorted names of all registered formatters.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FormatterFunc is an adapter to allow the use of ordinary functions as
+// Formatter.
+type FormatterFunc func(report *parser.Report, w io.Writer) error
+
+// Write calls f(report, w).
+func (f FormatterFunc) Write(report *parser.Report, w io.Writer) error {
+	return f(report, w)
+}
+
+func init() {
+	Register("junit", func(opts Options) (Formatter, error) {
+		if opts.Validate {
+			return FormatterFunc(func(report *parser.Report, w io.Writer) error {
+				var buf bytes.Buffer
+				if err := WriteJUnitXML(report, opts, &buf); err != nil {
+					return err
+				}
+				if err := ValidateJUnitXML(bytes.NewReader(buf.Bytes()), opts); err != nil {
+					return fmt.Errorf("report does not match the JUnit schema: %s", err)
+				}
+				_, err := buf.WriteTo(w)
+				return err
+			}), nil
+		}
+		return FormatterFunc(func(report *parser.Report, w io.Writer) error {
+			return WriteJUnitXML(report, opts, w)
+		}), nil
+	})
+	Register("console", func(opts Options) (Formatter, error) {
+		return FormatterFunc(func(report *parser.Report, w io.Writer) error {
+			return WriteConsole(report, opts, w)
+		}), nil
+	})
+	Register("json", func(opts Options) (Formatter, error) {
+		return FormatterFunc(WriteJSON), nil
+	})
+	Register("buildkite", func(opts Options) (Formatter, error) {
+		return FormatterFunc(func(report *parser.Report, w io.Writer) error {
+			return WriteBuildkiteJSON(report, opts, w)
+		}), nil
+	})
+	Register("otlp", func(opts Options) (Formatter, error) {
+		return FormatterFunc(func(report *parser.Report, w io.Writer) error {
+			return WriteOTLPJSON(report, opts, w)
+		}), nil
+	})
+	Register("prometheus", func(opts Options) (Formatter, error) {
+		return FormatterFunc(WritePrometheus), nil
+	})
+	Register("stats", func(opts Options) (Formatter, error) {
+		return FormatterFunc(WriteStatsJSON), nil
+	})
+	Register("histogram", func(opts Options) (Formatter, error) {
+		buckets, err := ParseHistogramBuckets(opts.HistogramBuckets)
+		if err != nil {
+			return nil, err
+		}
+		return FormatterFunc(func(report *parser.Report, w io.Writer) error {
+			return WriteHistogramMarkdown(report, buckets, w)
+		}), nil
+	})
+	Register("slowest", func(opts Options) (Formatter, error) {
+		n := opts.Slowest
+		if n <= 0 {
+			n = 10
+		}
+		return FormatterFunc(func(report *parser.Report, w io.Writer) error {
+			return WriteSlowestMarkdown(report, n, w)
+		}), nil
+	})
+	Register("template", func(opts Options) (Formatter, error) {
+		if opts.Template == "" {
+			return nil, fmt.Errorf("the template format requires a template file")
+		}
+		tmpl, err := ParseTemplateFile(opts.Template)
+		if err != nil {
+			return nil, err
+		}
+		return FormatterFunc(func(report *parser.Report, w io.Writer) error {
+			return WriteTemplate(report, tmpl, w)
+		}), nil
+	})
+}
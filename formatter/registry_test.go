@@ -0,0 +1,82 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestRegistry(t *testing.T) {
+	Register("test-count", func(opts Options) (Formatter, error) {
+		return FormatterFunc(func(report *parser.Report, w io.Writer) error {
+			_, err := fmt.Fprintf(w, "%d packages", len(report.Packages))
+			return err
+		}), nil
+	})
+
+	f, err := New("test-count", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&parser.Report{Packages: make([]parser.Package, 2)}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "2 packages" {
+		t.Errorf("Write() output == %q, want %q", buf.String(), "2 packages")
+	}
+
+	found := false
+	for _, name := range Names() {
+		found = found || name == "test-count"
+	}
+	if !found {
+		t.Errorf("Names() == %v, missing test-count", Names())
+	}
+
+	if _, err := New("does-not-exist", Options{}); err == nil {
+		t.Errorf("New() for unknown format did not return an error")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Register() with duplicate name did not panic")
+		}
+	}()
+	Register("junit", nil)
+}
+
+func TestJSONFormat(t *testing.T) {
+	f, err := New("json", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	report := &parser.Report{Packages: []parser.Package{{Name: "pkg", Tests: []*parser.Test{{Name: "TestA", Result: parser.PASS}}}}}
+	if err := f.Write(report, &buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "packages": [
+    {
+      "name": "pkg",
+      "tests": [
+        {
+          "name": "TestA",
+          "result": "pass",
+          "output": [],
+          "duration": 0
+        }
+      ],
+      "duration": 0
+    }
+  ]
+}
+`
+	if buf.String() != expected {
+		t.Errorf("json output:\n%s\nwant:\n%s", buf.String(), expected)
+	}
+}
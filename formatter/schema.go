@@ -0,0 +1,565 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// JUnitSchema is the XML schema of JUnit reports checked by
+// ValidateJUnitXML. It's based on the JUnit schema used by Jenkins, with these
+// deviations for what go-junit-report writes regardless of options:
+//
+//   - <testsuites> has a skipped attribute like <testsuite>, and a
+//     <system-err> element with the standard error of the test command
+//   - <testcase> has a timestamp attribute with the start of the test, and
+//     a <properties> element as in Surefire reports
+//   - <testcase> has at most one <skipped>, <error> or <failure> element and
+//     at most one <system-out> and <system-err> element
+//
+// The coverage attribute of testsuites, see Options.CoverageAttr, and their
+// <error> element, see Options.BuildErrors, are only accepted by
+// ValidateJUnitXML with the options that write them.
+const JUnitSchema = `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" elementFormDefault="qualified">
+	<xs:element name="testsuites">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:element ref="testsuite" minOccurs="0" maxOccurs="unbounded"/>
+				<xs:element ref="system-err" minOccurs="0"/>
+			</xs:sequence>
+			<xs:attribute name="name" type="xs:string"/>
+			<xs:attribute name="time" type="xs:decimal"/>
+			<xs:attribute name="tests" type="xs:int"/>
+			<xs:attribute name="failures" type="xs:int"/>
+			<xs:attribute name="errors" type="xs:int"/>
+			<xs:attribute name="skipped" type="xs:int"/>
+			<xs:attribute name="disabled" type="xs:int"/>
+		</xs:complexType>
+	</xs:element>
+
+	<xs:element name="testsuite">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:element ref="properties" minOccurs="0"/>
+				<xs:element ref="testcase" minOccurs="0" maxOccurs="unbounded"/>
+				<xs:element ref="system-out" minOccurs="0"/>
+				<xs:element ref="system-err" minOccurs="0"/>
+			</xs:sequence>
+			<xs:attribute name="name" type="xs:string" use="required"/>
+			<xs:attribute name="tests" type="xs:int" use="required"/>
+			<xs:attribute name="failures" type="xs:int"/>
+			<xs:attribute name="errors" type="xs:int"/>
+			<xs:attribute name="skipped" type="xs:int"/>
+			<xs:attribute name="disabled" type="xs:int"/>
+			<xs:attribute name="time" type="xs:decimal"/>
+			<xs:attribute name="timestamp" type="xs:dateTime"/>
+			<xs:attribute name="hostname" type="xs:string"/>
+			<xs:attribute name="id" type="xs:string"/>
+			<xs:attribute name="package" type="xs:string"/>
+			<xs:attribute name="file" type="xs:string"/>
+		</xs:complexType>
+	</xs:element>
+
+	<xs:element name="testcase">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:element ref="properties" minOccurs="0"/>
+				<xs:choice minOccurs="0">
+					<xs:element ref="skipped"/>
+					<xs:element ref="error"/>
+					<xs:element ref="failure"/>
+				</xs:choice>
+				<xs:element ref="system-out" minOccurs="0"/>
+				<xs:element ref="system-err" minOccurs="0"/>
+			</xs:sequence>
+			<xs:attribute name="name" type="xs:string" use="required"/>
+			<xs:attribute name="classname" type="xs:string"/>
+			<xs:attribute name="time" type="xs:decimal"/>
+			<xs:attribute name="timestamp" type="xs:dateTime"/>
+			<xs:attribute name="file" type="xs:string"/>
+			<xs:attribute name="line" type="xs:int"/>
+			<xs:attribute name="assertions" type="xs:int"/>
+			<xs:attribute name="status" type="xs:string"/>
+		</xs:complexType>
+	</xs:element>
+
+	<xs:element name="properties">
+		<xs:complexType>
+			<xs:sequence>
+				<xs:element ref="property" minOccurs="0" maxOccurs="unbounded"/>
+			</xs:sequence>
+		</xs:complexType>
+	</xs:element>
+
+	<xs:element name="property">
+		<xs:complexType>
+			<xs:attribute name="name" type="xs:string" use="required"/>
+			<xs:attribute name="value" type="xs:string" use="required"/>
+		</xs:complexType>
+	</xs:element>
+
+	<xs:element name="skipped">
+		<xs:complexType mixed="true">
+			<xs:attribute name="message" type="xs:string"/>
+		</xs:complexType>
+	</xs:element>
+
+	<xs:element name="error">
+		<xs:complexType mixed="true">
+			<xs:attribute name="message" type="xs:string"/>
+			<xs:attribute name="type" type="xs:string"/>
+		</xs:complexType>
+	</xs:element>
+
+	<xs:element name="failure">
+		<xs:complexType mixed="true">
+			<xs:attribute name="message" type="xs:string"/>
+			<xs:attribute name="type" type="xs:string"/>
+		</xs:complexType>
+	</xs:element>
+
+	<xs:element name="system-out" type="xs:string"/>
+	<xs:element name="system-err" type="xs:string"/>
+</xs:schema>
+`
+
+// schemaExtension adds an attribute or a child element to a global element
+// of JUnitSchema.
+type schemaExtension struct {
+	// enabled reports whether the extension applies to reports written
+	// with the given options.
+	enabled func(opts Options) bool
+	// element is the name of the extended element.
+	element string
+	// attr, if set, is declared as optional attribute of element with the
+	// simple type typ.
+	attr, typ string
+	// child, if set, is added as optional child element to the sequence of
+	// element, before the child before.
+	child, before string
+}
+
+// schemaExtensions are the additions to JUnitSchema for elements and
+// attributes that are only written with certain options.
+var schemaExtensions = []schemaExtension{
+	// Options.CoverageAttr: the coverage attribute of testsuites, which is
+	// not part of any JUnit schema
+	{
+		enabled: func(opts Options) bool { return opts.CoverageAttr },
+		element: "testsuite",
+		attr:    "coverage",
+		typ:     "xs:decimal",
+	},
+	// Options.BuildErrors: the <error> of testsuites that couldn't run, as
+	// written by Ant and Surefire
+	{
+		enabled: func(opts Options) bool { return opts.BuildErrors == "suite" || opts.BuildErrors == "both" },
+		element: "testsuite",
+		child:   "error",
+		before:  "testcase",
+	},
+}
+
+// apply adds the extension to the global elements of a schema.
+func (e schemaExtension) apply(globals map[string]*xsdNode) error {
+	decl := globals[e.element]
+	if decl == nil {
+		return fmt.Errorf("schema has no element %s", e.element)
+	}
+	ct := decl.child("complexType")
+	if ct == nil {
+		return fmt.Errorf("element %s has no complex type", e.element)
+	}
+	if e.attr != "" {
+		ct.Children = append(ct.Children, xsdNode{XMLName: xml.Name{Local: "attribute"}, Name: e.attr, Type: e.typ})
+	}
+	if e.child == "" {
+		return nil
+	}
+	seq := ct.child("sequence")
+	if seq == nil {
+		return fmt.Errorf("element %s has no sequence", e.element)
+	}
+	for i, c := range seq.Children {
+		if c.Ref == e.before || c.Name == e.before {
+			child := xsdNode{XMLName: xml.Name{Local: "element"}, Ref: e.child, MinOccurs: "0"}
+			seq.Children = append(seq.Children[:i], append([]xsdNode{child}, seq.Children[i:]...)...)
+			return nil
+		}
+	}
+	return fmt.Errorf("element %s has no child %s", e.element, e.before)
+}
+
+// xsdNode is an element of an XML schema. Only the parts of XML Schema used by
+// JUnitSchema are supported: global elements and element references,
+// sequences and choices with occurrence constraints, attributes and mixed
+// content.
+type xsdNode struct {
+	XMLName   xml.Name
+	Name      string    `xml:"name,attr"`
+	Ref       string    `xml:"ref,attr"`
+	Type      string    `xml:"type,attr"`
+	Use       string    `xml:"use,attr"`
+	Mixed     bool      `xml:"mixed,attr"`
+	MinOccurs string    `xml:"minOccurs,attr"`
+	MaxOccurs string    `xml:"maxOccurs,attr"`
+	Children  []xsdNode `xml:",any"`
+}
+
+// child returns the first child of n with the given local name, or nil.
+func (n *xsdNode) child(local string) *xsdNode {
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == local {
+			return &n.Children[i]
+		}
+	}
+	return nil
+}
+
+// occurs returns the minOccurs and maxOccurs of n, with -1 for unbounded.
+func (n *xsdNode) occurs() (min, max int, err error) {
+	min, max = 1, 1
+	if n.MinOccurs != "" {
+		if min, err = strconv.Atoi(n.MinOccurs); err != nil {
+			return 0, 0, fmt.Errorf("invalid minOccurs %q", n.MinOccurs)
+		}
+	}
+	if n.MaxOccurs == "unbounded" {
+		max = -1
+	} else if n.MaxOccurs != "" {
+		if max, err = strconv.Atoi(n.MaxOccurs); err != nil {
+			return 0, 0, fmt.Errorf("invalid maxOccurs %q", n.MaxOccurs)
+		}
+	}
+	return min, max, nil
+}
+
+var (
+	regexXSDDecimal  = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+	regexXSDInt      = regexp.MustCompile(`^[+-]?\d+$`)
+	regexXSDDateTime = regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`)
+)
+
+// checkXSDValue returns an error if value is not valid for the simple type
+// typ.
+func checkXSDValue(typ, value string) error {
+	var re *regexp.Regexp
+	switch typ {
+	case "", "xs:string", "xs:token":
+		return nil
+	case "xs:decimal":
+		re = regexXSDDecimal
+	case "xs:int", "xs:integer":
+		re = regexXSDInt
+	case "xs:dateTime":
+		re = regexXSDDateTime
+	default:
+		return fmt.Errorf("unsupported type %s", typ)
+	}
+	if !re.MatchString(strings.TrimSpace(value)) {
+		return fmt.Errorf("%q is not a valid %s", value, typ)
+	}
+	return nil
+}
+
+// xmlNode is an element of the validated document.
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlNode
+	// text is set if the element contains character data other than white
+	// space
+	text []byte
+	line int
+}
+
+// ValidateJUnitXML checks the JUnit report read from r against JUnitSchema,
+// with the schemaExtensions for the elements and attributes written with opts.
+// The error names the line of the first element that is not valid.
+func ValidateJUnitXML(r io.Reader, opts Options) error {
+	var schema xsdNode
+	if err := xml.Unmarshal([]byte(JUnitSchema), &schema); err != nil {
+		return fmt.Errorf("parsing schema: %s", err)
+	}
+	globals := map[string]*xsdNode{}
+	for i := range schema.Children {
+		if el := &schema.Children[i]; el.XMLName.Local == "element" {
+			globals[el.Name] = el
+		}
+	}
+	for _, ext := range schemaExtensions {
+		if !ext.enabled(opts) {
+			continue
+		}
+		if err := ext.apply(globals); err != nil {
+			return fmt.Errorf("extending schema: %s", err)
+		}
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	root, err := parseXMLNodes(data)
+	if err != nil {
+		return err
+	}
+	decl, ok := globals[root.name]
+	if !ok {
+		return fmt.Errorf("line %d: unexpected root element <%s>", root.line, root.name)
+	}
+	return validateNode(root, decl, globals)
+}
+
+// parseXMLNodes parses the element tree of the document in data.
+func parseXMLNodes(data []byte) (*xmlNode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var root *xmlNode
+	var stack []*xmlNode
+	line, offset := 1, 0
+	for {
+		line += bytes.Count(data[offset:dec.InputOffset()], []byte("\n"))
+		offset = int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: tok.Name.Local, attrs: tok.Attr, line: line}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				node := stack[len(stack)-1]
+				node.text = append(node.text, tok...)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
+
+// validateNode checks node and its children against the element declaration
+// decl.
+func validateNode(node *xmlNode, decl *xsdNode, globals map[string]*xsdNode) error {
+	if decl.Ref != "" {
+		if decl = globals[decl.Ref]; decl == nil {
+			return fmt.Errorf("schema has no element %s", node.name)
+		}
+	}
+	fail := func(format string, args ...interface{}) error {
+		return fmt.Errorf("line %d: <%s>: %s", node.line, node.name, fmt.Sprintf(format, args...))
+	}
+
+	ct := decl.child("complexType")
+	if ct == nil {
+		// an element of a simple type
+		if len(node.children) > 0 {
+			return fail("child element <%s> is not allowed", node.children[0].name)
+		}
+		if err := checkXSDValue(decl.Type, string(node.text)); err != nil {
+			return fail("%s", err)
+		}
+		return checkAttributes(node, nil, fail)
+	}
+
+	if !ct.Mixed && len(bytes.TrimSpace(node.text)) > 0 {
+		return fail("text content is not allowed")
+	}
+	if err := checkAttributes(node, ct, fail); err != nil {
+		return err
+	}
+
+	var model *xsdNode
+	for i := range ct.Children {
+		if local := ct.Children[i].XMLName.Local; local == "sequence" || local == "choice" {
+			model = &ct.Children[i]
+		}
+	}
+	if model == nil {
+		if len(node.children) > 0 {
+			return fail("child element <%s> is not allowed", node.children[0].name)
+		}
+		return nil
+	}
+
+	names := make([]string, len(node.children))
+	for i, child := range node.children {
+		names[i] = child.name
+	}
+	m := contentMatcher{names: names}
+	ends, err := m.match(model, 0)
+	if err != nil {
+		return fail("in schema: %s", err)
+	}
+	if !ends[len(names)] {
+		if m.furthest < len(names) {
+			child := node.children[m.furthest]
+			return fmt.Errorf("line %d: <%s> is not allowed here in <%s>", child.line, child.name, node.name)
+		}
+		return fail("required child elements are missing")
+	}
+
+	decls := map[string]*xsdNode{}
+	collectElements(model, decls)
+	for _, child := range node.children {
+		if err := validateNode(child, decls[child.name], globals); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAttributes checks the attributes of node against those declared by
+// the complex type ct, which is nil for simple types.
+func checkAttributes(node *xmlNode, ct *xsdNode, fail func(string, ...interface{}) error) error {
+	declared := map[string]*xsdNode{}
+	if ct != nil {
+		for i := range ct.Children {
+			if attr := &ct.Children[i]; attr.XMLName.Local == "attribute" {
+				declared[attr.Name] = attr
+			}
+		}
+	}
+	seen := map[string]bool{}
+	for _, attr := range node.attrs {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" || attr.Name.Space == "http://www.w3.org/2001/XMLSchema-instance" {
+			continue
+		}
+		decl, ok := declared[attr.Name.Local]
+		if !ok || attr.Name.Space != "" {
+			return fail("attribute %s is not allowed", attr.Name.Local)
+		}
+		if err := checkXSDValue(decl.Type, attr.Value); err != nil {
+			return fail("attribute %s: %s", attr.Name.Local, err)
+		}
+		seen[attr.Name.Local] = true
+	}
+	for name, decl := range declared {
+		if decl.Use == "required" && !seen[name] {
+			return fail("required attribute %s is missing", name)
+		}
+	}
+	return nil
+}
+
+// collectElements adds the element declarations in the content model p to
+// decls by name.
+func collectElements(p *xsdNode, decls map[string]*xsdNode) {
+	if p.XMLName.Local == "element" {
+		name := p.Name
+		if name == "" {
+			name = p.Ref
+		}
+		decls[name] = p
+		return
+	}
+	for i := range p.Children {
+		collectElements(&p.Children[i], decls)
+	}
+}
+
+// contentMatcher matches the names of the child elements of an element
+// against its content model.
+type contentMatcher struct {
+	names []string
+	// furthest is the number of children that could be matched
+	furthest int
+}
+
+// match returns the positions in m.names at which a match of the particle p,
+// starting at pos, can end.
+func (m *contentMatcher) match(p *xsdNode, pos int) (map[int]bool, error) {
+	min, max, err := p.occurs()
+	if err != nil {
+		return nil, err
+	}
+	ends := map[int]bool{}
+	if min == 0 {
+		ends[pos] = true
+	}
+	current := map[int]bool{pos: true}
+	for i := 1; (max < 0 || i <= max) && i <= len(m.names)+1 && len(current) > 0; i++ {
+		next := map[int]bool{}
+		for q := range current {
+			once, err := m.matchOnce(p, q)
+			if err != nil {
+				return nil, err
+			}
+			for e := range once {
+				next[e] = true
+			}
+		}
+		if i >= min {
+			for e := range next {
+				ends[e] = true
+			}
+		}
+		current = next
+	}
+	return ends, nil
+}
+
+// matchOnce is like match, ignoring the occurrence constraints of p.
+func (m *contentMatcher) matchOnce(p *xsdNode, pos int) (map[int]bool, error) {
+	ends := map[int]bool{}
+	switch p.XMLName.Local {
+	case "element":
+		name := p.Name
+		if name == "" {
+			name = p.Ref
+		}
+		if pos < len(m.names) && m.names[pos] == name {
+			ends[pos+1] = true
+			if pos+1 > m.furthest {
+				m.furthest = pos + 1
+			}
+		}
+	case "sequence":
+		ends[pos] = true
+		for i := range p.Children {
+			next := map[int]bool{}
+			for q := range ends {
+				e, err := m.match(&p.Children[i], q)
+				if err != nil {
+					return nil, err
+				}
+				for q := range e {
+					next[q] = true
+				}
+			}
+			ends = next
+		}
+	case "choice":
+		for i := range p.Children {
+			e, err := m.match(&p.Children[i], pos)
+			if err != nil {
+				return nil, err
+			}
+			for q := range e {
+				ends[q] = true
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported particle %s", p.XMLName.Local)
+	}
+	return ends, nil
+}
@@ -0,0 +1,97 @@
+package formatter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestValidateJUnitXMLTestdata(t *testing.T) {
+	files, err := filepath.Glob("../testdata/*-report.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateJUnitXML(f, Options{}); err != nil {
+			t.Errorf("%s: %s", file, err)
+		}
+		f.Close()
+	}
+}
+
+func TestValidateJUnitXMLOptions(t *testing.T) {
+	report := &parser.Report{
+		Packages: []parser.Package{{
+			Name:       "example.com/a",
+			Duration:   time.Second,
+			BuildError: &parser.BuildError{Name: "example.com/a", Output: []string{"a.go:1: undefined: x"}},
+			Tests: []*parser.Test{
+				{Name: "TestPass", Result: parser.PASS, Output: []string{"ok"}, CPU: 4, Start: time.Unix(1, 0)},
+				{Name: "TestFail", Result: parser.FAIL, Output: []string{"a_test.go:12: failed"}},
+				{Name: "TestSkip", Result: parser.SKIP, Output: []string{"a_test.go:20: skipped"}},
+			},
+			CoveragePct: "50.0",
+			Coverage:    50,
+		}},
+		Stderr: []string{"warning"},
+	}
+	opts := Options{
+		Validate:          true,
+		BuildErrors:       "both",
+		CoverageAttr:      true,
+		TestcaseSystemOut: true,
+		FileAttr:          true,
+		CDATA:             true,
+		Timestamp:         time.Unix(1, 0),
+		XMLStandalone:     "yes",
+	}
+	f, err := New("junit", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := f.Write(report, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// the coverage attribute and the error of the testsuite are only valid
+	// with the options that write them
+	for _, without := range []Options{
+		{BuildErrors: "both", TestcaseSystemOut: true},
+		{CoverageAttr: true, TestcaseSystemOut: true},
+	} {
+		if err := ValidateJUnitXML(bytes.NewReader(buf.Bytes()), without); err == nil {
+			t.Errorf("report with %+v is valid with options %+v", opts, without)
+		}
+	}
+}
+
+func TestValidateJUnitXMLInvalid(t *testing.T) {
+	tests := map[string]string{
+		`<testsuites><testcase name="a"/></testsuites>`:                                              "line 1: <testcase> is not allowed here in <testsuites>",
+		`<testsuite tests="1"></testsuite>`:                                                          "required attribute name is missing",
+		`<testsuite name="a" tests="x"></testsuite>`:                                                 `attribute tests: "x" is not a valid xs:int`,
+		"<testsuite name=\"a\" tests=\"1\">\n<testcase name=\"a\" color=\"red\"/></testsuite>":       "line 2: <testcase>: attribute color is not allowed",
+		`<testsuite name="a" tests="1">output</testsuite>`:                                           "text content is not allowed",
+		`<testsuite name="a" tests="1"><testcase name="a"><failure/><error/></testcase></testsuite>`: "<error> is not allowed here in <testcase>",
+		`<testsuite name="a" tests="1"><system-out>x</system-out><testcase name="a"/></testsuite>`:   "<testcase> is not allowed here in <testsuite>",
+		`<report/>`: "unexpected root element <report>",
+		`<testsuite name="a" tests="1" coverage="50.0"></testsuite>`:                "attribute coverage is not allowed",
+		`<testsuite name="a" tests="1"><error message="build failed"/></testsuite>`: "<error> is not allowed here in <testsuite>",
+	}
+	for in, want := range tests {
+		err := ValidateJUnitXML(strings.NewReader(in), Options{})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ValidateJUnitXML(%q) == %v, want an error containing %q", in, err, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// TestTiming is a test and the package it belongs to.
+type TestTiming struct {
+	Package string
+	Test    *parser.Test
+}
+
+// SlowestTests returns the n slowest tests in report, slowest first. Tests
+// with the same duration are returned in report order.
+func SlowestTests(report *parser.Report, n int) []TestTiming {
+	var tests []TestTiming
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.Tests {
+			tests = append(tests, TestTiming{pkg.Name, test})
+		}
+	}
+	sort.SliceStable(tests, func(i, j int) bool {
+		return tests[i].Test.Duration > tests[j].Test.Duration
+	})
+	if len(tests) > n {
+		tests = tests[:n]
+	}
+	return tests
+}
+
+// SlowestPackages returns the n slowest packages in report, slowest first.
+func SlowestPackages(report *parser.Report, n int) []parser.Package {
+	packages := append([]parser.Package{}, report.Packages...)
+	sort.SliceStable(packages, func(i, j int) bool {
+		return packages[i].Duration > packages[j].Duration
+	})
+	if len(packages) > n {
+		packages = packages[:n]
+	}
+	return packages
+}
+
+// slowestProperties returns properties naming the n slowest tests of pkg.
+func slowestProperties(pkg parser.Package, n int) []JUnitProperty {
+	var props []JUnitProperty
+	for i, t := range SlowestTests(&parser.Report{Packages: []parser.Package{pkg}}, n) {
+		prefix := fmt.Sprintf("tests.slowest.%d.", i+1)
+		props = append(props,
+			JUnitProperty{prefix + "name", t.Test.Name},
+			JUnitProperty{prefix + "ms", formatMillis(t.Test.Duration)},
+		)
+	}
+	return props
+}
+
+// WriteSlowestMarkdown writes markdown tables of the n slowest packages and
+// tests in report to w.
+func WriteSlowestMarkdown(report *parser.Report, n int, w io.Writer) error {
+	var err error
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	printf("## Slowest packages\n\n| Package | Time |\n| --- | ---: |\n")
+	for _, pkg := range SlowestPackages(report, n) {
+		printf("| %s | %.3fs |\n", markdownCell(pkg.Name), pkg.Duration.Seconds())
+	}
+	printf("\n## Slowest tests\n\n| Package | Test | Time |\n| --- | --- | ---: |\n")
+	for _, t := range SlowestTests(report, n) {
+		printf("| %s | %s | %.3fs |\n", markdownCell(t.Package), markdownCell(t.Test.Name), t.Test.Duration.Seconds())
+	}
+	return err
+}
+
+// markdownCell escapes s for use in a markdown table cell.
+func markdownCell(s string) string {
+	return strings.Replace(s, "|", `\|`, -1)
+}
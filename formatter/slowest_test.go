@@ -0,0 +1,60 @@
+package formatter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestSlowest(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{
+		{Name: "a", Duration: time.Second, Tests: []*parser.Test{
+			{Name: "TestA1", Duration: 300 * time.Millisecond},
+			{Name: "TestA2", Duration: 500 * time.Millisecond},
+		}},
+		{Name: "b|c", Duration: 2 * time.Second, Tests: []*parser.Test{
+			{Name: "TestB1", Duration: 300 * time.Millisecond},
+			{Name: "TestB2", Duration: 100 * time.Millisecond},
+		}},
+	}}
+
+	var names []string
+	for _, t := range SlowestTests(report, 3) {
+		names = append(names, t.Package+" "+t.Test.Name)
+	}
+	if want := "a TestA2,a TestA1,b|c TestB1"; strings.Join(names, ",") != want {
+		t.Errorf("SlowestTests == %s, want %s", strings.Join(names, ","), want)
+	}
+	if pkgs := SlowestPackages(report, 5); len(pkgs) != 2 || pkgs[0].Name != "b|c" {
+		t.Errorf("SlowestPackages == %+v", pkgs)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSlowestMarkdown(report, 1, &buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `## Slowest packages
+
+| Package | Time |
+| --- | ---: |
+| b\|c | 2.000s |
+
+## Slowest tests
+
+| Package | Test | Time |
+| --- | --- | ---: |
+| a | TestA2 | 0.500s |
+`
+	if buf.String() != expected {
+		t.Errorf("WriteSlowestMarkdown:\n%s\nwant:\n%s", buf.String(), expected)
+	}
+
+	props := slowestProperties(report.Packages[1], 1)
+	want := []JUnitProperty{{"tests.slowest.1.name", "TestB1"}, {"tests.slowest.1.ms", "300.000"}}
+	if len(props) != 2 || props[0] != want[0] || props[1] != want[1] {
+		t.Errorf("slowestProperties == %v, want %v", props, want)
+	}
+}
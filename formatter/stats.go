@@ -0,0 +1,62 @@
+package formatter
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// Stats is the summary of a report written by WriteStatsJSON, for dashboards
+// that don't need the output of every test.
+type Stats struct {
+	Tests    int            `json:"tests"`
+	Failures int            `json:"failures"`
+	Errors   int            `json:"errors"`
+	Skipped  int            `json:"skipped"`
+	Duration float64        `json:"duration"`
+	Packages []PackageStats `json:"packages"`
+}
+
+// PackageStats is the summary of a package. Durations are in seconds.
+// Coverage is the statement coverage in percent, if known. Failed lists the
+// names of the failed and errored tests.
+type PackageStats struct {
+	Name     string   `json:"name"`
+	Tests    int      `json:"tests"`
+	Failures int      `json:"failures"`
+	Errors   int      `json:"errors"`
+	Skipped  int      `json:"skipped"`
+	Duration float64  `json:"duration"`
+	Coverage *float64 `json:"coverage,omitempty"`
+	Failed   []string `json:"failed,omitempty"`
+}
+
+// ReportStats returns the summary of report.
+func ReportStats(report *parser.Report) Stats {
+	stats := Stats{Packages: []PackageStats{}, Duration: report.TotalDuration().Seconds()}
+	stats.Tests, stats.Failures, stats.Errors, stats.Skipped = report.Counts()
+	for _, pkg := range report.Packages {
+		ps := PackageStats{Name: pkg.Name, Duration: pkg.Duration.Seconds()}
+		ps.Tests, ps.Failures, ps.Errors, ps.Skipped = pkg.Counts()
+		if pkg.CoveragePct != "" {
+			coverage := pkg.Coverage
+			ps.Coverage = &coverage
+		}
+		for _, test := range pkg.AllTests() {
+			if test.Result == parser.FAIL || test.Result == parser.ERROR {
+				ps.Failed = append(ps.Failed, test.Name)
+			}
+		}
+		stats.Packages = append(stats.Packages, ps)
+	}
+	return stats
+}
+
+// WriteStatsJSON writes the summary of report returned by ReportStats as
+// indented JSON to w.
+func WriteStatsJSON(report *parser.Report, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ReportStats(report))
+}
@@ -0,0 +1,49 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestWriteStatsJSON(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{
+		{
+			Name:        "example.com/a",
+			Duration:    1500 * time.Millisecond,
+			CoveragePct: "80.0",
+			Coverage:    80,
+			Tests: []*parser.Test{
+				{Name: "TestA", Result: parser.PASS},
+				{Name: "TestB", Result: parser.FAIL},
+				{Name: "TestC", Result: parser.SKIP},
+			},
+		},
+		{Name: "example.com/b", BuildError: &parser.BuildError{Name: "[build failed]"}},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteStatsJSON(report, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var stats Stats
+	if err := json.Unmarshal(buf.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+
+	coverage := 80.0
+	want := Stats{
+		Tests: 4, Failures: 1, Errors: 1, Skipped: 1, Duration: 1.5,
+		Packages: []PackageStats{
+			{Name: "example.com/a", Tests: 3, Failures: 1, Skipped: 1, Duration: 1.5, Coverage: &coverage, Failed: []string{"TestB"}},
+			{Name: "example.com/b", Tests: 1, Errors: 1, Failed: []string{"[build failed]"}},
+		},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("stats == %+v, want %+v", stats, want)
+	}
+}
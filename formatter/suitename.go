@@ -0,0 +1,63 @@
+package formatter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var regexSuiteNamePlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// SuiteName returns the testsuite name for the package pkgName, by replacing
+// the placeholders in format:
+//
+//	{package}  the import path of the package
+//	{name}     the last element of the import path
+//	{module}   the module path, empty if unknown
+//	{path}     the import path relative to the module, the full import path
+//	           if it's not part of module
+//
+// Other text, such as a prefix, is kept as is. The package name is returned if
+// format is empty.
+func SuiteName(format, pkgName, module string) string {
+	if format == "" {
+		return pkgName
+	}
+	return regexSuiteNamePlaceholder.ReplaceAllStringFunc(format, func(placeholder string) string {
+		switch placeholder {
+		case "{package}":
+			return pkgName
+		case "{name}":
+			return pkgName[strings.LastIndex(pkgName, "/")+1:]
+		case "{module}":
+			return module
+		case "{path}":
+			return relativePath(pkgName, module)
+		}
+		return placeholder
+	})
+}
+
+// relativePath returns the import path pkgName relative to module, or pkgName
+// if it's not part of module.
+func relativePath(pkgName, module string) string {
+	if module != "" && strings.HasPrefix(pkgName, module+"/") {
+		return pkgName[len(module)+1:]
+	} else if module != "" && pkgName == module {
+		return "."
+	}
+	return pkgName
+}
+
+// CheckSuiteNameFormat returns an error if format contains placeholders that
+// are not supported by SuiteName.
+func CheckSuiteNameFormat(format string) error {
+	for _, placeholder := range regexSuiteNamePlaceholder.FindAllString(format, -1) {
+		switch placeholder {
+		case "{package}", "{name}", "{module}", "{path}":
+		default:
+			return fmt.Errorf("unknown placeholder %s", placeholder)
+		}
+	}
+	return nil
+}
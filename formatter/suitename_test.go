@@ -0,0 +1,31 @@
+package formatter
+
+import "testing"
+
+func TestSuiteName(t *testing.T) {
+	tests := []struct {
+		format, pkgName, module, want string
+	}{
+		{"", "example.com/mod/pkg/a", "example.com/mod", "example.com/mod/pkg/a"},
+		{"unit/{package}", "example.com/mod/pkg/a", "", "unit/example.com/mod/pkg/a"},
+		{"{name}", "example.com/mod/pkg/a", "", "a"},
+		{"{name}", "main", "", "main"},
+		{"{module}: {path}", "example.com/mod/pkg/a", "example.com/mod", "example.com/mod: pkg/a"},
+		{"{path}", "example.com/mod", "example.com/mod", "."},
+		{"{path}", "example.com/module/a", "example.com/mod", "example.com/module/a"},
+	}
+	for _, test := range tests {
+		if got := SuiteName(test.format, test.pkgName, test.module); got != test.want {
+			t.Errorf("SuiteName(%q, %q, %q) == %q, want %q", test.format, test.pkgName, test.module, got, test.want)
+		}
+	}
+}
+
+func TestCheckSuiteNameFormat(t *testing.T) {
+	if err := CheckSuiteNameFormat("unit/{module}/{path}-{name} {package}"); err != nil {
+		t.Errorf("CheckSuiteNameFormat returned error for valid format: %s", err)
+	}
+	if err := CheckSuiteNameFormat("{pkg}"); err == nil {
+		t.Errorf("CheckSuiteNameFormat did not return an error for unknown placeholder")
+	}
+}
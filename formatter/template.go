@@ -0,0 +1,88 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// TemplateFuncs are the functions available to templates used with
+// WriteTemplate. Functions that count tests accept either a *parser.Report or
+// a parser.Package.
+var TemplateFuncs = template.FuncMap{
+	"seconds":  func(d time.Duration) string { return fmt.Sprintf("%.3f", d.Seconds()) },
+	"millis":   func(d time.Duration) int64 { return int64(d / time.Millisecond) },
+	"tests":    func(v interface{}) (int, error) { return countTests(v, nil) },
+	"passed":   func(v interface{}) (int, error) { return countTests(v, resultIs(parser.PASS)) },
+	"failures": func(v interface{}) (int, error) { return countTests(v, resultIs(parser.FAIL)) },
+	"errors":   func(v interface{}) (int, error) { return countTests(v, resultIs(parser.ERROR)) },
+	"skipped":  func(v interface{}) (int, error) { return countTests(v, resultIs(parser.SKIP)) },
+	"failed":   failedTests,
+	"output":   testOutput,
+	"join":     strings.Join,
+	"lower":    strings.ToLower,
+}
+
+// ParseTemplateFile parses the template in the file at path, making the
+// TemplateFuncs available to it.
+func ParseTemplateFile(path string) (*template.Template, error) {
+	return template.New(filepath.Base(path)).Funcs(TemplateFuncs).ParseFiles(path)
+}
+
+// WriteTemplate renders report to w using tmpl, which must have been created
+// with the TemplateFuncs, e.g. by ParseTemplateFile.
+func WriteTemplate(report *parser.Report, tmpl *template.Template, w io.Writer) error {
+	return tmpl.Execute(w, report)
+}
+
+// testOutput returns the complete output of t, including spilled lines.
+func testOutput(t *parser.Test) (string, error) {
+	lines, err := t.AllOutput()
+	return strings.Join(lines, "\n"), err
+}
+
+func resultIs(result parser.Result) func(*parser.Test) bool {
+	return func(t *parser.Test) bool { return t.Result == result }
+}
+
+// countTests counts the tests in v matching match, or all tests if match is
+// nil. v must be a *parser.Report, a parser.Package or a *parser.Package.
+func countTests(v interface{}, match func(*parser.Test) bool) (int, error) {
+	var pkgs []parser.Package
+	switch v := v.(type) {
+	case *parser.Report:
+		pkgs = v.Packages
+	case parser.Package:
+		pkgs = []parser.Package{v}
+	case *parser.Package:
+		pkgs = []parser.Package{*v}
+	default:
+		return 0, fmt.Errorf("cannot count tests in %T", v)
+	}
+
+	count := 0
+	for _, pkg := range pkgs {
+		for _, test := range pkg.AllTests() {
+			if match == nil || match(test) {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+// failedTests returns the failed and errored tests of a package.
+func failedTests(pkg parser.Package) []*parser.Test {
+	var failed []*parser.Test
+	for _, test := range pkg.AllTests() {
+		if test.Result == parser.FAIL || test.Result == parser.ERROR {
+			failed = append(failed, test)
+		}
+	}
+	return failed
+}
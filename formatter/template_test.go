@@ -0,0 +1,46 @@
+package formatter
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestWriteTemplate(t *testing.T) {
+	report := &parser.Report{
+		Packages: []parser.Package{
+			{
+				Name:     "package/name",
+				Duration: 1500 * time.Millisecond,
+				Tests: []*parser.Test{
+					{Name: "TestOne", Result: parser.PASS},
+					{Name: "TestTwo", Result: parser.FAIL, Output: []string{"two_test.go:1: broken", "badly"}},
+					{Name: "TestThree", Result: parser.SKIP},
+				},
+			},
+		},
+	}
+
+	tmpl := template.Must(template.New("test").Funcs(TemplateFuncs).Parse(
+		`{{tests .}} tests, {{failures .}} failed, {{skipped .}} skipped
+{{range .Packages}}{{.Name}} ({{seconds .Duration}}s, {{passed .}} passed)
+{{range failed .}}- {{.Name}}: {{output .}}
+{{end}}{{end}}`))
+
+	var buf bytes.Buffer
+	if err := WriteTemplate(report, tmpl, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `3 tests, 1 failed, 1 skipped
+package/name (1.500s, 1 passed)
+- TestTwo: two_test.go:1: broken
+badly
+`
+	if buf.String() != want {
+		t.Errorf("WriteTemplate output\nEXP:\n%s\nGOT:\n%s", want, buf.String())
+	}
+}
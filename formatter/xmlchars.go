@@ -0,0 +1,69 @@
+package formatter
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// isXMLChar reports whether r may appear in an XML 1.0 document, see
+// https://www.w3.org/TR/xml/#charsets
+func isXMLChar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		r >= 0x20 && r <= 0xD7FF ||
+		r >= 0xE000 && r <= 0xFFFD ||
+		r >= 0x10000 && r <= 0x10FFFF
+}
+
+// sanitizeXML replaces the characters and invalid UTF-8 bytes in s that are
+// not allowed in XML 1.0 by placeholder. They are removed if placeholder is
+// empty.
+func sanitizeXML(s, placeholder string) string {
+	valid := func(i int) (int, bool) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		return size, (r != utf8.RuneError || size > 1) && isXMLChar(r)
+	}
+
+	i := 0
+	for i < len(s) {
+		size, ok := valid(i)
+		if !ok {
+			break
+		}
+		i += size
+	}
+	if i == len(s) {
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteString(s[:i])
+	for i < len(s) {
+		size, ok := valid(i)
+		if ok {
+			b.WriteString(s[i : i+size])
+		} else {
+			b.WriteString(placeholder)
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// escapeComment returns s with a space inserted between consecutive hyphens
+// and after a trailing hyphen, which are not allowed in XML comments.
+func escapeComment(s string) string {
+	if !strings.Contains(s, "--") && !strings.HasSuffix(s, "-") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '-' && i > 0 && s[i-1] == '-' {
+			b.WriteByte(' ')
+		}
+		b.WriteByte(s[i])
+	}
+	if strings.HasSuffix(s, "-") {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
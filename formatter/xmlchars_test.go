@@ -0,0 +1,80 @@
+package formatter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestSanitizeXML(t *testing.T) {
+	tests := []struct {
+		in, placeholder, want string
+	}{
+		{"plain text", "", "plain text"},
+		{"tab\tnewline\ncr\r", "", "tab\tnewline\ncr\r"},
+		{"nul\x00 bell\a esc\x1b", "", "nul bell esc"},
+		{"nul\x00 bell\a", "?", "nul? bell?"},
+		{"bad utf-8 \xff\xfe", "\uFFFD", "bad utf-8 \uFFFD\uFFFD"},
+		{"noncharacter \uFFFE, emoji \U0001F600", "", "noncharacter , emoji \U0001F600"},
+	}
+	for _, test := range tests {
+		if got := sanitizeXML(test.in, test.placeholder); got != test.want {
+			t.Errorf("sanitizeXML(%q, %q) == %q, want %q", test.in, test.placeholder, got, test.want)
+		}
+	}
+}
+
+func TestWriteJUnitXMLInvalidChars(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{{
+		Name:       "pkg\x01",
+		Properties: []parser.Property{{Name: "dump", Value: "\x00\x01"}},
+		Tests: []*parser.Test{
+			{Name: "TestPass\x02", Result: parser.PASS, Output: []string{"binary \x00 dump"}},
+			{Name: "TestFail", Result: parser.FAIL, Output: []string{"binary \x00 dump"}},
+		},
+	}}}
+
+	var buf bytes.Buffer
+	if err := WriteJUnitXML(report, Options{InvalidCharPlaceholder: "\u2423"}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, c := range []string{"\x00", "\x01", "\x02", "\uFFFD"} {
+		if strings.Contains(out, c) {
+			t.Errorf("output contains %q:\n%s", c, out)
+		}
+	}
+	if n := strings.Count(out, "\u2423"); n != 8 {
+		t.Errorf("output contains %d placeholders, want 8:\n%s", n, out)
+	}
+}
+
+func TestEscapeComment(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain output", "plain output"},
+		{"go test -- -v", "go test - - -v"},
+		{"----", "- - - - "},
+		{"trailing -", "trailing - "},
+	}
+	for _, test := range tests {
+		if got := escapeComment(test.in); got != test.want {
+			t.Errorf("escapeComment(%q) == %q, want %q", test.in, got, test.want)
+		}
+	}
+
+	report := &parser.Report{Packages: []parser.Package{{
+		Name:  "pkg",
+		Tests: []*parser.Test{{Name: "TestPass", Result: parser.PASS, Output: []string{"args: -- -v", "-"}}},
+	}}}
+	var buf bytes.Buffer
+	if err := WriteJUnitXML(report, Options{}, &buf); err != nil {
+		t.Fatalf("WriteJUnitXML with hyphens in a comment: %s", err)
+	}
+	if !strings.Contains(buf.String(), "<!--args: - - -v\n- -->") {
+		t.Errorf("output does not contain the escaped comment:\n%s", buf.String())
+	}
+}
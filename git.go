@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"strconv"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// gitInfo describes the commit that was tested.
+type gitInfo struct {
+	RepositoryURL string
+	Commit        string
+	Branch        string
+	Message       string
+}
+
+// gitMetadata returns information about the commit checked out in dir. Fields
+// that can't be determined, for example because dir is not in a git
+// repository, are left empty.
+func gitMetadata(dir string) gitInfo {
+	info := gitInfo{
+		RepositoryURL: gitOutput(dir, "config", "--get", "remote.origin.url"),
+		Commit:        gitOutput(dir, "rev-parse", "HEAD"),
+		Branch:        gitOutput(dir, "rev-parse", "--abbrev-ref", "HEAD"),
+		Message:       gitOutput(dir, "log", "-1", "--format=%B"),
+	}
+	if info.Branch == "HEAD" {
+		// detached
+		info.Branch = ""
+	}
+	return info
+}
+
+// gitOutput returns the trimmed output of git with args run in dir, or an
+// empty string if it fails.
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return string(bytes.TrimSpace(out))
+}
+
+// gitDirty reports whether the working tree of the git repository containing
+// dir has uncommitted changes. ok is false if that can't be determined.
+func gitDirty(dir string) (dirty, ok bool) {
+	cmd := exec.Command("git", "status", "--porcelain")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return false, false
+	}
+	return len(bytes.TrimSpace(out)) > 0, true
+}
+
+// gitProperties returns the git.sha, git.branch and git.dirty properties of
+// the tested commit. sha and branch are used if set, otherwise they and the
+// dirty status are detected in the current directory if detect is set.
+// Properties whose value is unknown are left out.
+func gitProperties(sha, branch string, detect bool) []parser.Property {
+	var props []parser.Property
+	add := func(name, value string) {
+		if value != "" {
+			props = append(props, parser.Property{Name: name, Value: value})
+		}
+	}
+	if detect {
+		info := gitMetadata(".")
+		if sha == "" {
+			sha = info.Commit
+		}
+		if branch == "" {
+			branch = info.Branch
+		}
+	}
+	add("git.sha", sha)
+	add("git.branch", branch)
+	if detect {
+		if dirty, ok := gitDirty("."); ok {
+			add("git.dirty", strconv.FormatBool(dirty))
+		}
+	}
+	return props
+}
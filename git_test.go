@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestGitProperties(t *testing.T) {
+	props := gitProperties("abc123", "main", false)
+	want := []parser.Property{{Name: "git.sha", Value: "abc123"}, {Name: "git.branch", Value: "main"}}
+	if !reflect.DeepEqual(props, want) {
+		t.Errorf("gitProperties() == %v, want %v", props, want)
+	}
+
+	if gitOutput("", "rev-parse", "HEAD") == "" {
+		t.Skip("not in a git repository")
+	}
+	found := map[string]string{}
+	for _, prop := range gitProperties("abc123", "", true) {
+		found[prop.Name] = prop.Value
+	}
+	if found["git.sha"] != "abc123" {
+		t.Errorf("git.sha == %q, want the sha that was set", found["git.sha"])
+	}
+	if dirty := found["git.dirty"]; dirty != "true" && dirty != "false" {
+		t.Errorf("git.dirty == %q, want true or false", dirty)
+	}
+}
+
+func TestGitDirtyOutsideRepository(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, ok := gitDirty(dir); ok {
+		t.Errorf("gitDirty() outside a repository reported a status")
+	}
+}
@@ -1,47 +1,767 @@
 package main
 
 import (
+	"compress/gzip"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
 
 	"github.com/hexon/go-junit-report/formatter"
 	"github.com/hexon/go-junit-report/parser"
 )
 
 var (
+	configFile           = flag.String("config", "", "read default flag values from this YAML `file` (default .go-junit-report.yaml, if it exists)")
+	flavor               = flag.String("flavor", "", "adjust the JUnit XML for the importer of a CI system: azure, circleci, datadog or gitlab")
+	format               = flag.String("format", "junit", "output `format`: "+strings.Join(formatter.Names(), ", "))
+	templateFile         = flag.String("template", "", "text/template file used to render the report with -format=template")
+	outFile              = flag.String("out", "", "write the report to this `file` instead of stdout")
+	batchDir             = flag.String("batch", "", "convert every .txt and .log file of test output in the tree rooted at this `dir` to a report with the same relative path in -out-dir, instead of reading standard input")
+	outDir               = flag.String("out-dir", "", "write a separate TEST-<package> report for each package to this `dir`, with the extension of -format, e.g. .xml for junit")
+	moduleOutDir         = flag.String("module-out-dir", "", "write a separate TEST-<module> report for each module of -modules to this `dir`, with the extension of -format, e.g. .xml for junit")
+	groupModules         = flag.Bool("group-modules", false, "order testsuites by the module of -modules their package belongs to")
+	compress             = flag.Bool("compress", false, "gzip the written reports, .gz is appended to output file names")
+	buildkiteUpload      = flag.Bool("buildkite-upload", false, "upload the results to Buildkite Test Analytics using the test suite API token in $BUILDKITE_ANALYTICS_TOKEN")
+	otlpEndpoint         = flag.String("otlp-endpoint", "", "export the results as OpenTelemetry spans to this OTLP/HTTP `url`, the span of $TRACEPARENT becomes their parent")
+	uploadURL            = flag.String("upload-url", "", "POST the report in -format to this `url` after writing it")
+	uploadTokenEnv       = flag.String("upload-token-env", "GO_JUNIT_REPORT_UPLOAD_TOKEN", "send the value of this environment `variable`, if set, as bearer token with -upload-url")
+	uploadRetries        = flag.Int("upload-retries", 3, "retry -upload-url and -upload requests up to `N` times after network errors and server errors")
+	uploadRetention      = flag.Duration("upload-retention", 0, "add the time this `duration` after the upload as retain-until metadata to objects stored by -upload")
+	pushgateway          = flag.String("pushgateway", "", "push per-package test counts and durations to the Prometheus Pushgateway at this `url`")
+	promTextfile         = flag.String("prometheus-textfile", "", "write per-package test counts and durations to this `file` for the textfile collector of the Prometheus node exporter")
+	manifestFile         = flag.String("manifest", "", "write a SHA-256 manifest of all written report files to this `file`")
+	manifestKey          = flag.String("manifest-key", "", "sign the manifest with HMAC-SHA256 using the key in this `file`, the signature is written to the manifest file name with .sig appended")
 	noXMLHeader          = flag.Bool("no-xml-header", false, "do not print xml header")
+	xmlEncoding          = flag.String("xml-encoding", "UTF-8", "`spelling` of UTF-8 in the XML declaration: UTF-8, utf-8 or utf8, reports are always encoded in UTF-8")
+	xmlStandalone        = flag.String("xml-standalone", "", "add a standalone attribute with this `value`, yes or no, to the XML declaration")
+	validate             = flag.Bool("validate", false, "check JUnit reports against the bundled JUnit schema and fail instead of writing reports that don't match it")
+	compact              = flag.Bool("compact", false, "write JUnit reports without indentation")
+	indent               = flag.String("indent", "", "indent elements of JUnit reports by this `string` per level, spaces or tabs, \\t for a tab (default a tab)")
+	xmlDoctype           = flag.String("xml-doctype", "", "write a document type declaration with this `declaration` after the XML declaration, e.g. 'testsuites SYSTEM \"junit.dtd\"'")
 	packageName          = flag.String("package-name", "", "specify a package name (compiled test have no package name in output)")
 	goVersionFlag        = flag.String("go-version", "", "specify the value to use for the go.version property in the generated XML")
+	goOS                 = flag.String("go-os", "", "specify the value to use for the go.os property (default GOOS of go-junit-report)")
+	goArch               = flag.String("go-arch", "", "specify the value to use for the go.arch property (default GOARCH of go-junit-report)")
+	numCPU               = flag.Int("num-cpu", 0, "specify the value to use for the runtime.numcpu property (default number of CPUs of this machine)")
 	setExitCode          = flag.Bool("set-exit-code", false, "set exit code to 1 if tests failed")
-	stripANSIEscape      = flag.Bool("strip-ansi-escape-codes", false, "strip ANSI escape codes (terminal color codes)")
+	buildErrors          = flag.String("build-errors", "testcase", "how to report packages that failed to build: testcase (a testcase with an error), suite (an error element in the testsuite) or both")
+	cdata                = flag.Bool("cdata", false, "write the output of tests and packages and the standard error of the test command in CDATA sections instead of escaping it")
+	stripANSIEscape      = flag.Bool("strip-ansi-escape-codes", false, "strip ANSI escape codes (terminal color codes) from test output in the report, they are always ignored when parsing")
 	fullPackageClassname = flag.Bool("full-package-classname", false, "use the full package name as the test classname instead of just the last part")
+	moduleClassname      = flag.Bool("module-classname", false, "use the module path and the package path relative to it as test classname, e.g. example.com/mod:pkg/name")
+	modulePathFlag       = flag.String("module", "", "use this module `path` for -module-classname, -suite-name-format, -source-url, file attributes and Buildkite file names (default the module path in -modfile)")
+	modFile              = flag.String("modfile", "", "read the module path from this go.mod `file` (default the go.mod file of the current directory or its closest parent)")
+	groupSubtests        = flag.String("group-subtests", "", "group subtests under their top-level test: classname (add the top-level test to the classname of its subtests) or suite (write a testsuite for every top-level test with subtests)")
+	suiteClassname       = flag.Bool("suite-classname", false, "report testify suite methods, subtests named like TestMySuite/TestSomething, as test TestSomething with the suite added to the classname")
+	durationKind         = flag.String("duration", "go", "`kind` of testcase times: go (as reported by go test), wall (from start to end of a test, including the time parallel tests were paused) or active (excluding it); wall and active require go test -json output")
+	pausedProperty       = flag.Bool("paused-property", false, "add the time parallel tests were paused, waiting for the sequential tests of their package, as paused property to their testcases (requires go test -json output)")
+	properties           propertyFlag
+	mangleReplacements   replacementFlag
+	outputs              outputFlag
+	mergeFiles           listFlag
+	includePackages      listFlag
+	modulePrefixes       listFlag
+	excludePackages      listFlag
+	includeTests         regexpFlag
+	excludeTests         regexpFlag
+	ignoredFailures      regexpFlag
+	renames              replacementFlag
+	uploadHeaders        = headerFlag{}
+	objectUploads        listFlag
+	attachments          attachFlag
+	trimPrefix           = flag.String("trim-prefix", "", "remove this `prefix`, e.g. github.com/company/repo/, from package names, and thereby from testsuite names and classnames, in all formats")
+	mangleCharset        = flag.String("mangle-charset", "", "replace characters in package and test names that are not in this regexp character `class` (e.g. A-Za-z0-9_./-)")
+	xmlPlaceholder       = flag.String("invalid-char-placeholder", "", "replace characters that are not allowed in XML, such as control characters in test output, by this `string` instead of removing them")
+	manglePlaceholder    = flag.String("mangle-placeholder", "_", "replacement for characters not allowed by -mangle-charset")
+	mangleMaxLength      = flag.Int("mangle-max-length", 0, "shorten package and test names longer than `N` bytes, keeping them unique")
+	testCommand          = flag.String("command", "", "add the `command` that ran the tests as run.command property to all testsuites (default the test command run by go-junit-report)")
+	buildTags            = flag.String("tags", "", "add the build tags the tests were run with as go.buildtags property to all testsuites (default the -tags of the test command)")
+	propertyEnv          = flag.String("prop-env", "", "add the environment variables in this comma separated `list` as testsuite properties")
+	coverageAttr         = flag.Bool("coverage-attr", false, "add the coverage percentage as coverage attribute to testsuites")
+	suiteStats           = flag.Bool("suite-stats", false, "add test duration and output size statistics as testsuite properties")
+	disabledTestsDir     = flag.String("disabled-tests", "", "list tests in the module at this `dir` that are excluded by build constraints as skipped testcases (requires the go tool)")
+	coverFuncFile        = flag.String("coverfunc", "", "add per-function and per-file coverage from this file, the output of go tool cover -func, as testsuite properties")
+	gitDetect            = flag.Bool("git", false, "add the commit, branch and dirty status of the git repository in the current directory as git.sha, git.branch and git.dirty properties to all testsuites")
+	gitSHA               = flag.String("git-sha", "", "add this commit `sha` as git.sha property to all testsuites, instead of the one found with -git, and use it for {sha} in -source-url")
+	gitBranch            = flag.String("git-branch", "", "add this `branch` as git.branch property to all testsuites, instead of the one found with -git")
+	sourceURLTemplate    = flag.String("source-url", "", "link the first location logged by failed tests to their source using this URL `template`, in which {file}, {line} and {sha}, the tested commit, are replaced, e.g. https://github.com/org/repo/blob/{sha}/{file}#L{line}")
+	codeowners           = flag.String("codeowners", "", "add the owners of the directory of each package in this CODEOWNERS `file` as owner property to its testsuite")
+	codeownersFailures   = flag.Bool("codeowners-failures", false, "with -codeowners, also add the owners of the file declaring each failed test as owner property to its testcase")
+	coverProfile         = flag.String("coverprofile", "", "add the location and the statement coverage of this coverage profile `file` as testsuite properties and attach it to all testsuites")
+	cpuTime              = flag.Bool("cpu-time", false, "when running a go test command, add the wall clock and CPU time of the test binary of each package as testsuite properties, by running the test binaries with go test -exec")
+	summary              = flag.Bool("summary", false, "print a summary of the test results to stderr")
+	summaryCluster       = flag.Bool("summary-cluster", false, "group failures with the same fingerprint in the summary")
+	summarySkipped       = flag.Int("summary-skipped", 0, "list up to `N` skipped tests and their reasons in the summary")
+	slowest              = flag.Int("slowest", 0, "list the `N` slowest packages and tests in the summary and add the N slowest tests of each package as testsuite properties")
+	histogramBuckets     = flag.String("histogram-buckets", "10ms,100ms,1s,10s", "comma separated ascending upper bounds of the duration `buckets` of the histogram format")
+	colorMode            = flag.String("color", "auto", "use colors in the console format: auto (if stdout is a terminal), always or never")
+	stats                = flag.Bool("stats", false, "print the number of packages, tests, failures, errors and skipped tests and the test and wall clock time to stderr")
+	impactMapFile        = flag.String("impact-map", "", "write a JSON mapping of tests to the packages they depend on to this file (requires the go tool)")
+	failuresOnly         = flag.Bool("failures-only", false, "only report failed tests")
+	suiteNameFormat      = flag.String("suite-name-format", "", "`format` of testsuite names, in which {package}, {name} (last element), {module} and {path} (relative to the module of the current directory) are replaced, e.g. unit/{path}")
+	subtestMode          = flag.String("subtest-mode", "all", "how to report tests with subtests: all, exclude-parents or ignore-parent-results (report failed parents as passed)")
+	subtestSpaces        = flag.Bool("subtest-spaces", false, "replace the underscores in subtest names, which go test prints instead of spaces, by spaces")
+	compareFile          = flag.String("compare", "", "compare the tests with the JUnit XML or JSON report in this `file` and exit with status 1 if tests started failing or became slower")
+	compareOut           = flag.String("compare-out", "", "write the comparison to this `file` instead of stderr")
+	compareMarkdown      = flag.Bool("compare-markdown", false, "write the comparison as markdown")
+	compareSlowdown      = flag.Float64("compare-slowdown", 2, "report tests that became slower by this `factor` as regressions")
+	compareMinSlowdown   = flag.Duration("compare-min-slowdown", 100*time.Millisecond, "ignore slowdowns of less than this `duration`")
+	baselineFile         = flag.String("baseline", "", "report tests of the JUnit XML or JSON report or go test -list output in this `file` that have no result as errors of type missing and exit with status 1")
+	quarantineFile       = flag.String("quarantine", "", "report failures of the known flaky tests listed in this `file`, one test name or regexp per line, as skipped with a quarantined property")
+	benchBaseline        = flag.String("bench-baseline", "", "report benchmarks whose ns/op increased by more than -bench-threshold compared to the go test -bench output in this `file` as failed and exit with status 1")
+	benchThreshold       = flag.Float64("bench-threshold", 10, "`percent` by which the ns/op of a benchmark must increase to be reported by -bench-baseline")
+	inputTimeout         = flag.Duration("input-timeout", 0, "stop reading and write a partial report with an error testcase if no input arrives for this `duration`, the test command is terminated")
+	maxOutputLines       = flag.Int("max-output-lines", 0, "truncate the output of each test to `N` lines, keeping the first and last lines")
+	maxOutputBytes       = flag.Int("max-output-bytes", 0, "truncate the output of each test to about `N` bytes, keeping the first and last lines")
+	maxLineBytes         = flag.Int("max-line-bytes", 0, "shorten input lines longer than `N` bytes while reading, keeping their start")
+	maxTestBytes         = flag.Int("max-test-bytes", 0, "keep at most about `N` bytes of output of each test in memory while parsing, keeping the first and last lines (not used with -spill-lines)")
+	trimGoroutines       = flag.Bool("trim-goroutines", false, "shorten the goroutine dumps of panicked tests to the panicking goroutine and the goroutines running the test")
+	goroutineDumpDir     = flag.String("goroutine-dump-dir", "", "write the complete output of tests whose goroutine dump was shortened to a file in this `dir` and attach it to the test, implies -trim-goroutines")
+	spillLines           = flag.Int("spill-lines", 0, "keep at most `N` lines of output of each test in memory and move earlier lines to temporary files (not used with -record)")
+	recordLog            = flag.String("record", "", "write a JSONL log of the parser decisions for every input line to this `file`")
+	bazelLogs            = flag.Bool("bazel", false, "parse the output of bazel test or its test.log files: remove Bazel's framing, name packages after the test targets and report only the last attempt of flaky targets")
+	progressFormat       = flag.String("progress", "", "write a live view of the test progress to stderr while parsing, `format` is verbose for all output, testname for test and package results, pkgname for package results or failures for failed tests with their output")
+	replayLog            = flag.String("replay", "", "parse the input stored in this decision log `file` again and report the lines that are parsed differently, instead of reading test output")
 )
 
+func init() {
+	flag.Var(&properties, "prop", "add a `name=value` property to all testsuites (repeatable)")
+	flag.Var(&outputs, "output", "also write the report in `format=path`, a path of - writes to stdout (repeatable)")
+	flag.Var(&mergeFiles, "merge", "merge these comma separated JUnit XML or JSON report `files` instead of parsing test output (repeatable)")
+	flag.Var(&modulePrefixes, "modules", "group packages by the longest of these comma separated module `paths` that their import path starts with and add it as go.module property, for -group-modules and -module-out-dir (repeatable, default the modules of the go.work file of the current directory)")
+	flag.Var(&includePackages, "include-packages", "only report packages matching one of these comma separated `globs` (repeatable)")
+	flag.Var(&excludePackages, "exclude-packages", "do not report packages matching one of these comma separated `globs` (repeatable)")
+	flag.Var(&includeTests, "include-tests", "only report tests whose full name matches this `regex` (repeatable)")
+	flag.Var(&ignoredFailures, "ignore-failures-matching", "report failed tests with an output line matching this `regex` as passed with a failure-ignored property, e.g. for known failures of the environment (repeatable)")
+	flag.Var(&excludeTests, "exclude-tests", "do not report tests whose full name matches this `regex` (repeatable)")
+	flag.Var(&renames, "rename", "rewrite package and test names matching regex before the report is written in any format (`regex=>replacement`, repeatable)")
+	flag.Var(&attachments, "attach", "attach a file to a package or test (`package=path` or package:test=path, repeatable), attachments are also collected from [[ATTACHMENT|path]] lines in test output")
+	flag.Var(&objectUploads, "upload", "store the written report files and the manifest in object storage at these comma separated `urls`, s3://bucket/prefix/ or gs://bucket/prefix/ (repeatable)")
+	flag.Var(uploadHeaders, "upload-header", "send this HTTP header with -upload-url (`name:value`, repeatable)")
+	flag.Var(&mangleReplacements, "mangle-replace", "replace matches of regex in package and test names (`regex=>replacement`, repeatable)")
+}
+
 func main() {
+	if file := os.Getenv(usageEnv); file != "" {
+		// run by go test as -exec program, see -cpu-time
+		os.Exit(runTestBinary(file, os.Args[1:]))
+	}
 	flag.Parse()
 
-	if flag.NArg() != 0 {
-		fmt.Fprintf(os.Stderr, "%s does not accept positional arguments\n", os.Args[0])
-		flag.Usage()
-		os.Exit(1)
+	if err := loadConfig(flag.CommandLine, *configFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading config: %s\n", err)
+		exit(1)
+	}
+
+	if *replayLog != "" {
+		changed, err := replayFile(os.Stdout, *replayLog)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error replaying %s: %s\n", *replayLog, err)
+			exit(1)
+		}
+		if changed > 0 {
+			fmt.Printf("%d lines parsed differently\n", changed)
+			exit(1)
+		}
+		return
 	}
 
-	// Read input
-	report, err := parser.Parse(os.Stdin, *packageName)
+	if *batchDir != "" {
+		failed, err := runBatch(*batchDir, *outDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error in batch mode: %s\n", err)
+			exit(1)
+		}
+		if *manifestFile != "" {
+			if err := writeManifest(*manifestFile, *manifestKey, outputFiles); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing manifest: %s\n", err)
+				exit(1)
+			}
+		}
+		if len(objectUploads) > 0 {
+			if err := uploadObjects(objectUploads); err != nil {
+				fmt.Fprintf(os.Stderr, "Error uploading to object storage: %s\n", err)
+				exit(1)
+			}
+		}
+		if *setExitCode && failed {
+			exit(1)
+		}
+		return
+	}
+
+	// Read input, either from stdin, from the test command given as
+	// arguments or from the reports to merge
+	var report *parser.Report
+	var cmd *command
+	var err error
+	var interruption string
+	if len(mergeFiles) > 0 {
+		report, err = mergeReports(mergeFiles)
+	} else {
+		var input io.Reader = os.Stdin
+		if flag.NArg() > 0 {
+			if cmd, err = startCommand(flag.Args(), *cpuTime); err != nil {
+				fmt.Fprintf(os.Stderr, "Error running %s: %s\n", flag.Arg(0), err)
+				exit(1)
+			}
+			input = cmd.stdout
+		}
+		in := newInterruptibleReader(input)
+		stopSignals := interruptOnSignal(in, cmd)
+		if *inputTimeout > 0 {
+			interruptOnInactivity(in, cmd, *inputTimeout)
+		}
+		report, err = parseInput(in)
+		stopSignals()
+		if err == nil {
+			if interruption = in.interrupted(); interruption != "" {
+				markIncomplete(report, interruption)
+			}
+			if in.inputStalled() {
+				addStalledTest(report, *packageName, interruption)
+			}
+		}
+	}
 	if err != nil {
 		fmt.Printf("Error reading input: %s\n", err)
-		os.Exit(1)
+		exit(1)
+	}
+
+	var cmdErr error
+	if cmd != nil {
+		cmdErr = cmd.wait()
+		report.Stderr = cmd.stderrLines()
+		if *cpuTime {
+			if err := addUsageProperties(report); err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading CPU times: %s\n", err)
+				exit(1)
+			}
+		}
+	}
+
+	benchRegressed := 0
+	if *benchBaseline != "" {
+		if benchRegressed, err = markBenchRegressions(report, *benchBaseline, *benchThreshold); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading benchmark baseline: %s\n", err)
+			exit(1)
+		}
+	}
+
+	quarantined := 0
+	if *quarantineFile != "" {
+		patterns, err := readQuarantine(*quarantineFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading quarantine list: %s\n", err)
+			exit(1)
+		}
+		quarantined = quarantineTests(report, patterns)
+	}
+
+	ignored := 0
+	if len(ignoredFailures) > 0 {
+		ignored = ignoreFailures(report, ignoredFailures)
+	}
+	// the test command also fails if only quarantined or ignored tests
+	// failed, its exit status is ignored only if all failures were
+	// downgraded
+	downgraded := quarantined+ignored > 0 && !hasFailures(report)
+
+	// the tests that ran are compared with the baseline after the report
+	// was filtered and renamed, like the report the baseline was read from
+	var ran *parser.Report
+	if *baselineFile != "" {
+		ran = ranTests(report)
+	}
+
+	if err := transformReport(report); err != nil {
+		fmt.Fprintf(os.Stderr, "Error %s\n", err)
+		exit(1)
+	}
+
+	missing := 0
+	if *baselineFile != "" {
+		if missing, err = markMissingTests(report, ran, *baselineFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading baseline: %s\n", err)
+			exit(1)
+		}
+	}
+
+	// Write report
+	opts, err := formatOptions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %s\n", err)
+		exit(1)
+	}
+	f, err := formatter.New(*format, opts)
+	if err == nil && *outDir != "" {
+		err = writeReportDir(f, *format, report, *outDir)
+	}
+	if err == nil && *moduleOutDir != "" {
+		err = writeModuleDir(f, *format, report, *moduleOutDir)
+	}
+	if err == nil && ((*outDir == "" && *moduleOutDir == "" && len(outputs) == 0) || *outFile != "") {
+		err = writeReport(f, report, *outFile)
+	}
+	if err == nil {
+		err = writeOutputs(report, outputs, opts)
+	}
+	if err != nil {
+		fmt.Printf("Error writing report: %s\n", err)
+		exit(1)
+	}
+
+	if *uploadURL != "" {
+		if err := uploadReport(f, *format, report, *uploadURL, uploadHeaders, os.Getenv(*uploadTokenEnv), *uploadRetries); err != nil {
+			fmt.Fprintf(os.Stderr, "Error uploading report: %s\n", err)
+			exit(1)
+		}
+	}
+
+	if *impactMapFile != "" {
+		if err := writeImpactMap(report, *impactMapFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing impact map: %s\n", err)
+			exit(1)
+		}
+		outputFiles = append(outputFiles, *impactMapFile)
+	}
+
+	if *buildkiteUpload {
+		if err := uploadBuildkite(report, opts, os.Getenv("BUILDKITE_ANALYTICS_TOKEN")); err != nil {
+			fmt.Fprintf(os.Stderr, "Error uploading to Buildkite: %s\n", err)
+			exit(1)
+		}
+	}
+
+	if *otlpEndpoint != "" {
+		if err := uploadOTLP(report, opts, *otlpEndpoint); err != nil {
+			fmt.Fprintf(os.Stderr, "Error exporting spans: %s\n", err)
+			exit(1)
+		}
+	}
+
+	if *pushgateway != "" {
+		if err := pushPrometheus(report, *pushgateway); err != nil {
+			fmt.Fprintf(os.Stderr, "Error pushing metrics: %s\n", err)
+			exit(1)
+		}
+	}
+	if *promTextfile != "" {
+		if err := writePrometheusTextfile(report, *promTextfile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing metrics: %s\n", err)
+			exit(1)
+		}
+	}
+
+	if *stats {
+		writeStats(os.Stderr, report, time.Since(startTime))
+	}
+
+	regressed := false
+	if *compareFile != "" {
+		var err error
+		if regressed, err = compare(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error comparing with %s: %s\n", *compareFile, err)
+			exit(1)
+		}
+	}
+
+	if *manifestFile != "" {
+		if err := writeManifest(*manifestFile, *manifestKey, outputFiles); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing manifest: %s\n", err)
+			exit(1)
+		}
+	}
+
+	if len(objectUploads) > 0 {
+		if err := uploadObjects(objectUploads); err != nil {
+			fmt.Fprintf(os.Stderr, "Error uploading to object storage: %s\n", err)
+			exit(1)
+		}
+	}
+
+	if *summary {
+		writeSummary(os.Stderr, report, summaryOptions{
+			maxSkipped: *summarySkipped,
+			clusters:   *summaryCluster,
+			slowest:    *slowest,
+		})
+	}
+
+	if benchRegressed > 0 {
+		fmt.Fprintf(os.Stderr, "Benchmarks regressed compared to %s: %d\n", *benchBaseline, benchRegressed)
+	}
+
+	if quarantined > 0 {
+		fmt.Fprintf(os.Stderr, "Quarantined failed tests: %d\n", quarantined)
+	}
+	if ignored > 0 {
+		fmt.Fprintf(os.Stderr, "Ignored test failures: %d\n", ignored)
+	}
+	if missing > 0 {
+		fmt.Fprintf(os.Stderr, "Tests of %s without a result: %d\n", *baselineFile, missing)
+	}
+
+	if interruption != "" {
+		fmt.Fprintf(os.Stderr, "Wrote partial report, %s\n", interruption)
+		exit(1)
+	}
+	if regressed || benchRegressed > 0 || missing > 0 || (*setExitCode && (hasFailures(report) || (cmdErr != nil && !downgraded))) {
+		exit(1)
+	}
+	exit(0)
+}
+
+// transformReport adds the requested properties and disabled tests to report
+// and filters and renames its packages and tests.
+func transformReport(report *parser.Report) error {
+	addProperties(report, properties)
+	addProperties(report, envProperties(*propertyEnv))
+	addProperties(report, gitProperties(*gitSHA, *gitBranch, *gitDetect))
+
+	command := *testCommand
+	if command == "" && flag.NArg() > 0 {
+		command = commandLine(flag.Args())
+	}
+	if command != "" {
+		addProperties(report, []parser.Property{{Name: "run.command", Value: command}})
+	}
+
+	tags := *buildTags
+	if tags == "" {
+		tags = commandBuildTags(flag.Args())
+	}
+	addBuildTags(report, tags)
+
+	if *disabledTestsDir != "" {
+		if err := addDisabledTests(report, *disabledTestsDir); err != nil {
+			return fmt.Errorf("finding disabled tests: %s", err)
+		}
+	}
+
+	if *coverFuncFile != "" {
+		if err := addCoverFuncProperties(report, *coverFuncFile); err != nil {
+			return fmt.Errorf("reading coverfunc: %s", err)
+		}
+	}
+
+	if add, ok := flavorReports[*flavor]; ok {
+		if err := add(report); err != nil {
+			return fmt.Errorf("in -flavor=%s: %s", *flavor, err)
+		}
+	}
+
+	if *coverProfile != "" {
+		if err := addCoverProfile(report, *coverProfile); err != nil {
+			return fmt.Errorf("reading coverprofile: %s", err)
+		}
+	}
+
+	if *codeowners != "" {
+		if err := addOwners(report, *codeowners, *codeownersFailures); err != nil {
+			return fmt.Errorf("reading codeowners: %s", err)
+		}
 	}
 
-	// Write xml
-	err = formatter.JUnitReportXML(report, *noXMLHeader, *goVersionFlag, *fullPackageClassname, *stripANSIEscape, os.Stdout)
+	if err := addAttachments(report, attachments); err != nil {
+		return fmt.Errorf("collecting attachments: %s", err)
+	}
+
+	if *groupModules || *moduleOutDir != "" || len(modulePrefixes) > 0 {
+		modules, err := reportModules(modulePrefixes)
+		if err != nil {
+			return fmt.Errorf("finding modules: %s", err)
+		}
+		addModules(report, modules, *groupModules)
+	}
+
+	if err := report.FilterPackages(includePackages, excludePackages); err != nil {
+		return fmt.Errorf("in package filter: %s", err)
+	}
+	if len(includeTests) > 0 || len(excludeTests) > 0 || *failuresOnly {
+		report.FilterTests(testFilter(includeTests, excludeTests, *failuresOnly))
+	}
+	if err := applySubtestMode(report, *subtestMode); err != nil {
+		return fmt.Errorf("in -subtest-mode: %s", err)
+	}
+	if err := renameReport(report); err != nil {
+		return err
+	}
+	if *goroutineDumpDir != "" {
+		if err := os.MkdirAll(*goroutineDumpDir, 0755); err != nil {
+			return fmt.Errorf("in -goroutine-dump-dir: %s", err)
+		}
+	}
+	if *trimGoroutines || *goroutineDumpDir != "" {
+		if err := report.TrimGoroutineDumps(*goroutineDumpDir); err != nil {
+			return fmt.Errorf("trimming goroutine dumps: %s", err)
+		}
+	}
+	if err := report.TruncateOutput(*maxOutputLines, *maxOutputBytes); err != nil {
+		return fmt.Errorf("truncating output: %s", err)
+	}
+	return nil
+}
+
+// renameReport applies the flags renaming packages and tests to report.
+func renameReport(report *parser.Report) error {
+	if *subtestSpaces {
+		report.RestoreSubtestSpaces()
+	}
+	report.TrimPackagePrefix(*trimPrefix)
+	if len(renames) > 0 {
+		report.Rename((&formatter.Mangler{Replacements: renames}).Mangle)
+	}
+	mangler, err := newMangler(mangleReplacements, *mangleCharset, *manglePlaceholder, *mangleMaxLength)
+	if err != nil {
+		return fmt.Errorf("in name mangling flags: %s", err)
+	}
+	if mangler != nil {
+		report.Rename(mangler.Mangle)
+	}
+	return nil
+}
+
+// formatOptions returns the formatter options given by the flags.
+func formatOptions() (formatter.Options, error) {
+	color, err := useColor(*colorMode)
+	if err != nil {
+		return formatter.Options{}, fmt.Errorf("in -color: %s", err)
+	}
+
+	if err := formatter.CheckBuildErrors(*buildErrors); err != nil {
+		return formatter.Options{}, fmt.Errorf("in -build-errors: %s", err)
+	}
+
+	if err := formatter.CheckXMLDeclaration(*xmlEncoding, *xmlStandalone); err != nil {
+		return formatter.Options{}, fmt.Errorf("in XML declaration: %s", err)
+	}
+
+	indentString := strings.Replace(*indent, `\t`, "\t", -1)
+	if strings.Trim(indentString, " \t") != "" {
+		return formatter.Options{}, fmt.Errorf("in -indent: %q is not made of spaces and tabs", *indent)
+	}
+
+	if _, err := formatter.ParseHistogramBuckets(*histogramBuckets); err != nil {
+		return formatter.Options{}, fmt.Errorf("in -histogram-buckets: %s", err)
+	}
+
+	if err := formatter.CheckSuiteNameFormat(*suiteNameFormat); err != nil {
+		return formatter.Options{}, fmt.Errorf("in -suite-name-format: %s", err)
+	}
+
+	if err := formatter.CheckGroupSubtests(*groupSubtests); err != nil {
+		return formatter.Options{}, fmt.Errorf("in -group-subtests: %s", err)
+	}
+
+	if err := formatter.CheckDuration(*durationKind); err != nil {
+		return formatter.Options{}, fmt.Errorf("in -duration: %s", err)
+	}
+
+	sourceURL, err := expandSourceURL(*sourceURLTemplate, *modFile)
+	if err != nil {
+		return formatter.Options{}, fmt.Errorf("in -source-url: %s", err)
+	}
+
+	opts := formatter.Options{
+		NoXMLHeader:            *noXMLHeader,
+		XMLEncoding:            *xmlEncoding,
+		XMLStandalone:          *xmlStandalone,
+		XMLDoctype:             *xmlDoctype,
+		Compact:                *compact,
+		Validate:               *validate,
+		Indent:                 indentString,
+		GoVersion:              *goVersionFlag,
+		GoOS:                   *goOS,
+		GoArch:                 *goArch,
+		NumCPU:                 *numCPU,
+		FullPackageClassname:   *fullPackageClassname,
+		ModuleClassname:        *moduleClassname,
+		SuiteClassname:         *suiteClassname,
+		GroupSubtests:          *groupSubtests,
+		StripANSIEscape:        *stripANSIEscape,
+		CDATA:                  *cdata,
+		CoverageAttr:           *coverageAttr,
+		SuiteStats:             *suiteStats,
+		Slowest:                *slowest,
+		HistogramBuckets:       *histogramBuckets,
+		Duration:               *durationKind,
+		PausedProperty:         *pausedProperty,
+		Color:                  color,
+		SuiteNameFormat:        *suiteNameFormat,
+		InvalidCharPlaceholder: *xmlPlaceholder,
+		Template:               *templateFile,
+		BuildErrors:            *buildErrors,
+		Buildkite:              buildkiteRunEnv(),
+		TraceParent:            os.Getenv("TRACEPARENT"),
+		SourceURL:              sourceURL,
+	}
+	if opts.GoOS == "" {
+		opts.GoOS = runtime.GOOS
+	}
+	if opts.GoArch == "" {
+		opts.GoArch = runtime.GOARCH
+	}
+	if opts.NumCPU <= 0 {
+		opts.NumCPU = runtime.NumCPU()
+	}
+	if err := applyFlavor(&opts, *flavor); err != nil {
+		return formatter.Options{}, fmt.Errorf("in -flavor: %s", err)
+	}
+	if opts.SuiteNameFormat != "" || opts.FileAttr || opts.ModuleClassname || opts.SourceURL != "" || *buildkiteUpload || usesFormat("buildkite") {
+		if opts.Module, err = moduleFlagPath(*modulePathFlag, *modFile); err != nil {
+			return formatter.Options{}, fmt.Errorf("finding module: %s", err)
+		}
+	}
+	return opts, nil
+}
+
+// usesFormat returns whether the report is written in the format name, with
+// -format or -output.
+func usesFormat(name string) bool {
+	if *format == name {
+		return true
+	}
+	for _, out := range outputs {
+		if out.format == name {
+			return true
+		}
+	}
+	return false
+}
+
+// spillDir is the temporary directory test output is spilled to, if any.
+var spillDir string
+
+// exit removes spillDir and usageFile, if any, and exits with code.
+func exit(code int) {
+	if spillDir != "" {
+		os.RemoveAll(spillDir)
+	}
+	if usageFile != "" {
+		os.Remove(usageFile)
+	}
+	os.Exit(code)
+}
+
+// parseInput parses the go test output read from r, which may be gzip
+// compressed. The parser decisions are recorded if -record is set, otherwise
+// test output is spilled to disk if -spill-lines is set. The progress is
+// written to stderr if -progress is set. Bazel logs are parsed per target if
+// -bazel is set.
+func parseInput(r io.Reader) (*parser.Report, error) {
+	input, err := maybeGunzip(r)
 	if err != nil {
-		fmt.Printf("Error writing XML: %s\n", err)
-		os.Exit(1)
+		return nil, err
+	}
+	opts := []parser.Option{
+		parser.WithPackageName(*packageName),
+		parser.WithMaxLineBytes(*maxLineBytes),
+		parser.WithMaxTestBytes(*maxTestBytes),
+	}
+	var progress *progressWriter
+	if *progressFormat != "" {
+		if progress, err = newProgressWriter(os.Stderr, *progressFormat); err != nil {
+			return nil, fmt.Errorf("in -progress: %s", err)
+		}
+	}
+	if *bazelLogs {
+		if progress != nil {
+			opts = append(opts, parser.WithRecorder(progress.record))
+		}
+		return parseBazel(input, opts)
+	}
+	if *recordLog == "" {
+		if progress != nil {
+			opts = append(opts, parser.WithRecorder(progress.record))
+		}
+		if *spillLines <= 0 {
+			return parser.ParseWithOptions(input, opts...)
+		}
+		if spillDir, err = ioutil.TempDir("", "go-junit-report"); err != nil {
+			return nil, err
+		}
+		return parser.ParseWithOptions(input, append(opts, parser.WithSpill(spillDir, *spillLines))...)
 	}
 
-	if *setExitCode && report.Failures() > 0 {
-		os.Exit(1)
+	rw, err := newRecordWriter(*recordLog)
+	if err != nil {
+		return nil, err
 	}
+	report, err := parser.ParseWithOptions(input, append(opts, parser.WithRecorder(func(rec parser.Record) {
+		rw.record(rec)
+		if progress != nil {
+			progress.record(rec)
+		}
+	}))...)
+	if cerr := rw.Close(); err == nil {
+		err = cerr
+	}
+	return report, err
 }
+
+// writeReport writes report using f to filename, or to stdout if filename is
+// empty. The output is gzip compressed if -compress is set.
+func writeReport(f formatter.Formatter, report *parser.Report, filename string) error {
+	var out io.WriteCloser = nopCloser{os.Stdout}
+	var tmp *os.File
+	if filename != "" {
+		if *compress {
+			filename = compressedName(filename)
+		}
+		// the report is written to a temporary file that replaces filename
+		// once it's complete, so a failure doesn't leave a truncated report
+		var err error
+		if tmp, err = ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".*"); err != nil {
+			return err
+		}
+		if err := tmp.Chmod(0644); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+		out = tmp
+	}
+
+	w := io.Writer(out)
+	var zw *gzip.Writer
+	if *compress {
+		zw = gzip.NewWriter(out)
+		w = zw
+	}
+
+	err := f.Write(report, w)
+	if zw != nil {
+		if cerr := zw.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if tmp == nil {
+		return err
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	outputFiles = append(outputFiles, filename)
+	return nil
+}
+
+// nopCloser is a WriteCloser whose Close method does nothing.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
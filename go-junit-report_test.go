@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
@@ -63,6 +64,9 @@ var testCases = []TestCase{
 					Name:     "package/name",
 					Duration: 151 * time.Millisecond,
 					Time:     151,
+					Output: []string{
+						"exit status 1",
+					},
 					Tests: []*parser.Test{
 						{
 							Name:     "TestOne",
@@ -208,6 +212,9 @@ var testCases = []TestCase{
 					Name:     "package/name2",
 					Duration: 151 * time.Millisecond,
 					Time:     151,
+					Output: []string{
+						"exit status 1",
+					},
 					Tests: []*parser.Test{
 						{
 							Name:     "TestOne",
@@ -319,6 +326,7 @@ var testCases = []TestCase{
 						},
 					},
 					CoveragePct: "13.37",
+					Coverage:    13.37,
 				},
 			},
 		},
@@ -349,6 +357,7 @@ var testCases = []TestCase{
 						},
 					},
 					CoveragePct: "10.0",
+					Coverage:    10.0,
 				},
 				{
 					Name:     "package2/bar",
@@ -364,6 +373,7 @@ var testCases = []TestCase{
 						},
 					},
 					CoveragePct: "99.8",
+					Coverage:    99.8,
 				},
 			},
 		},
@@ -582,47 +592,35 @@ var testCases = []TestCase{
 					},
 				},
 				{
-					Name: "package/name/failing1",
-					Tests: []*parser.Test{
-						{
-							Name:     "[build failed]",
-							Duration: 0,
-							Time:     0,
-							Result:   parser.ERROR,
-							Output: []string{
-								"failing1/failing_test.go:15: undefined: x",
-							},
+					Name:  "package/name/failing1",
+					Tests: []*parser.Test{},
+					BuildError: &parser.BuildError{
+						Name: "[build failed]",
+						Output: []string{
+							"failing1/failing_test.go:15: undefined: x",
 						},
 					},
 				},
 				{
-					Name: "package/name/failing2",
-					Tests: []*parser.Test{
-						{
-							Name:     "[build failed]",
-							Duration: 0,
-							Time:     0,
-							Result:   parser.ERROR,
-							Output: []string{
-								"failing2/another_failing_test.go:20: undefined: y",
-							},
+					Name:  "package/name/failing2",
+					Tests: []*parser.Test{},
+					BuildError: &parser.BuildError{
+						Name: "[build failed]",
+						Output: []string{
+							"failing2/another_failing_test.go:20: undefined: y",
 						},
 					},
 				},
 				{
-					Name: "package/name/setupfailing1",
-					Tests: []*parser.Test{
-						{
-							Name:     "[setup failed]",
-							Duration: 0,
-							Time:     0,
-							Result:   parser.ERROR,
-							Output: []string{
-								"setupfailing1/failing_test.go:4: cannot find package \"other/package\" in any of:",
-								"\t/path/vendor (vendor tree)",
-								"\t/path/go/root (from $GOROOT)",
-								"\t/path/go/path (from $GOPATH)",
-							},
+					Name:  "package/name/setupfailing1",
+					Tests: []*parser.Test{},
+					BuildError: &parser.BuildError{
+						Name: "[setup failed]",
+						Output: []string{
+							"setupfailing1/failing_test.go:4: cannot find package \"other/package\" in any of:",
+							"\t/path/vendor (vendor tree)",
+							"\t/path/go/root (from $GOROOT)",
+							"\t/path/go/path (from $GOPATH)",
 						},
 					},
 				},
@@ -676,7 +674,10 @@ var testCases = []TestCase{
 					Name:     "package/empty",
 					Duration: 1 * time.Millisecond,
 					Time:     1,
-					Tests:    []*parser.Test{},
+					Output: []string{
+						"testing: warning: no tests to run",
+					},
+					Tests: []*parser.Test{},
 				},
 			},
 		},
@@ -726,6 +727,9 @@ var testCases = []TestCase{
 					Name:     "race_test",
 					Duration: 15 * time.Millisecond,
 					Time:     15,
+					Output: []string{
+						"exit status 1",
+					},
 					Tests: []*parser.Test{
 						{
 							Name:     "TestRace",
@@ -801,6 +805,7 @@ var testCases = []TestCase{
 						},
 					},
 					CoveragePct: "10.0",
+					Coverage:    10.0,
 				},
 				{
 					Name:     "package2/bar",
@@ -816,6 +821,7 @@ var testCases = []TestCase{
 						},
 					},
 					CoveragePct: "99.8",
+					Coverage:    99.8,
 				},
 			},
 		},
@@ -858,6 +864,9 @@ var testCases = []TestCase{
 					Name:     "pkg/parallel",
 					Duration: 3010 * time.Millisecond,
 					Time:     3010,
+					Output: []string{
+						"exit status 1",
+					},
 					Tests: []*parser.Test{
 						{
 							Name:     "FirstTest",
@@ -926,11 +935,17 @@ var testCases = []TestCase{
 					Name:     "package/basic",
 					Duration: 3212 * time.Millisecond,
 					Time:     3212,
+					Output: []string{
+						"goos: darwin",
+						"goarch: amd64",
+						"pkg: code.internal/state",
+					},
 					Tests: []*parser.Test{
 						{
 							Name:     "BenchmarkParse",
 							Duration: 604 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkParse-8                     2000000\t       604 ns/op",
 							},
@@ -939,6 +954,7 @@ var testCases = []TestCase{
 							Name:     "BenchmarkReadingList",
 							Duration: 1425 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkReadingList-8               1000000\t      1425 ns/op",
 							},
@@ -957,11 +973,17 @@ var testCases = []TestCase{
 					Name:     "package/one",
 					Duration: 9415 * time.Millisecond,
 					Time:     9415,
+					Output: []string{
+						"goos: darwin",
+						"goarch: amd64",
+						"pkg: code.internal/state",
+					},
 					Tests: []*parser.Test{
 						{
 							Name:     "BenchmarkIpsHistoryInsert",
 							Duration: 52568 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkIpsHistoryInsert-8 30000\t52568 ns/op\t24879 B/op\t494 allocs/op",
 							},
@@ -970,6 +992,7 @@ var testCases = []TestCase{
 							Name:     "BenchmarkIpsHistoryLookup",
 							Duration: 15208 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkIpsHistoryLookup-8 100000\t15208 ns/op\t7369 B/op\t143 allocs/op",
 							},
@@ -988,6 +1011,11 @@ var testCases = []TestCase{
 					Name:     "package3/baz",
 					Duration: 1382 * time.Millisecond,
 					Time:     1382,
+					Output: []string{
+						"goos: darwin",
+						"goarch: amd64",
+						"pkg: package3/baz",
+					},
 					Tests: []*parser.Test{
 						{
 							Name:     "TestNew",
@@ -1021,6 +1049,7 @@ var testCases = []TestCase{
 							Name:     "BenchmarkDeepMerge",
 							Duration: 2611 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkDeepMerge-8      500000       2611 ns/op     1110 B/op       16 allocs/op",
 							},
@@ -1029,6 +1058,7 @@ var testCases = []TestCase{
 							Name:     "BenchmarkNext",
 							Duration: 100 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkNext-8           500000       100 ns/op      100 B/op        1 allocs/op",
 							},
@@ -1052,6 +1082,7 @@ var testCases = []TestCase{
 							Name:     "BenchmarkNew",
 							Duration: 345 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkNew-8   \t 5000000\t       350 ns/op\t      80 B/op\t       3 allocs/op",
 								"BenchmarkNew-8   \t 5000000\t       357 ns/op\t      80 B/op\t       3 allocs/op",
@@ -1064,6 +1095,7 @@ var testCases = []TestCase{
 							Name:     "BenchmarkFew",
 							Duration: 102 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkFew-8   \t 5000000\t       100 ns/op\t      20 B/op\t       1 allocs/op",
 								"BenchmarkFew-8   \t 5000000\t       105 ns/op\t      20 B/op\t       1 allocs/op",
@@ -1086,11 +1118,15 @@ var testCases = []TestCase{
 					Name:     "mycode/common",
 					Duration: 7267 * time.Millisecond,
 					Time:     7267,
+					Output: []string{
+						"pkg: mycode/common",
+					},
 					Tests: []*parser.Test{
 						{
 							Name:     "BenchmarkParse",
 							Duration: 1591 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkParse-8                   \t 1000000\t      1591 ns/op",
 							},
@@ -1099,6 +1135,7 @@ var testCases = []TestCase{
 							Name:     "BenchmarkNewTask",
 							Duration: 391 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkNewTask-8                 \t 3000000\t       391 ns/op",
 							},
@@ -1109,11 +1146,15 @@ var testCases = []TestCase{
 					Name:     "mycode/benchmarks/channels",
 					Duration: 47084 * time.Millisecond,
 					Time:     47084,
+					Output: []string{
+						"pkg: mycode/benchmarks/channels",
+					},
 					Tests: []*parser.Test{
 						{
 							Name:     "BenchmarkFanout/Channel/10",
 							Duration: 4673 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkFanout/Channel/10-8         \t  500000\t      4673 ns/op",
 							},
@@ -1122,6 +1163,7 @@ var testCases = []TestCase{
 							Name:     "BenchmarkFanout/Channel/100",
 							Duration: 24965 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkFanout/Channel/100-8        \t   50000\t     24965 ns/op",
 							},
@@ -1130,6 +1172,7 @@ var testCases = []TestCase{
 							Name:     "BenchmarkFanout/Channel/1000",
 							Duration: 195672 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkFanout/Channel/1000-8       \t   10000\t    195672 ns/op",
 							},
@@ -1138,6 +1181,7 @@ var testCases = []TestCase{
 							Name:     "BenchmarkFanout/Channel/10000",
 							Duration: 2410200 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkFanout/Channel/10000-8      \t     500\t   2410200 ns/op",
 							},
@@ -1156,11 +1200,17 @@ var testCases = []TestCase{
 					Name:     "really/small",
 					Duration: 4344 * time.Millisecond,
 					Time:     4344,
+					Output: []string{
+						"goos: darwin",
+						"goarch: amd64",
+						"pkg: really/small",
+					},
 					Tests: []*parser.Test{
 						{
 							Name:     "BenchmarkItsy",
 							Duration: 45 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkItsy-8    \t  30000000\t         45.7 ns/op",
 							},
@@ -1169,6 +1219,7 @@ var testCases = []TestCase{
 							Name:     "BenchmarkTeeny",
 							Duration: 2 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkTeeny-8      1000000000\t         2.12 ns/op",
 							},
@@ -1177,6 +1228,7 @@ var testCases = []TestCase{
 							Name:     "BenchmarkWeeny",
 							Duration: 0 * time.Second,
 							Result:   parser.PASS,
+							CPU:      8,
 							Output: []string{
 								"BenchmarkWeeny-8      2000000000\t         0.26 ns/op",
 							},
@@ -1195,11 +1247,15 @@ var testCases = []TestCase{
 					Name:     "single/cpu",
 					Duration: 9467 * time.Millisecond,
 					Time:     9467,
+					Output: []string{
+						"pkg: single/cpu",
+					},
 					Tests: []*parser.Test{
 						{
 							Name:     "BenchmarkRing",
 							Duration: 74 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      1,
 							Output: []string{
 								"BenchmarkRing        \t20000000\t        74.2 ns/op",
 							},
@@ -1218,11 +1274,15 @@ var testCases = []TestCase{
 					Name:     "sixteen/cpu",
 					Duration: 1522 * time.Millisecond,
 					Time:     1522,
+					Output: []string{
+						"pkg: sixteen/cpu",
+					},
 					Tests: []*parser.Test{
 						{
 							Name:     "BenchmarkRingaround",
 							Duration: 13571 * time.Nanosecond,
 							Result:   parser.PASS,
+							CPU:      16,
 							Output: []string{
 								"BenchmarkRingaround-16    \t  100000\t     13571 ns/op",
 							},
@@ -1479,47 +1539,35 @@ var testCases = []TestCase{
 					},
 				},
 				{
-					Name: "package/name/failing1",
-					Tests: []*parser.Test{
-						{
-							Name:     "[build failed]",
-							Duration: 0,
-							Time:     0,
-							Result:   parser.ERROR,
-							Output: []string{
-								"failing1/failing_test.go:15: undefined: x",
-							},
+					Name:  "package/name/failing1",
+					Tests: []*parser.Test{},
+					BuildError: &parser.BuildError{
+						Name: "[build failed]",
+						Output: []string{
+							"failing1/failing_test.go:15: undefined: x",
 						},
 					},
 				},
 				{
-					Name: "package/name/failing2",
-					Tests: []*parser.Test{
-						{
-							Name:     "[build failed]",
-							Duration: 0,
-							Time:     0,
-							Result:   parser.ERROR,
-							Output: []string{
-								"failing2/another_failing_test.go:20: undefined: y",
-							},
+					Name:  "package/name/failing2",
+					Tests: []*parser.Test{},
+					BuildError: &parser.BuildError{
+						Name: "[build failed]",
+						Output: []string{
+							"failing2/another_failing_test.go:20: undefined: y",
 						},
 					},
 				},
 				{
-					Name: "package/name/setupfailing1",
-					Tests: []*parser.Test{
-						{
-							Name:     "[setup failed]",
-							Duration: 0,
-							Time:     0,
-							Result:   parser.ERROR,
-							Output: []string{
-								"setupfailing1/failing_test.go:4: cannot find package \"other/package\" in any of:",
-								"\t/path/vendor (vendor tree)",
-								"\t/path/go/root (from $GOROOT)",
-								"\t/path/go/path (from $GOPATH)",
-							},
+					Name:  "package/name/setupfailing1",
+					Tests: []*parser.Test{},
+					BuildError: &parser.BuildError{
+						Name: "[setup failed]",
+						Output: []string{
+							"setupfailing1/failing_test.go:4: cannot find package \"other/package\" in any of:",
+							"\t/path/vendor (vendor tree)",
+							"\t/path/go/root (from $GOROOT)",
+							"\t/path/go/path (from $GOPATH)",
 						},
 					},
 				},
@@ -1564,6 +1612,9 @@ var testCases = []TestCase{
 					Name:     "example.com/test",
 					Duration: 5 * time.Millisecond,
 					Time:     5,
+					Output: []string{
+						"exit status 1",
+					},
 					Tests: []*parser.Test{
 						{
 							Name:     "TestFoo",
@@ -1590,82 +1641,878 @@ var testCases = []TestCase{
 			},
 		},
 	},
-}
-
-func TestParser(t *testing.T) {
-	for _, testCase := range testCases {
-		t.Run(testCase.name, func(t *testing.T) {
-			file, err := os.Open("testdata/" + testCase.name)
-			if err != nil {
-				t.Fatal(err)
-			}
-
-			report, err := parser.Parse(file, testCase.packageName)
-			if err != nil {
-				t.Fatalf("error parsing: %s", err)
-			}
-
-			if report == nil {
-				t.Fatalf("Report == nil")
-			}
-
-			expected := testCase.report
-			if len(report.Packages) != len(expected.Packages) {
-				t.Fatalf("Report packages == %d, want %d", len(report.Packages), len(expected.Packages))
-			}
-
-			for i, pkg := range report.Packages {
-				expPkg := expected.Packages[i]
-
-				t.Run(expPkg.Name, func(t *testing.T) {
-					if pkg.Name != expPkg.Name {
-						t.Errorf("Package.Name == %s, want %s", pkg.Name, expPkg.Name)
-					}
-
-					if pkg.Duration != expPkg.Duration {
-						t.Errorf("Package.Duration == %s, want %s", pkg.Duration, expPkg.Duration)
-					}
-
-					// pkg.Time is deprecated
-					if pkg.Time != expPkg.Time {
-						t.Errorf("Package.Time == %d, want %d", pkg.Time, expPkg.Time)
-					}
-
-					if len(pkg.Tests) != len(expPkg.Tests) {
-						t.Fatalf("Package Tests == %d, want %d", len(pkg.Tests), len(expPkg.Tests))
-					}
-
-					for j, test := range pkg.Tests {
-						expTest := expPkg.Tests[j]
-
-						t.Run(expTest.Name, func(t *testing.T) {
-							if test.Name != expTest.Name {
-								t.Errorf("Test.Name == %s, want %s", test.Name, expTest.Name)
-							}
-
-							if test.Duration != expTest.Duration {
-								t.Errorf("Test.Duration == %s, want %s", test.Duration, expTest.Duration)
-							}
-
-							// test.Time is deprecated
-							if test.Time != expTest.Time {
-								t.Errorf("Test.Time == %d, want %d", test.Time, expTest.Time)
-							}
-
-							if test.Result != expTest.Result {
-								t.Errorf("Test.Result == %v, want %v", test.Result, expTest.Result)
-							}
-
-							testOutput := strings.Join(test.Output, "\n")
-							expTestOutput := strings.Join(expTest.Output, "\n")
-							if testOutput != expTestOutput {
-								t.Errorf("Test.Output\nEXP: %q\nGOT: %q", expTestOutput, testOutput)
-							}
-						})
-					}
-
-					if pkg.CoveragePct != expPkg.CoveragePct {
-						t.Errorf("Package.CoveragePct == %s, want %s", pkg.CoveragePct, expPkg.CoveragePct)
+	{
+		name:       "34-localized-numbers.txt",
+		reportName: "34-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:     "package/name",
+					Duration: 1234567 * time.Millisecond,
+					Time:     1234567,
+					Tests: []*parser.Test{
+						{
+							Name:     "TestOne",
+							Duration: 1234500 * time.Millisecond,
+							Time:     1234500,
+							Result:   parser.PASS,
+							Output:   []string{},
+						},
+						{
+							Name:     "TestTwo",
+							Duration: 130 * time.Millisecond,
+							Time:     130,
+							Result:   parser.FAIL,
+							Output: []string{
+								"two_test.go:10: failed",
+							},
+						},
+					},
+					CoveragePct: "12.5",
+					Coverage:    12.5,
+				},
+				{
+					Name:     "package/bench",
+					Duration: 2500 * time.Millisecond,
+					Time:     2500,
+					Tests: []*parser.Test{
+						{
+							Name:     "BenchmarkThree",
+							Duration: 1234 * time.Nanosecond,
+							Result:   parser.PASS,
+							CPU:      8,
+							Output: []string{
+								"BenchmarkThree-8   \t 1\u00a0000\u00a0000\t  1,234 ns/op",
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "35-json-mixed.txt",
+		reportName: "35-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:     "example.com/jt",
+					Duration: 3 * time.Millisecond,
+					Time:     3,
+					Output: []string{
+						"Running unit tests for example.com/jt",
+					},
+					Tests: []*parser.Test{
+						{
+							Name:     "TestPass",
+							Duration: 0,
+							Time:     0,
+							Start:    time.Date(2026, 10, 17, 7, 25, 47, 697360897, time.UTC),
+							Result:   parser.PASS,
+							Output: []string{
+								"jt_test.go:6: hello",
+							},
+						},
+						{
+							Name:     "TestFail",
+							Duration: 0,
+							Time:     0,
+							Start:    time.Date(2026, 10, 17, 7, 25, 47, 697620672, time.UTC),
+							Result:   parser.FAIL,
+							Output: []string{
+								"jt_test.go:10: broken",
+							},
+						},
+						{
+							Name:     "TestSub",
+							Duration: 0,
+							Time:     0,
+							Start:    time.Date(2026, 10, 17, 7, 25, 47, 697711617, time.UTC),
+							Result:   parser.PASS,
+							Output:   []string{},
+						},
+						{
+							Name:     "TestSub/one",
+							Duration: 0,
+							Time:     0,
+							Start:    time.Date(2026, 10, 17, 7, 25, 47, 697748889, time.UTC),
+							Result:   parser.PASS,
+							Output:   []string{},
+						},
+						{
+							Name:     "TestSub/two",
+							Duration: 0,
+							Time:     0,
+							Start:    time.Date(2026, 10, 17, 7, 25, 47, 698120959, time.UTC),
+							Result:   parser.SKIP,
+							Output: []string{
+								"jt_test.go:15: not now",
+							},
+						},
+					},
+				},
+				{
+					Name:     "example.com/text",
+					Duration: 12 * time.Millisecond,
+					Time:     12,
+					Tests: []*parser.Test{
+						{
+							Name:     "TestText",
+							Duration: 10 * time.Millisecond,
+							Time:     10,
+							Result:   parser.PASS,
+							Output:   []string{},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "36-ansi-colors.txt",
+		reportName: "36-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:     "package/colored",
+					Duration: 35 * time.Millisecond,
+					Time:     35,
+					Tests: []*parser.Test{
+						{
+							Name:     "TestOne",
+							Duration: 10 * time.Millisecond,
+							Time:     10,
+							Result:   parser.PASS,
+							Output:   []string{},
+						},
+						{
+							Name:     "TestTwo",
+							Duration: 20 * time.Millisecond,
+							Time:     20,
+							Result:   parser.FAIL,
+							Output:   []string{},
+						},
+						{
+							Name:     "TestTwo/sub",
+							Duration: 10 * time.Millisecond,
+							Time:     10,
+							Result:   parser.FAIL,
+							Output: []string{
+								"two_test.go:12: not equal",
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "37-skip-reason.txt",
+		reportName: "37-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:     "package/skip",
+					Duration: 15 * time.Millisecond,
+					Time:     15,
+					Tests: []*parser.Test{
+						{
+							Name:     "TestDatabase",
+							Duration: 10 * time.Millisecond,
+							Time:     10,
+							Result:   parser.SKIP,
+							Output: []string{
+								"db_test.go:12: connecting to localhost:5432",
+								"db_test.go:14: no database available:",
+								"    connection refused",
+							},
+						},
+						{
+							Name:     "TestQuiet",
+							Duration: 0,
+							Time:     0,
+							Result:   parser.SKIP,
+							Output:   []string{},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "38-shuffle.txt",
+		reportName: "38-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:       "package/shuffle",
+					Duration:   35 * time.Millisecond,
+					Time:       35,
+					Properties: []parser.Property{{Name: "go.test.shuffle", Value: "1699999999"}},
+					Tests: []*parser.Test{
+						{
+							Name:     "TestB",
+							Duration: 10 * time.Millisecond,
+							Time:     10,
+							Result:   parser.PASS,
+							Output:   []string{},
+						},
+						{
+							Name:     "TestA",
+							Duration: 20 * time.Millisecond,
+							Time:     20,
+							Result:   parser.FAIL,
+							Output:   []string{"a_test.go:9: depends on TestB"},
+						},
+					},
+				},
+				{
+					Name:       "package/other",
+					Duration:   5 * time.Millisecond,
+					Time:       5,
+					Properties: []parser.Property{{Name: "go.test.shuffle", Value: "1700000000"}},
+					Tests: []*parser.Test{
+						{
+							Name:     "TestC",
+							Duration: 0,
+							Time:     0,
+							Result:   parser.PASS,
+							Output:   []string{},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "39-vet.txt",
+		reportName: "39-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:     "package/vet",
+					Duration: 0,
+					Time:     0,
+					Tests:    []*parser.Test{},
+					BuildError: &parser.BuildError{
+						Name: "[build failed]",
+						Output: []string{
+							`./a_test.go:9:14: fmt.Printf format %d has arg "x" of wrong type string`,
+							"./a_test.go:12:2: unreachable code",
+						},
+						Type: "vet",
+					},
+				},
+				{
+					Name:     "package/vet/ok",
+					Duration: 3 * time.Millisecond,
+					Time:     3,
+					Tests: []*parser.Test{
+						{
+							Name:     "TestOK",
+							Duration: 0,
+							Time:     0,
+							Result:   parser.PASS,
+							Output:   []string{},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "40-testmain.txt",
+		reportName: "40-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:     "package/testmain",
+					Duration: 15 * time.Millisecond,
+					Time:     15,
+					Output: []string{
+						"init output",
+						"setting up database",
+						"leaked goroutine output",
+						"stopping database",
+						"teardown done",
+					},
+					Tests: []*parser.Test{
+						{
+							Name:     "TestA",
+							Duration: 0,
+							Time:     0,
+							Result:   parser.PASS,
+							Output:   []string{"a_test.go:5: hello"},
+						},
+						{
+							Name:     "TestB",
+							Duration: 10 * time.Millisecond,
+							Time:     10,
+							Result:   parser.FAIL,
+							Output:   []string{"b_test.go:7: failed"},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "41-test-panic.txt",
+		reportName: "41-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:     "package/panic",
+					Duration: 5 * time.Millisecond,
+					Time:     5,
+					Tests: []*parser.Test{
+						{
+							Name:     "TestOK",
+							Duration: 0,
+							Time:     0,
+							Result:   parser.PASS,
+							Output:   []string{},
+						},
+						{
+							Name:     "TestPanic",
+							Duration: 0,
+							Time:     0,
+							Result:   parser.FAIL,
+							Output: []string{
+								"pn_test.go:12: before",
+								"panic: assignment to entry in nil map [recovered]",
+								"\tpanic: assignment to entry in nil map",
+								"",
+								"goroutine 7 [running]:",
+								"package/panic.TestPanic(0xc000007a00)",
+								"\t/src/pn_test.go:14 +0x69",
+								"created by testing.(*T).Run in goroutine 1",
+								"\t/usr/local/go/src/testing/testing.go:2258 +0x4d4",
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "42-panic-attribution.txt",
+		reportName: "42-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:     "package/panic",
+					Duration: 15 * time.Millisecond,
+					Time:     15,
+					Tests: []*parser.Test{
+						{
+							Name:     "TestPanic",
+							Duration: 0,
+							Time:     0,
+							Result:   parser.FAIL,
+							Output: []string{
+								"panic: boom [recovered]",
+								"\tpanic: boom",
+								"",
+								"goroutine 7 [running]:",
+								"testing.tRunner.func1.2({0x6b6f60, 0x6ef0e0})",
+								"\t/usr/local/go/src/testing/testing.go:2123 +0x232",
+								"package/panic.TestPanic(0xc000007a00)",
+								"\t/src/pn_test.go:14 +0x69",
+							},
+						},
+					},
+				},
+				{
+					Name:     "package/panic2",
+					Duration: 4 * time.Millisecond,
+					Time:     4,
+					Tests: []*parser.Test{
+						{
+							Name:     "TestWorker",
+							Duration: 0,
+							Time:     0,
+							Result:   parser.FAIL,
+							Output: []string{
+								"panic: background",
+								"",
+								"goroutine 9 [running]:",
+								"package/panic2.TestWorker.func1()",
+								"\t/src/w_test.go:8 +0x25",
+								"created by package/panic2.TestWorker in goroutine 7",
+								"\t/src/w_test.go:7 +0x1a",
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "43-timeout.txt",
+		reportName: "43-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:     "package/timeout",
+					Duration: 2004 * time.Millisecond,
+					Time:     2004,
+					Tests: []*parser.Test{
+						{
+							Name:     "TestOK",
+							Duration: 0,
+							Time:     0,
+							Result:   parser.PASS,
+							Output:   []string{},
+						},
+						{
+							Name:      "TestHang",
+							Duration:  2 * time.Second,
+							Time:      2000,
+							Result:    parser.ERROR,
+							ErrorType: "timeout",
+							Output:    []string{"panic: test timed out after 2s"},
+						},
+						{
+							Name:      "TestHang/sub",
+							Duration:  2 * time.Second,
+							Time:      2000,
+							Result:    parser.ERROR,
+							ErrorType: "timeout",
+							Output: []string{
+								"hang_test.go:10: waiting",
+								"panic: test timed out after 2s",
+								"\trunning tests:",
+								"\t\tTestHang (2s)",
+								"\t\tTestHang/sub (2s)",
+								"",
+								"goroutine 9 [running]:",
+								"testing.(*M).startAlarm.func1()",
+								"\t/usr/local/go/src/testing/testing.go:2959 +0x34a",
+							},
+						},
+					},
+				},
+				{
+					Name:     "package/timeout2",
+					Duration: 60003 * time.Millisecond,
+					Time:     60003,
+					Tests: []*parser.Test{
+						{
+							Name:      "TestSlow",
+							Duration:  time.Minute,
+							Time:      60000,
+							Result:    parser.ERROR,
+							ErrorType: "timeout",
+							Output: []string{
+								"panic: test timed out after 1m0s",
+								"\trunning tests:",
+								"\t\tTestSlow (1m0s)",
+								"",
+								"goroutine 5 [running]:",
+								"testing.(*M).startAlarm.func1()",
+								"\t/usr/local/go/src/testing/testing.go:2959 +0x34a",
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "44-gocheck.txt",
+		reportName: "44-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:     "package/gocheck",
+					Duration: 25 * time.Millisecond,
+					Time:     25,
+					Tests: []*parser.Test{
+						{
+							Name:     "Test",
+							Duration: 20 * time.Millisecond,
+							Time:     20,
+							Result:   parser.FAIL,
+							Output:   []string{"OOPS: 1 passed, 1 skipped, 1 FAILED, 1 PANICKED"},
+						},
+						{
+							Name:     "MySuite.TestBar",
+							Duration: 12 * time.Millisecond,
+							Time:     12,
+							Result:   parser.PASS,
+							Output:   []string{},
+						},
+						{
+							Name:   "MySuite.TestBaz",
+							Result: parser.FAIL,
+							Output: []string{
+								"baz log",
+								"suite_test.go:18:",
+								"    c.Assert(1, Equals, 2)",
+								"... obtained int = 1",
+								"... expected int = 2",
+							},
+						},
+						{
+							Name:   "MySuite.TestPanic",
+							Result: parser.FAIL,
+							Output: []string{
+								"... Panic: assignment to entry in nil map (PC=0x48E8E4)",
+								"",
+								"suite_test.go:21",
+								"  in MySuite.TestPanic",
+							},
+						},
+						{
+							Name:   "MySuite.TestSkip",
+							Result: parser.SKIP,
+							Output: []string{"not now"},
+						},
+						{
+							Name:     "TestPlain",
+							Duration: 0,
+							Time:     0,
+							Result:   parser.PASS,
+							Output:   []string{},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "45-goconvey.txt",
+		reportName: "45-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:     "package/goconvey",
+					Duration: 15 * time.Millisecond,
+					Time:     15,
+					Tests: []*parser.Test{
+						{
+							Name:     "TestSpec",
+							Duration: 10 * time.Millisecond,
+							Time:     10,
+							Result:   parser.FAIL,
+							Output: []string{
+								"",
+								"  Given some integer with a starting value ",
+								"    When the integer is incremented ",
+								"      The value should be greater by one ✔✔",
+								"    When the integer is decremented ✘",
+								"    Skipped one ⚠",
+								"    Panics 🔥",
+								"",
+								"",
+								"Errors:",
+								"",
+								"Failures:",
+								"",
+								"4 total assertions (one or more sections skipped)",
+								"",
+							},
+						},
+						{
+							Name:   "TestSpec/Given some integer with a starting value/When the integer is incremented/The value should be greater by one",
+							Result: parser.PASS,
+							Output: []string{},
+						},
+						{
+							Name:   "TestSpec/Given some integer with a starting value/When the integer is decremented",
+							Result: parser.FAIL,
+							Output: []string{
+								"* /src/goconvey/spec_test.go ",
+								"Line 21:",
+								"Expected: '1'",
+								"Actual:   '0'",
+								"(Should be equal)",
+								"goroutine 6 [running]:",
+								"package/goconvey.TestSpec.func1.2()",
+								"\t/src/goconvey/spec_test.go:21 +0x7c",
+							},
+						},
+						{
+							Name:   "TestSpec/Given some integer with a starting value/Skipped one",
+							Result: parser.SKIP,
+							Output: []string{},
+						},
+						{
+							Name:   "TestSpec/Given some integer with a starting value/Panics",
+							Result: parser.FAIL,
+							Output: []string{
+								"* /src/goconvey/spec_test.go ",
+								"Line 26: - assignment to entry in nil map ",
+								"goroutine 6 [running]:",
+								"package/goconvey.TestSpec.func1.4()",
+								"\t/src/goconvey/spec_test.go:26 +0x28",
+							},
+						},
+						{
+							Name:     "TestOther",
+							Duration: 0,
+							Time:     0,
+							Result:   parser.PASS,
+							Output: []string{
+								"",
+								"  All good ✔",
+								"",
+								"",
+								"5 total assertions (one or more sections skipped)",
+								"",
+							},
+						},
+						{
+							Name:   "TestOther/All good",
+							Result: parser.PASS,
+							Output: []string{},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "46-bench-cpu.txt",
+		reportName: "46-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:     "package/cpu",
+					Duration: 4120 * time.Millisecond,
+					Time:     4120,
+					Output: []string{
+						"goos: linux",
+						"goarch: amd64",
+						"pkg: package/cpu",
+					},
+					Tests: []*parser.Test{
+						{
+							Name:     "BenchmarkHash",
+							Duration: 1020 * time.Nanosecond,
+							Result:   parser.PASS,
+							CPU:      1,
+							Output: []string{
+								"BenchmarkHash       \t 1000000\t      1020 ns/op",
+							},
+						},
+						{
+							Name:     "BenchmarkHash-4",
+							Duration: 1011 * time.Nanosecond,
+							Result:   parser.PASS,
+							CPU:      4,
+							Output: []string{
+								"BenchmarkHash-4     \t 1000000\t      1011 ns/op",
+							},
+						},
+						{
+							Name:     "BenchmarkSort",
+							Duration: 24310 * time.Nanosecond,
+							Result:   parser.PASS,
+							CPU:      1,
+							Output: []string{
+								"BenchmarkSort       \t   50000\t     24310 ns/op",
+							},
+						},
+						{
+							Name:     "BenchmarkSort-4",
+							Duration: 12873 * time.Nanosecond,
+							Result:   parser.PASS,
+							CPU:      4,
+							Output: []string{
+								"BenchmarkSort-4     \t  100000\t     12873 ns/op",
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "47-skip-dashes.txt",
+		reportName: "47-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:     "package/skipdashes",
+					Duration: 5 * time.Millisecond,
+					Time:     5,
+					Tests: []*parser.Test{
+						{
+							Name:     "TestFlags",
+							Duration: 0,
+							Time:     0,
+							Result:   parser.SKIP,
+							Output: []string{
+								"flags_test.go:8: running with --count=1 -- -v",
+								"flags_test.go:10: use --integration to enable",
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		name:       "48-panic-attribution-v.txt",
+		reportName: "48-report.xml",
+		report: &parser.Report{
+			Packages: []parser.Package{
+				{
+					Name:     "package/panic",
+					Duration: 15 * time.Millisecond,
+					Time:     15,
+					Tests: []*parser.Test{
+						{
+							Name:     "TestPanic",
+							Duration: 10 * time.Millisecond,
+							Time:     10,
+							Result:   parser.FAIL,
+							Output: []string{
+								"panic: boom [recovered]",
+								"\tpanic: boom",
+								"",
+								"goroutine 7 [running]:",
+								"testing.tRunner.func1.2({0x6b6f60, 0x6ef0e0})",
+								"\t/usr/local/go/src/testing/testing.go:2123 +0x232",
+								"package/panic.TestPanic(0xc000007a00)",
+								"\t/src/pn_test.go:14 +0x69",
+							},
+						},
+					},
+				},
+				{
+					Name:     "package/panic2",
+					Duration: 4 * time.Millisecond,
+					Time:     4,
+					Tests: []*parser.Test{
+						{
+							Name:     "TestWorker",
+							Duration: 0,
+							Time:     0,
+							Result:   parser.FAIL,
+							Output: []string{
+								"panic: background",
+								"",
+								"goroutine 9 [running]:",
+								"package/panic2.TestWorker.func1()",
+								"\t/src/w_test.go:8 +0x25",
+								"created by package/panic2.TestWorker in goroutine 7",
+								"\t/src/w_test.go:7 +0x1a",
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+func TestParser(t *testing.T) {
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			file, err := os.Open("testdata/" + testCase.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := parser.Parse(file, testCase.packageName)
+			if err != nil {
+				t.Fatalf("error parsing: %s", err)
+			}
+
+			if report == nil {
+				t.Fatalf("Report == nil")
+			}
+
+			expected := testCase.report
+			if len(report.Packages) != len(expected.Packages) {
+				t.Fatalf("Report packages == %d, want %d", len(report.Packages), len(expected.Packages))
+			}
+
+			for i, pkg := range report.Packages {
+				expPkg := expected.Packages[i]
+
+				t.Run(expPkg.Name, func(t *testing.T) {
+					if pkg.Name != expPkg.Name {
+						t.Errorf("Package.Name == %s, want %s", pkg.Name, expPkg.Name)
+					}
+
+					if pkg.Duration != expPkg.Duration {
+						t.Errorf("Package.Duration == %s, want %s", pkg.Duration, expPkg.Duration)
+					}
+
+					// pkg.Time is deprecated
+					if pkg.Time != expPkg.Time {
+						t.Errorf("Package.Time == %d, want %d", pkg.Time, expPkg.Time)
+					}
+
+					if len(pkg.Tests) != len(expPkg.Tests) {
+						t.Fatalf("Package Tests == %d, want %d", len(pkg.Tests), len(expPkg.Tests))
+					}
+
+					for j, test := range pkg.Tests {
+						expTest := expPkg.Tests[j]
+
+						t.Run(expTest.Name, func(t *testing.T) {
+							if test.Name != expTest.Name {
+								t.Errorf("Test.Name == %s, want %s", test.Name, expTest.Name)
+							}
+
+							if test.Duration != expTest.Duration {
+								t.Errorf("Test.Duration == %s, want %s", test.Duration, expTest.Duration)
+							}
+
+							// test.Time is deprecated
+							if test.Time != expTest.Time {
+								t.Errorf("Test.Time == %d, want %d", test.Time, expTest.Time)
+							}
+
+							if test.Result != expTest.Result {
+								t.Errorf("Test.Result == %v, want %v", test.Result, expTest.Result)
+							}
+
+							if test.ErrorType != expTest.ErrorType {
+								t.Errorf("Test.ErrorType == %q, want %q", test.ErrorType, expTest.ErrorType)
+							}
+
+							if !test.Start.Equal(expTest.Start) {
+								t.Errorf("Test.Start == %s, want %s", test.Start, expTest.Start)
+							}
+
+							if test.CPU != expTest.CPU {
+								t.Errorf("Test.CPU == %d, want %d", test.CPU, expTest.CPU)
+							}
+
+							testOutput := strings.Join(test.Output, "\n")
+							expTestOutput := strings.Join(expTest.Output, "\n")
+							if testOutput != expTestOutput {
+								t.Errorf("Test.Output\nEXP: %q\nGOT: %q", expTestOutput, testOutput)
+							}
+						})
+					}
+
+					pkgOutput := strings.Join(pkg.Output, "\n")
+					expPkgOutput := strings.Join(expPkg.Output, "\n")
+					if pkgOutput != expPkgOutput {
+						t.Errorf("Package.Output\nEXP: %q\nGOT: %q", expPkgOutput, pkgOutput)
+					}
+
+					if pkg.CoveragePct != expPkg.CoveragePct {
+						t.Errorf("Package.CoveragePct == %s, want %s", pkg.CoveragePct, expPkg.CoveragePct)
+					}
+
+					if pkg.Coverage != expPkg.Coverage {
+						t.Errorf("Package.Coverage == %v, want %v", pkg.Coverage, expPkg.Coverage)
+					}
+
+					if !reflect.DeepEqual(pkg.BuildError, expPkg.BuildError) {
+						t.Errorf("Package.BuildError == %+v, want %+v", pkg.BuildError, expPkg.BuildError)
+					}
+
+					if !reflect.DeepEqual(pkg.Properties, expPkg.Properties) {
+						t.Errorf("Package.Properties == %v, want %v", pkg.Properties, expPkg.Properties)
 					}
 				})
 			}
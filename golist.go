@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// goModule is the module information reported by `go list -json`.
+type goModule struct {
+	Path string
+	Dir  string
+}
+
+// goPackage contains the subset of `go list -json` output used by
+// go-junit-report.
+type goPackage struct {
+	Dir          string
+	ImportPath   string
+	Standard     bool
+	Module       *goModule
+	Deps         []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+	TestImports  []string
+	XTestImports []string
+
+	IgnoredGoFiles []string
+}
+
+// goList runs `go list -e -json` in dir with the given package patterns and
+// returns the listed packages keyed by import path.
+func goList(dir string, patterns ...string) (map[string]*goPackage, error) {
+	args := append([]string{"list", "-e", "-json"}, patterns...)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %s: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	pkgs := make(map[string]*goPackage)
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		pkg := &goPackage{}
+		if err := dec.Decode(pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("go list: %s", err)
+		}
+		pkgs[pkg.ImportPath] = pkg
+	}
+	return pkgs, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+)
+
+// gzipMagic are the first bytes of gzip compressed data.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// maybeGunzip returns a reader that transparently decompresses r if it
+// contains gzip compressed data, otherwise r is read as is.
+func maybeGunzip(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.Equal(magic, gzipMagic) {
+		return br, nil
+	}
+	return gzip.NewReader(br)
+}
+
+// compressedName returns filename with a .gz extension.
+func compressedName(filename string) string {
+	if strings.HasSuffix(filename, ".gz") {
+		return filename
+	}
+	return filename + ".gz"
+}
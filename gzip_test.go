@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestMaybeGunzip(t *testing.T) {
+	const input = "=== RUN   TestOne\n--- PASS: TestOne (0.00s)\n"
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(input))
+	zw.Close()
+
+	tests := map[string][]byte{
+		"plain":      []byte(input),
+		"compressed": compressed.Bytes(),
+		"empty":      {},
+		"one byte":   {0x1f},
+	}
+	for name, in := range tests {
+		r, err := maybeGunzip(bytes.NewReader(in))
+		if err != nil {
+			t.Errorf("%s: maybeGunzip() returned error: %s", name, err)
+			continue
+		}
+		out, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Errorf("%s: reading returned error: %s", name, err)
+			continue
+		}
+		want := string(in)
+		if name == "compressed" {
+			want = input
+		}
+		if string(out) != want {
+			t.Errorf("%s: read %q, want %q", name, out, want)
+		}
+	}
+
+	if _, err := maybeGunzip(strings.NewReader("\x1f\x8bnot really gzip")); err == nil {
+		t.Errorf("maybeGunzip() with corrupt gzip header did not return an error")
+	}
+}
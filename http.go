@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// postJSON posts the JSON body to url with the additional headers.
+func postJSON(url string, headers map[string]string, body io.Reader) error {
+	return sendRequest("POST", url, "application/json", headers, body)
+}
+
+// sendRequest sends body of the given content type to url with the
+// additional headers and returns an error including the start of the response
+// body if the request wasn't successful.
+func sendRequest(method, url, contentType string, headers map[string]string, body io.Reader) error {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(&io.LimitedReader{R: resp.Body, N: 1024})
+		return &statusError{resp.StatusCode, fmt.Sprintf("%s: %s", resp.Status, bytes.TrimSpace(msg))}
+	}
+	return nil
+}
+
+// statusError is returned by sendRequest for unsuccessful responses.
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e *statusError) Error() string {
+	return e.msg
+}
+
+// retryDelay is the time sendWithRetries waits before the first retry, it's
+// doubled for every further retry.
+var retryDelay = time.Second
+
+// sendWithRetries sends body like sendRequest and retries up to retries times
+// after network errors and server errors or rate limiting responses.
+func sendWithRetries(method, url, contentType string, headers map[string]string, body []byte, retries int) error {
+	delay := retryDelay
+	for attempt := 0; ; attempt++ {
+		err := sendRequest(method, url, contentType, headers, bytes.NewReader(body))
+		if err == nil || attempt >= retries || !retryable(err) {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// retryable returns whether a request that failed with err may succeed when
+// it's sent again.
+func retryable(err error) bool {
+	if e, ok := err.(*statusError); ok {
+		return e.code >= 500 || e.code == http.StatusTooManyRequests
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestIgnoreFailures(t *testing.T) {
+	in := `=== RUN   TestFetch
+    fetch_test.go:12: dial tcp 10.0.0.1:443: connect: connection refused
+--- FAIL: TestFetch (0.01s)
+=== RUN   TestParse
+    parse_test.go:20: got 1, want 2
+--- FAIL: TestParse (0.00s)
+=== RUN   TestSuite
+=== RUN   TestSuite/mirror
+    suite_test.go:8: lookup mirror.internal: no such host
+    --- FAIL: TestSuite/mirror (0.00s)
+--- FAIL: TestSuite (0.00s)
+=== RUN   TestVerify
+    verify_test.go:5: checksum mismatch
+=== RUN   TestVerify/mirror
+    verify_test.go:9: lookup mirror.internal: no such host
+    --- FAIL: TestVerify/mirror (0.00s)
+--- FAIL: TestVerify (0.00s)
+FAIL
+FAIL	pkg	0.01s
+`
+	report, err := parser.Parse(strings.NewReader(in), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var patterns regexpFlag
+	patterns.Set("connection refused|no such host")
+	if n := ignoreFailures(report, patterns); n != 4 {
+		t.Errorf("ignoreFailures() == %d, want 4", n)
+	}
+
+	for _, test := range report.Packages[0].Tests {
+		// TestVerify failed by itself besides its ignored subtest
+		ignored := test.Name != "TestParse" && test.Name != "TestVerify"
+		if test.FailureIgnored != ignored || (test.Result == parser.PASS) != ignored {
+			t.Errorf("%s: result %s, FailureIgnored == %t, want ignored %t", test.Name, test.Result, test.FailureIgnored, ignored)
+		}
+	}
+}
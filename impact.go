@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// impactMap maps tests to the source packages they exercise.
+type impactMap struct {
+	Tests []impactTest `json:"tests"`
+}
+
+// impactTest describes the packages covered by a single test.
+type impactTest struct {
+	Package string   `json:"package"`
+	Name    string   `json:"name"`
+	File    string   `json:"file,omitempty"`
+	Covers  []string `json:"covers"`
+}
+
+// writeImpactMap resolves the source file of every test in report and, using
+// the dependency information reported by `go list`, writes a JSON mapping of
+// tests to the non-standard packages they depend on to filename.
+func writeImpactMap(report *parser.Report, filename string) error {
+	var names []string
+	for _, pkg := range report.Packages {
+		if pkg.Name != "" {
+			names = append(names, pkg.Name)
+		}
+	}
+
+	listed := map[string]*goPackage{}
+	if len(names) > 0 {
+		var err error
+		if listed, err = goList("", names...); err != nil {
+			return err
+		}
+	}
+
+	m := impactMap{Tests: []impactTest{}}
+	for _, pkg := range report.Packages {
+		gopkg := listed[pkg.Name]
+		covers := coveredPackages(gopkg)
+		files := testFuncFiles(gopkg)
+
+		for _, test := range pkg.Tests {
+			if test.Result == parser.ERROR {
+				// build failures and other synthesized tests
+				continue
+			}
+			m.Tests = append(m.Tests, impactTest{
+				Package: pkg.Name,
+				Name:    test.Name,
+				File:    files[topLevelName(test.Name)],
+				Covers:  covers,
+			})
+		}
+	}
+
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// coveredPackages returns the package itself and all its (test) dependencies
+// that belong to the same module, sorted by import path.
+func coveredPackages(pkg *goPackage) []string {
+	if pkg == nil {
+		return []string{}
+	}
+
+	seen := map[string]bool{pkg.ImportPath: true}
+	covers := []string{pkg.ImportPath}
+	var deps []string
+	deps = append(deps, pkg.Deps...)
+	deps = append(deps, pkg.TestImports...)
+	deps = append(deps, pkg.XTestImports...)
+	for _, dep := range deps {
+		if seen[dep] || !inModule(pkg, dep) {
+			continue
+		}
+		seen[dep] = true
+		covers = append(covers, dep)
+	}
+	sort.Strings(covers)
+	return covers
+}
+
+// inModule reports whether importPath is part of the module containing pkg.
+// Without module information every non-standard looking path is accepted.
+func inModule(pkg *goPackage, importPath string) bool {
+	if pkg.Module == nil || pkg.Module.Path == "" {
+		first := strings.SplitN(importPath, "/", 2)[0]
+		return strings.Contains(first, ".")
+	}
+	return importPath == pkg.Module.Path || strings.HasPrefix(importPath, pkg.Module.Path+"/")
+}
+
+// testFuncFiles returns a map of top-level function names to the test file
+// declaring them. File names are relative to the module root if known.
+func testFuncFiles(pkg *goPackage) map[string]string {
+	files := map[string]string{}
+	if pkg == nil {
+		return files
+	}
+
+	var names []string
+	names = append(names, pkg.TestGoFiles...)
+	names = append(names, pkg.XTestGoFiles...)
+	for _, name := range names {
+		path := filepath.Join(pkg.Dir, name)
+		funcs, err := topLevelFuncs(path)
+		if err != nil {
+			continue
+		}
+
+		rel := path
+		if pkg.Module != nil && pkg.Module.Dir != "" {
+			if r, err := filepath.Rel(pkg.Module.Dir, path); err == nil {
+				rel = filepath.ToSlash(r)
+			}
+		}
+		for _, fn := range funcs {
+			files[fn] = rel
+		}
+	}
+	return files
+}
+
+// topLevelFuncs returns the names of all functions without receiver declared
+// in the Go source file at path.
+func topLevelFuncs(path string) ([]string, error) {
+	f, err := goparser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var funcs []string
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+			funcs = append(funcs, fn.Name.Name)
+		}
+	}
+	return funcs, nil
+}
+
+// topLevelName returns the name of the top-level test of a (sub)test name.
+func topLevelName(name string) string {
+	if idx := strings.Index(name, "/"); idx > -1 {
+		return name[:idx]
+	}
+	return name
+}
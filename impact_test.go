@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTestFuncFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "impact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := "package foo\n\nimport \"testing\"\n\nfunc TestFoo(t *testing.T) {}\n\nfunc BenchmarkFoo(b *testing.B) {}\n\ntype s struct{}\n\nfunc (s) TestMethod() {}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "foo_test.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg := &goPackage{
+		Dir:         dir,
+		ImportPath:  "example.com/foo",
+		Module:      &goModule{Path: "example.com", Dir: filepath.Dir(dir)},
+		TestGoFiles: []string{"foo_test.go"},
+	}
+	want := filepath.Base(dir) + "/foo_test.go"
+	files := testFuncFiles(pkg)
+	if files["TestFoo"] != want || files["BenchmarkFoo"] != want {
+		t.Errorf("testFuncFiles() = %v, want TestFoo and BenchmarkFoo in %s", files, want)
+	}
+	if _, ok := files["TestMethod"]; ok {
+		t.Errorf("testFuncFiles() contains method TestMethod")
+	}
+}
+
+func TestCoveredPackages(t *testing.T) {
+	pkg := &goPackage{
+		ImportPath:  "example.com/mod/foo",
+		Module:      &goModule{Path: "example.com/mod"},
+		Deps:        []string{"fmt", "example.com/mod/bar", "example.com/other"},
+		TestImports: []string{"testing", "example.com/mod/testutil", "example.com/mod/bar"},
+	}
+	want := []string{"example.com/mod/bar", "example.com/mod/foo", "example.com/mod/testutil"}
+	if got := coveredPackages(pkg); !reflect.DeepEqual(got, want) {
+		t.Errorf("coveredPackages() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// interruptibleReader reads from an underlying reader until it's interrupted,
+// after which it returns io.EOF, so the parser finishes as if the input had
+// ended.
+type interruptibleReader struct {
+	pr *io.PipeReader
+	pw *io.PipeWriter
+
+	mu      sync.Mutex
+	reason  string
+	stalled bool
+
+	// timer interrupts reading if it isn't reset within timeout
+	timer   inactivityTimer
+	timeout time.Duration
+}
+
+// inactivityTimer is the part of *time.Timer used for the inactivity timeout.
+type inactivityTimer interface {
+	Reset(d time.Duration) bool
+	Stop() bool
+}
+
+// afterFunc starts the inactivity timer, it's replaced in tests.
+var afterFunc = func(d time.Duration, f func()) inactivityTimer {
+	return time.AfterFunc(d, f)
+}
+
+func newInterruptibleReader(r io.Reader) *interruptibleReader {
+	pr, pw := io.Pipe()
+	ir := &interruptibleReader{pr: pr, pw: pw}
+	go func() {
+		_, err := io.Copy(pw, activityReader{r, ir})
+		ir.mu.Lock()
+		if ir.timer != nil {
+			ir.timer.Stop()
+		}
+		ir.mu.Unlock()
+		pw.CloseWithError(err)
+	}()
+	return ir
+}
+
+func (r *interruptibleReader) Read(p []byte) (int, error) {
+	return r.pr.Read(p)
+}
+
+// interrupt stops reading, reason describes why. Only the first interruption
+// has any effect.
+func (r *interruptibleReader) interrupt(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interruptLocked(reason)
+}
+
+func (r *interruptibleReader) interruptLocked(reason string) bool {
+	if r.reason != "" {
+		return false
+	}
+	r.reason = reason
+	r.pw.Close()
+	return true
+}
+
+// stall interrupts reading because no input arrived within the timeout.
+func (r *interruptibleReader) stall() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.interruptLocked(fmt.Sprintf("input stalled, no input for %s", r.timeout)) {
+		return false
+	}
+	r.stalled = true
+	return true
+}
+
+// active resets the inactivity timer, if any.
+func (r *interruptibleReader) active() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.timer != nil {
+		r.timer.Reset(r.timeout)
+	}
+}
+
+// interrupted returns the reason reading was interrupted, or an empty string
+// if it wasn't.
+func (r *interruptibleReader) interrupted() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reason
+}
+
+// inputStalled reports whether reading was interrupted by the inactivity
+// timeout.
+func (r *interruptibleReader) inputStalled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stalled
+}
+
+// activityReader tells an interruptibleReader about data read from r.
+type activityReader struct {
+	r  io.Reader
+	ir *interruptibleReader
+}
+
+func (a activityReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	if n > 0 {
+		a.ir.active()
+	}
+	return n, err
+}
+
+// interruptOnSignal interrupts r when SIGINT or SIGTERM is received, and
+// forwards the signal to the test command, if any. After the first signal, or
+// once the returned stop function is called when the input is done, the
+// default behavior of terminating immediately is restored.
+func interruptOnSignal(r *interruptibleReader, cmd *command) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		var sig os.Signal
+		select {
+		case sig = <-sigs:
+		case <-done:
+			return
+		}
+		signal.Stop(sigs)
+		name := "SIGTERM"
+		if sig == os.Interrupt {
+			name = "SIGINT"
+		}
+		r.interrupt("interrupted by " + name)
+		if cmd != nil {
+			cmd.interrupt(sig)
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
+
+// interruptOnInactivity interrupts r when no input arrives for the duration
+// timeout, and terminates the test command, if any.
+func interruptOnInactivity(r *interruptibleReader, cmd *command, timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timeout = timeout
+	r.timer = afterFunc(timeout, func() {
+		if r.stall() && cmd != nil {
+			cmd.interrupt(syscall.SIGTERM)
+		}
+	})
+}
+
+// markIncomplete marks the tests that were still running as errors and adds
+// reason to their output.
+func markIncomplete(report *parser.Report, reason string) {
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.Tests {
+			if test.Incomplete {
+				test.Result = parser.ERROR
+				test.Output = append(test.Output, reason)
+			}
+		}
+	}
+}
+
+const (
+	// stalledTestName is the name of the testcase added to a report when the
+	// input stalled.
+	stalledTestName = "input stalled"
+	// stalledErrorType is the error type of that testcase.
+	stalledErrorType = "stalled"
+)
+
+// addStalledTest adds an error testcase of type stalled describing reason to
+// the last package of report, which is usually the one whose tests hung.
+func addStalledTest(report *parser.Report, pkgName, reason string) {
+	if len(report.Packages) == 0 {
+		report.Packages = append(report.Packages, parser.Package{Name: pkgName})
+	}
+	pkg := &report.Packages[len(report.Packages)-1]
+	pkg.Tests = append(pkg.Tests, &parser.Test{
+		Name:      stalledTestName,
+		Result:    parser.ERROR,
+		ErrorType: stalledErrorType,
+		Output:    []string{reason},
+	})
+}
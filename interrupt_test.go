@@ -0,0 +1,101 @@
+package main
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestInterruptibleReader(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	r := newInterruptibleReader(pr)
+
+	done := make(chan *parser.Report)
+	go func() {
+		report, err := parser.Parse(r, "pkg")
+		if err != nil {
+			t.Error(err)
+		}
+		done <- report
+	}()
+	io.WriteString(pw, "=== RUN   TestHang\n    hang_test.go:10: working\n")
+	// the first write has been passed on once the second one is read
+	io.WriteString(pw, "    hang_test.go:11: still working\n")
+
+	if reason := r.interrupted(); reason != "" {
+		t.Errorf("interrupted() == %q before interrupt", reason)
+	}
+	r.interrupt("interrupted by SIGTERM")
+	r.interrupt("interrupted by SIGINT")
+	report := <-done
+	if reason := r.interrupted(); reason != "interrupted by SIGTERM" {
+		t.Errorf("interrupted() == %q, want the first reason", reason)
+	}
+
+	markIncomplete(report, r.interrupted())
+	test := report.Packages[0].Tests[0]
+	if test.Result != parser.ERROR {
+		t.Errorf("result of incomplete test == %v, want ERROR", test.Result)
+	}
+	if n := len(test.Output); n < 2 || test.Output[0] != "hang_test.go:10: working" || test.Output[n-1] != "interrupted by SIGTERM" {
+		t.Errorf("output of incomplete test == %q, want the reason appended", test.Output)
+	}
+}
+
+// fakeTimer is an inactivityTimer that only fires when the test calls fire.
+type fakeTimer struct {
+	fire func()
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	return true
+}
+
+func (t *fakeTimer) Stop() bool {
+	return true
+}
+
+func TestInputTimeout(t *testing.T) {
+	timer := &fakeTimer{}
+	defer func(f func(time.Duration, func()) inactivityTimer) { afterFunc = f }(afterFunc)
+	afterFunc = func(d time.Duration, f func()) inactivityTimer {
+		timer.fire = f
+		return timer
+	}
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	r := newInterruptibleReader(pr)
+	interruptOnInactivity(r, nil, 50*time.Millisecond)
+
+	done := make(chan *parser.Report)
+	go func() {
+		report, err := parser.Parse(r, "pkg")
+		if err != nil {
+			t.Error(err)
+		}
+		done <- report
+	}()
+	io.WriteString(pw, "=== RUN   TestHang\n")
+	// the first write has been passed on once the second one is read, the
+	// input stays open until the timer fires
+	io.WriteString(pw, "    hang_test.go:10: working\n")
+	timer.fire()
+	report := <-done
+	if !r.inputStalled() {
+		t.Fatalf("inputStalled() == false after the timeout")
+	}
+	reason := r.interrupted()
+	if reason != "input stalled, no input for 50ms" {
+		t.Errorf("interrupted() == %q", reason)
+	}
+
+	addStalledTest(report, "", reason)
+	tests := report.Packages[0].Tests
+	if len(tests) != 2 || tests[1].Name != stalledTestName || tests[1].Result != parser.ERROR || tests[1].ErrorType != "stalled" {
+		t.Errorf("no stalled testcase added: %+v", tests)
+	}
+}
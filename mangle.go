@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/hexon/go-junit-report/formatter"
+)
+
+// replacementFlag is a repeatable flag of regex=>replacement rules.
+type replacementFlag []formatter.Replacement
+
+func (r *replacementFlag) String() string {
+	var rules []string
+	for _, rule := range *r {
+		rules = append(rules, rule.Pattern.String()+"=>"+rule.With)
+	}
+	return strings.Join(rules, ",")
+}
+
+func (r *replacementFlag) Set(value string) error {
+	idx := strings.Index(value, "=>")
+	if idx < 1 {
+		return errors.New("rule must be of the form regex=>replacement")
+	}
+	re, err := regexp.Compile(value[:idx])
+	if err != nil {
+		return err
+	}
+	*r = append(*r, formatter.Replacement{Pattern: re, With: value[idx+2:]})
+	return nil
+}
+
+// newMangler returns a Mangler for the given settings, or nil if none of them
+// change names. charset is a regexp character class of allowed characters,
+// without the surrounding brackets.
+func newMangler(replacements []formatter.Replacement, charset, placeholder string, maxLength int) (*formatter.Mangler, error) {
+	if len(replacements) == 0 && charset == "" && maxLength <= 0 {
+		return nil, nil
+	}
+
+	m := &formatter.Mangler{
+		Replacements: replacements,
+		Placeholder:  placeholder,
+		MaxLength:    maxLength,
+	}
+	if charset != "" {
+		re, err := regexp.Compile("[^" + charset + "]")
+		if err != nil {
+			return nil, err
+		}
+		m.Disallowed = re
+	}
+	return m, nil
+}
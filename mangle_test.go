@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestTransformReportMangles(t *testing.T) {
+	defer func(charset string) { *mangleCharset = charset }(*mangleCharset)
+	*mangleCharset = "A-Za-z0-9/."
+
+	report := &parser.Report{Packages: []parser.Package{{
+		Name:  "example.com/a",
+		Tests: []*parser.Test{{Name: "TestA/with spaces", Result: parser.PASS}},
+	}}}
+	if err := transformReport(report); err != nil {
+		t.Fatal(err)
+	}
+
+	// the mangled names are used by all formats, not only JUnit XML
+	var buf bytes.Buffer
+	if err := formatter.WriteJSON(report, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, `"TestA/with_spaces"`) || strings.Contains(out, "with spaces") {
+		t.Errorf("JSON report does not use the mangled test name:\n%s", out)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// outputFiles contains the names of all files written by go-junit-report.
+var outputFiles []string
+
+// createOutput creates the named output file and records it in outputFiles.
+func createOutput(filename string) (*os.File, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	outputFiles = append(outputFiles, filename)
+	return f, nil
+}
+
+// writeManifest writes a SHA-256 manifest of files to filename, in the format
+// used by sha256sum so it can be verified with `sha256sum -c`. File names are
+// relative to the directory of the manifest. If keyFile is not empty, an
+// HMAC-SHA256 signature of the manifest using the contents of keyFile as key
+// is written to filename + ".sig".
+func writeManifest(filename, keyFile string, files []string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no output files to include in the manifest, use -out")
+	}
+
+	var buf bytes.Buffer
+	for _, file := range files {
+		sum, err := sha256File(file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "%s  %s\n", sum, manifestPath(filename, file))
+	}
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	if keyFile == "" {
+		return nil
+	}
+	key, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, bytes.TrimSpace(key))
+	mac.Write(buf.Bytes())
+	sig := hex.EncodeToString(mac.Sum(nil)) + "\n"
+	return ioutil.WriteFile(filename+".sig", []byte(sig), 0644)
+}
+
+func sha256File(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// manifestPath returns the path of file relative to the directory of the
+// manifest, or file itself if that's not possible.
+func manifestPath(manifest, file string) string {
+	dir, err := filepath.Abs(filepath.Dir(manifest))
+	if err != nil {
+		return file
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return file
+	}
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil {
+		return file
+	}
+	return filepath.ToSlash(rel)
+}
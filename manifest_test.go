@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	report := filepath.Join(dir, "reports", "report.xml")
+	os.Mkdir(filepath.Dir(report), 0755)
+	if err := ioutil.WriteFile(report, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	key := filepath.Join(dir, "key")
+	if err := ioutil.WriteFile(key, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := filepath.Join(dir, "manifest.sha256")
+	if err := writeManifest(manifest, key, []string{report}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  reports/report.xml\n"
+	if string(got) != want {
+		t.Errorf("manifest == %q, want %q", got, want)
+	}
+
+	sig, err := ioutil.ReadFile(manifest + ".sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(got)
+	if want := hex.EncodeToString(mac.Sum(nil)) + "\n"; string(sig) != want {
+		t.Errorf("signature == %q, want %q", sig, want)
+	}
+
+	if err := writeManifest(manifest, "", nil); err == nil {
+		t.Errorf("writeManifest() without files did not return an error")
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// readReport reads a JUnit XML or JSON report, which may be gzip compressed,
+// from filename.
+func readReport(filename string) (*parser.Report, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := maybeGunzip(f)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	start, _ := br.Peek(512)
+	if bytes.HasPrefix(bytes.TrimSpace(start), []byte("{")) {
+		report := &parser.Report{}
+		if err := json.NewDecoder(br).Decode(report); err != nil {
+			return nil, err
+		}
+		return report, nil
+	}
+	return formatter.ParseJUnit(br)
+}
+
+// mergeReports reads the reports in files and merges them, see parser.Merge.
+func mergeReports(files []string) (*parser.Report, error) {
+	var reports []*parser.Report
+	for _, file := range files {
+		report, err := readReport(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+		reports = append(reports, report)
+	}
+	return parser.Merge(reports...), nil
+}
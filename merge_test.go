@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestMergeReports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "merge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	report := func(result parser.Result) *parser.Report {
+		return &parser.Report{Packages: []parser.Package{{Name: "pkg", Tests: []*parser.Test{{Name: "TestA", Result: result}}}}}
+	}
+
+	var xml, gz, js bytes.Buffer
+	platform := formatter.Options{GoOS: "plan9", GoArch: "mips", NumCPU: 3}
+	if err := formatter.WriteJUnitXML(report(parser.PASS), platform, &xml); err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(&gz)
+	if err := formatter.WriteJUnitXML(report(parser.SKIP), formatter.Options{}, zw); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+	if err := formatter.WriteJSON(report(parser.FAIL), &js); err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	for name, data := range map[string][]byte{"a.xml": xml.Bytes(), "b.xml.gz": gz.Bytes(), "c.json": js.Bytes()} {
+		file := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(file, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	merged, err := mergeReports(files)
+	if err != nil {
+		t.Fatalf("mergeReports: %s", err)
+	}
+	if len(merged.Packages) != 1 || len(merged.Packages[0].Tests) != 1 {
+		t.Fatalf("merged report == %+v", merged)
+	}
+	if result := merged.Packages[0].Tests[0].Result; result != parser.FAIL {
+		t.Errorf("merged result == %v, want FAIL", result)
+	}
+
+	var out bytes.Buffer
+	if err := formatter.WriteJUnitXML(merged, formatter.Options{GoOS: "linux", GoArch: "amd64", NumCPU: 8}, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`name="go.os" value="plan9"`, `name="go.arch" value="mips"`, `name="runtime.numcpu" value="3"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("merged report has no property %s:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "linux") {
+		t.Errorf("merged report has the platform of the merging machine:\n%s", out.String())
+	}
+
+	if _, err := mergeReports([]string{filepath.Join(dir, "missing.xml")}); err == nil {
+		t.Errorf("mergeReports with missing file did not return an error")
+	}
+}
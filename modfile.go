@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// moduleFlagPath returns the module path of the tested packages: module if
+// it's set, otherwise the module path declared in the go.mod file modfile or,
+// if that's empty too, the one found by findModulePath for the current
+// directory.
+func moduleFlagPath(module, modfile string) (string, error) {
+	if module != "" {
+		return module, nil
+	}
+	if modfile == "" {
+		return findModulePath(".")
+	}
+	f, err := os.Open(modfile)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	path := modulePath(f)
+	if path == "" {
+		return "", fmt.Errorf("no module directive in %s", modfile)
+	}
+	return path, nil
+}
+
+// findModulePath returns the module path declared in the go.mod file in dir
+// or the closest parent directory that has one. It returns an empty string if
+// there is no go.mod file.
+func findModulePath(dir string) (string, error) {
+	file, err := findModFile(dir)
+	if err != nil || file == "" {
+		return "", err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return modulePath(f), nil
+}
+
+// findModFile returns the path of the go.mod file in dir or the closest parent
+// directory that has one, or an empty string if there is none.
+func findModFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		file := filepath.Join(dir, "go.mod")
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// modulePath returns the path in the module directive of the go.mod file read
+// from r.
+func modulePath(r io.Reader) string {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+		if path, err := strconv.Unquote(fields[1]); err == nil {
+			return path
+		}
+		return fields[1]
+	}
+	return ""
+}
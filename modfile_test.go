@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestModulePath(t *testing.T) {
+	tests := map[string]string{
+		"module example.com/mod\n\ngo 1.11\n":         "example.com/mod",
+		"// comment\nmodule \"example.com/q\" // x\n": "example.com/q",
+		"go 1.11\n": "",
+	}
+	for in, want := range tests {
+		if got := modulePath(strings.NewReader(in)); got != want {
+			t.Errorf("modulePath(%q) == %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFindModulePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "modfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/mod\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(dir, "pkg", "a")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	module, err := findModulePath(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if module != "example.com/mod" {
+		t.Errorf("findModulePath() == %q, want %q", module, "example.com/mod")
+	}
+}
+
+func TestModuleFlagPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "modfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	modfile := filepath.Join(dir, "go.mod")
+	if err := ioutil.WriteFile(modfile, []byte("module example.com/mod\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if module, err := moduleFlagPath("", modfile); err != nil || module != "example.com/mod" {
+		t.Errorf("moduleFlagPath(%q) == %q, %v, want %q", modfile, module, err, "example.com/mod")
+	}
+	if module, err := moduleFlagPath("example.com/x", modfile); err != nil || module != "example.com/x" {
+		t.Errorf("moduleFlagPath() with -module == %q, %v, want %q", module, err, "example.com/x")
+	}
+
+	empty := filepath.Join(dir, "empty.mod")
+	if err := ioutil.WriteFile(empty, []byte("go 1.11\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := moduleFlagPath("", empty); err == nil {
+		t.Errorf("moduleFlagPath(%q) returned no error", empty)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// uploadOTLP exports report as OpenTelemetry spans to the OTLP/HTTP endpoint,
+// with the headers in $OTEL_EXPORTER_OTLP_HEADERS.
+func uploadOTLP(report *parser.Report, opts formatter.Options, endpoint string) error {
+	headers, err := otlpHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return fmt.Errorf("in OTEL_EXPORTER_OTLP_HEADERS: %s", err)
+	}
+	var body bytes.Buffer
+	if err := formatter.WriteOTLPJSON(report, opts, &body); err != nil {
+		return err
+	}
+	return postJSON(strings.TrimSuffix(endpoint, "/")+"/v1/traces", headers, &body)
+}
+
+// otlpHeaders parses a comma separated list of URL encoded name=value pairs.
+func otlpHeaders(list string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, header := range strings.Split(list, ",") {
+		if strings.TrimSpace(header) == "" {
+			continue
+		}
+		idx := strings.Index(header, "=")
+		if idx < 0 {
+			return nil, fmt.Errorf("header %q is not in name=value format", header)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(header[idx+1:]))
+		if err != nil {
+			return nil, err
+		}
+		headers[strings.TrimSpace(header[:idx])] = value
+	}
+	return headers, nil
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOTLPHeaders(t *testing.T) {
+	headers, err := otlpHeaders("api-key=secret, x-team = a%20b,")
+	want := map[string]string{"api-key": "secret", "x-team": "a b"}
+	if err != nil || !reflect.DeepEqual(headers, want) {
+		t.Errorf("otlpHeaders() = %v, %v, want %v", headers, err, want)
+	}
+	if _, err := otlpHeaders("api-key"); err == nil {
+		t.Errorf("otlpHeaders() did not return an error for a header without value")
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+var regexUnsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// writeReportDir writes a separate report for each package in report to dir,
+// named TEST-<package> as is common for JUnit reports, with the extension of
+// format.
+func writeReportDir(f formatter.Formatter, format string, report *parser.Report, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	used := map[string]int{}
+	for _, pkg := range report.Packages {
+		name := packageFileName(pkg.Name)
+		used[name]++
+		if n := used[name]; n > 1 {
+			name = fmt.Sprintf("%s-%d", name, n)
+		}
+
+		single := &parser.Report{
+			Packages: []parser.Package{pkg},
+			Stderr:   report.Stderr,
+		}
+		if err := writeReport(f, single, filepath.Join(dir, "TEST-"+name+formatExtension(format))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// packageFileName returns a string suitable to be used in a file name for the
+// package with the given import path.
+func packageFileName(pkgName string) string {
+	if pkgName == "" {
+		return "unknown"
+	}
+	return regexUnsafeFileChars.ReplaceAllString(strings.Replace(pkgName, "/", ".", -1), "_")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestPackageFileName(t *testing.T) {
+	tests := map[string]string{
+		"":                        "unknown",
+		"github.com/foo/bar":      "github.com.foo.bar",
+		"example.com/a b/c:d":     "example.com.a_b.c_d",
+		"gopkg.in/yaml.v2/parser": "gopkg.in.yaml.v2.parser",
+	}
+	for in, want := range tests {
+		if got := packageFileName(in); got != want {
+			t.Errorf("packageFileName(%q) == %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWriteReportDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	report := &parser.Report{Packages: []parser.Package{{Name: "a/b"}, {Name: "c"}, {Name: "c"}}}
+	f, err := formatter.New("junit", formatter.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeReportDir(f, "junit", report, filepath.Join(dir, "reports")); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "reports", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	sort.Strings(files)
+	want := []string{"TEST-a.b.xml", "TEST-c-2.xml", "TEST-c.xml"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("written files == %v, want %v", files, want)
+	}
+
+	f, err = formatter.New("json", formatter.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeReportDir(f, "json", report, filepath.Join(dir, "json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "json", "TEST-a.b.json")); err != nil {
+		t.Errorf("json report not written with .json extension: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// output is a report format and the file it's written to.
+type output struct {
+	format string
+	path   string
+}
+
+// outputFlag is a repeatable flag of format=path outputs.
+type outputFlag []output
+
+func (o *outputFlag) String() string {
+	var outputs []string
+	for _, out := range *o {
+		outputs = append(outputs, out.format+"="+out.path)
+	}
+	return strings.Join(outputs, ",")
+}
+
+func (o *outputFlag) Set(value string) error {
+	idx := strings.Index(value, "=")
+	if idx < 1 || idx == len(value)-1 {
+		return errors.New("output must be of the form format=path")
+	}
+	*o = append(*o, output{format: value[:idx], path: value[idx+1:]})
+	return nil
+}
+
+// writeOutputs writes report in each of the requested formats. A path of "-"
+// writes to stdout.
+func writeOutputs(report *parser.Report, outputs []output, opts formatter.Options) error {
+	for _, out := range outputs {
+		f, err := formatter.New(out.format, opts)
+		if err != nil {
+			return err
+		}
+		path := out.path
+		if path == "-" {
+			path = ""
+		}
+		if err := writeReport(f, report, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestOutputFlag(t *testing.T) {
+	var outputs outputFlag
+	for _, value := range []string{"junit=report.xml", "template=out/summary=1.md"} {
+		if err := outputs.Set(value); err != nil {
+			t.Fatalf("Set(%q) returned error: %s", value, err)
+		}
+	}
+	for _, value := range []string{"", "junit", "=report.xml", "junit="} {
+		if err := outputs.Set(value); err == nil {
+			t.Errorf("Set(%q) did not return an error", value)
+		}
+	}
+
+	want := outputFlag{
+		{format: "junit", path: "report.xml"},
+		{format: "template", path: "out/summary=1.md"},
+	}
+	if !reflect.DeepEqual(outputs, want) {
+		t.Errorf("outputs == %v, want %v", outputs, want)
+	}
+}
+
+func TestWriteOutputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmpl := filepath.Join(dir, "report.tmpl")
+	if err := ioutil.WriteFile(tmpl, []byte("{{range .Packages}}{{.Name}}{{end}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report := &parser.Report{Packages: []parser.Package{{Name: "pkg"}}}
+	outputs := []output{
+		{format: "junit", path: filepath.Join(dir, "report.xml")},
+		{format: "template", path: filepath.Join(dir, "summary.txt")},
+	}
+	if err := writeOutputs(report, outputs, formatter.Options{Template: tmpl}); err != nil {
+		t.Fatalf("writeOutputs: %s", err)
+	}
+
+	xml, err := ioutil.ReadFile(outputs[0].path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(xml), `<testsuite tests="0"`) {
+		t.Errorf("unexpected junit output:\n%s", xml)
+	}
+	txt, err := ioutil.ReadFile(outputs[1].path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(txt) != "pkg" {
+		t.Errorf("template output == %q, want %q", txt, "pkg")
+	}
+
+	if err := writeOutputs(report, []output{{format: "unknown", path: "x"}}, formatter.Options{}); err == nil {
+		t.Errorf("writeOutputs with unknown format did not return an error")
+	}
+}
+
+func TestWriteReportFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "report.xml")
+	if err := ioutil.WriteFile(file, []byte("previous report"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	failing := formatter.FormatterFunc(func(report *parser.Report, w io.Writer) error {
+		io.WriteString(w, "<testsuites>")
+		return errors.New("encoding failed")
+	})
+	if err := writeReport(failing, &parser.Report{}, file); err == nil {
+		t.Fatal("writeReport did not return the error of the formatter")
+	}
+	if got, err := ioutil.ReadFile(file); err != nil || string(got) != "previous report" {
+		t.Errorf("report after a failure == %q (%v), want the previous report", got, err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("writeReport left %d files, want 1", len(files))
+	}
+
+	ok := formatter.FormatterFunc(func(report *parser.Report, w io.Writer) error {
+		_, err := io.WriteString(w, "<testsuites></testsuites>")
+		return err
+	})
+	if err := writeReport(ok, &parser.Report{}, file); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ioutil.ReadFile(file); err != nil || string(got) != "<testsuites></testsuites>" {
+		t.Errorf("report == %q (%v), want the new report", got, err)
+	}
+}
@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// regexAttachment matches the attachment markers of the Jenkins attachments
+// plugin.
+var regexAttachment = regexp.MustCompile(`\[\[ATTACHMENT\|([^\]\r\n]+)\]\]`)
+
+// CollectAttachments adds the files referenced by [[ATTACHMENT|path]] markers,
+// the convention of the Jenkins attachments plugin, in the output of tests and
+// packages to their Attachments.
+func (r *Report) CollectAttachments() error {
+	for i := range r.Packages {
+		pkg := &r.Packages[i]
+		for _, line := range pkg.Output {
+			pkg.Attachments = appendAttachments(pkg.Attachments, findAttachments(line)...)
+		}
+		for _, test := range pkg.Tests {
+			err := test.EachOutputLine(func(line string) error {
+				test.Attachments = appendAttachments(test.Attachments, findAttachments(line)...)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Attach adds path to the attachments of the named package, or of all tests
+// with the given name in it if test is not empty. It returns false if no
+// such package or test exists.
+func (r *Report) Attach(pkgName, test, path string) bool {
+	found := false
+	for i := range r.Packages {
+		pkg := &r.Packages[i]
+		if pkg.Name != pkgName {
+			continue
+		}
+		if test == "" {
+			pkg.Attachments = appendAttachments(pkg.Attachments, path)
+			found = true
+			continue
+		}
+		for _, t := range pkg.Tests {
+			if t.Name == test {
+				t.Attachments = appendAttachments(t.Attachments, path)
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+func findAttachments(line string) []string {
+	if !strings.Contains(line, "[[ATTACHMENT|") {
+		return nil
+	}
+	var paths []string
+	for _, match := range regexAttachment.FindAllStringSubmatch(line, -1) {
+		paths = append(paths, match[1])
+	}
+	return paths
+}
+
+// appendAttachments appends the paths that aren't in attachments yet.
+func appendAttachments(attachments []string, paths ...string) []string {
+	for _, path := range paths {
+		found := false
+		for _, a := range attachments {
+			if a == path {
+				found = true
+				break
+			}
+		}
+		if !found {
+			attachments = append(attachments, path)
+		}
+	}
+	return attachments
+}
@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// benchmarkTest returns the test of benchmark name run with cpu as
+// GOMAXPROCS, or 0 for lines without a result, adding it if it didn't run
+// before. A benchmark run with several GOMAXPROCS values, e.g. with go test
+// -cpu=1,4, is reported as a test for every value, named like go test prints
+// them, e.g. "BenchmarkX" and "BenchmarkX-4". Otherwise the test is named
+// after the benchmark.
+func (p *lineParser) benchmarkTest(name string, cpu int) *Test {
+	var variants []*Test
+	for _, test := range p.tests {
+		if benchmarkBase(test.Name) == name {
+			variants = append(variants, test)
+		}
+	}
+	for _, test := range variants {
+		if cpu == 0 || test.CPU == cpu || test.CPU == 0 {
+			if test.CPU == 0 {
+				// started by a RUN line, or a line without a result
+				test.CPU = cpu
+			}
+			return test
+		}
+	}
+
+	test := &Test{Name: name, CPU: cpu, Result: PASS, Output: make([]string, 0)}
+	if len(variants) > 0 {
+		for _, v := range variants {
+			v.Name = benchmarkName(name, v.CPU)
+		}
+		test.Name = benchmarkName(name, cpu)
+	}
+	p.tests = append(p.tests, test)
+	return test
+}
+
+// benchmarkName returns the name go test prints for benchmark name run with
+// cpu as GOMAXPROCS.
+func benchmarkName(name string, cpu int) string {
+	if cpu <= 1 {
+		return name
+	}
+	return name + "-" + strconv.Itoa(cpu)
+}
+
+// benchmarkBase returns name without the GOMAXPROCS suffix added by
+// benchmarkName.
+func benchmarkBase(name string) string {
+	if i := strings.LastIndexByte(name, '-'); i > 0 {
+		if _, err := strconv.Atoi(name[i+1:]); err == nil {
+			return name[:i]
+		}
+	}
+	return name
+}
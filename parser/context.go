@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"context"
+	"io"
+)
+
+// ParseContext parses go test output from reader r like ParseWithOptions, but
+// stops reading when ctx is done. It then returns the report of the input
+// read so far, in which tests that were still running are incomplete, and
+// the error of ctx. Reading r continues in the background until its current
+// Read call returns.
+func ParseContext(ctx context.Context, r io.Reader, opts ...Option) (*Report, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := io.Copy(pw, r)
+		pw.CloseWithError(err)
+	}()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// the parser sees the end of the input
+			pw.Close()
+		case <-done:
+		}
+	}()
+
+	report, err := ParseWithOptions(pr, opts...)
+	if err != nil {
+		return report, err
+	}
+	return report, ctx.Err()
+}
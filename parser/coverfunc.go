@@ -0,0 +1,44 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// FuncCoverage is the statement coverage of a single function, as reported by
+// `go tool cover -func`.
+type FuncCoverage struct {
+	File     string // import path based file name, e.g. example.com/pkg/file.go
+	Line     int
+	Function string
+	Pct      float64
+}
+
+var regexCoverFunc = regexp.MustCompile(`^(.+\.go):(\d+):\s+(\S+)\s+(` + numberPattern + `)\s*%$`)
+
+// ParseCoverFunc parses the output of `go tool cover -func=profile` from r.
+// The total line and any unrecognized lines are ignored.
+func ParseCoverFunc(r io.Reader) ([]FuncCoverage, error) {
+	var funcs []FuncCoverage
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		matches := regexCoverFunc.FindStringSubmatch(normalizeSpace(scanner.Text()))
+		if matches == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(matches[2])
+		funcs = append(funcs, FuncCoverage{
+			File:     matches[1],
+			Line:     line,
+			Function: matches[3],
+			Pct:      parseCoverage(matches[4]),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return funcs, nil
+}
@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CoverProfile is a coverage profile written by `go test -coverprofile`.
+type CoverProfile struct {
+	Mode   string
+	Blocks []ProfileBlock
+}
+
+// ProfileBlock is a block of statements in a coverage profile. Position is the
+// start and end position of the block in File, e.g. 10.2,12.16.
+type ProfileBlock struct {
+	File       string // import path based file name, e.g. example.com/pkg/file.go
+	Position   string
+	Statements int
+	Count      int
+}
+
+var regexProfileBlock = regexp.MustCompile(`^(.+\.go):(\d+\.\d+,\d+\.\d+) (\d+) (\d+)$`)
+
+// ParseCoverProfile parses a coverage profile from r. Blocks that occur more
+// than once, as in profiles concatenated from several test runs, are merged:
+// their counts are added.
+func ParseCoverProfile(r io.Reader) (CoverProfile, error) {
+	var profile CoverProfile
+	index := map[string]int{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "mode: ") {
+			if profile.Mode == "" {
+				profile.Mode = strings.TrimPrefix(text, "mode: ")
+			}
+			continue
+		}
+		matches := regexProfileBlock.FindStringSubmatch(text)
+		if matches == nil {
+			return profile, fmt.Errorf("line %d: invalid block %q", line, text)
+		}
+		stmts, _ := strconv.Atoi(matches[3])
+		count, _ := strconv.Atoi(matches[4])
+
+		key := matches[1] + ":" + matches[2]
+		if i, ok := index[key]; ok {
+			profile.Blocks[i].Count += count
+			continue
+		}
+		index[key] = len(profile.Blocks)
+		profile.Blocks = append(profile.Blocks, ProfileBlock{
+			File:       matches[1],
+			Position:   matches[2],
+			Statements: stmts,
+			Count:      count,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return profile, err
+	}
+	if profile.Mode == "" {
+		return profile, fmt.Errorf("missing mode line")
+	}
+	return profile, nil
+}
+
+// Coverage returns the percentage of statements covered in the package
+// pkgName, or in all packages if pkgName is empty. It returns false if the
+// profile contains no statements of the package.
+func (p CoverProfile) Coverage(pkgName string) (float64, bool) {
+	var total, covered int
+	for _, block := range p.Blocks {
+		if pkgName != "" && path.Dir(block.File) != pkgName {
+			continue
+		}
+		total += block.Statements
+		if block.Count > 0 {
+			covered += block.Statements
+		}
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return 100 * float64(covered) / float64(total), true
+}
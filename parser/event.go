@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// event is a single test2json event, as produced by `go test -json`.
+type event struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// eventActions contains all actions test2json is known to emit.
+var eventActions = map[string]bool{
+	"start":        true,
+	"run":          true,
+	"pause":        true,
+	"cont":         true,
+	"pass":         true,
+	"bench":        true,
+	"fail":         true,
+	"output":       true,
+	"skip":         true,
+	"build-output": true,
+	"build-fail":   true,
+}
+
+// parseEvent tries to parse line as a test2json event. Lines that are not JSON
+// objects, or JSON objects without a known Action, are not events.
+func parseEvent(line string) (*event, bool) {
+	if !strings.HasPrefix(line, "{") || !strings.HasSuffix(strings.TrimSpace(line), "}") {
+		return nil, false
+	}
+
+	ev := &event{}
+	if err := json.Unmarshal([]byte(line), ev); err != nil {
+		return nil, false
+	}
+	if !eventActions[ev.Action] {
+		return nil, false
+	}
+	return ev, true
+}
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// FilterPackages removes the packages whose name doesn't match any of the
+// include patterns, if there are any, or that match one of the exclude
+// patterns. Patterns are globs in which * matches any sequence of characters
+// except /, ** matches any sequence of characters and ? matches any single
+// character except /.
+func (r *Report) FilterPackages(include, exclude []string) error {
+	inc, err := compileGlobs(include)
+	if err != nil {
+		return err
+	}
+	exc, err := compileGlobs(exclude)
+	if err != nil {
+		return err
+	}
+
+	packages := r.Packages[:0]
+	for _, pkg := range r.Packages {
+		if (len(inc) == 0 || matchAny(inc, pkg.Name)) && !matchAny(exc, pkg.Name) {
+			packages = append(packages, pkg)
+		}
+	}
+	r.Packages = packages
+	return nil
+}
+
+func compileGlobs(patterns []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := globRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// globRegexp returns a regexp that matches the same strings as glob pattern.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterTests removes the tests for which keep returns false from all
+// packages in the report. Subtests of removed tests are linked to their
+// closest remaining ancestor.
+func (r *Report) FilterTests(keep func(*Test) bool) {
+	for i := range r.Packages {
+		pkg := &r.Packages[i]
+		tests := pkg.Tests[:0]
+		for _, test := range pkg.Tests {
+			if keep(test) {
+				tests = append(tests, test)
+			}
+		}
+		pkg.Tests = tests
+		linkSubtests(pkg.Tests)
+	}
+}
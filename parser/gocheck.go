@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// regexGocheck matches the line gopkg.in/check.v1 prints for a test or
+	// fixture method, e.g. "PASS: foo_test.go:12: MySuite.TestBar\t0.042s"
+	// or "SKIP: foo_test.go:20: MySuite.TestBaz (not supported)". Problems
+	// are followed by the log of the method. With -check.vv a START line
+	// precedes the log of every method.
+	regexGocheck = regexp.MustCompile(`^(START|PASS|FAIL|SKIP|MISS|PANIC|FIXTURE-PANIC|FIXTURE-FAIL|FAIL EXPECTED): (\S+\.go:\d+): (\S+)(?: \((.*)\))?(?:\s+(` + numberPattern + `)s)?$`)
+	// regexGocheckEnd matches the lines that end the log of a gocheck
+	// method: the line separating problems and the summary of the suites,
+	// e.g. "OOPS: 1 passed, 1 FAILED".
+	regexGocheckEnd = regexp.MustCompile(`^(?:-{70}|(?:OK|OOPS): \d+ passed.*)$`)
+)
+
+// parseGocheckLine handles line if it's part of the output of tests written
+// with gopkg.in/check.v1, which run within a single go test function. The
+// methods are reported as tests named after their suite and method, e.g.
+// "MySuite.TestBar", their log is the output of the test. It returns false if
+// the line is to be parsed as usual, the summary of the suites is output of
+// the go test function.
+func (p *lineParser) parseGocheckLine(line, plain string) bool {
+	if matches := regexGocheck.FindStringSubmatch(plain); matches != nil {
+		p.endGocheckOutput()
+		if !p.inGocheck {
+			p.inGocheck = true
+			p.gocheckParent = p.cur
+		}
+		name := matches[3]
+		test := findTest(p.tests, name)
+		if test == nil {
+			test = &Test{Name: name, Result: FAIL, Output: make([]string, 0)}
+			p.tests = append(p.tests, test)
+		}
+
+		result := "FAIL"
+		switch matches[1] {
+		case "START":
+			result = ""
+		case "PASS", "FAIL EXPECTED":
+			result = "PASS"
+		case "SKIP", "MISS":
+			result = "SKIP"
+		}
+		p.decide(Decision{Kind: "gocheck", Text: line, Test: name, Result: result})
+		if result == "" {
+			// the log follows while the method runs
+			p.cur = name
+			p.curFinished = false
+			return true
+		}
+
+		p.finished[test] = true
+		test.Duration = parseSeconds(matches[5])
+		test.Time = int(test.Duration / time.Millisecond) // deprecated
+		switch result {
+		case "PASS":
+			test.Result = PASS
+			p.cur = p.gocheckParent
+		case "SKIP":
+			test.Result = SKIP
+			if matches[4] != "" {
+				p.appendOutput(test, matches[4])
+			}
+			p.cur = p.gocheckParent
+		default:
+			// the log of a problem follows
+			test.Result = FAIL
+			p.cur = name
+			p.curFinished = false
+		}
+		return true
+	}
+	if strings.HasPrefix(plain, "-") && regexGocheckEnd.MatchString(plain) {
+		// the separator precedes every problem
+		p.endGocheckOutput()
+		if !p.inGocheck {
+			p.inGocheck = true
+			p.gocheckParent = p.cur
+		}
+		p.cur = p.gocheckParent
+		p.decide(Decision{Kind: "gocheck", Text: line})
+		return true
+	}
+	if !p.inGocheck {
+		return false
+	}
+
+	if regexGocheckEnd.MatchString(plain) {
+		p.endGocheckOutput()
+		p.cur = p.gocheckParent
+		// the summary ends the run of the suites
+		p.inGocheck = false
+		return false
+	}
+	if test := p.gocheckTest(); plain == "" && test != nil && len(test.Output) == 0 && test.SpilledLines == 0 {
+		// the log of a problem is preceded by an empty line
+		p.decide(Decision{Kind: "gocheck", Text: line, Test: test.Name})
+		return true
+	}
+	return false
+}
+
+// gocheckTest returns the gocheck method whose log is being parsed, if any.
+func (p *lineParser) gocheckTest() *Test {
+	if !p.inGocheck || p.cur == p.gocheckParent {
+		return nil
+	}
+	return findTest(p.tests, p.cur)
+}
+
+// endGocheckOutput removes the empty lines that end the output of the current
+// test, the log of a gocheck method or the output of the go test function
+// running the suites, which gocheck prints to separate its lines.
+func (p *lineParser) endGocheckOutput() {
+	if test := findTest(p.tests, p.cur); test != nil {
+		test.Output = trimEmptyLines(test.Output)
+	} else {
+		p.buffers[p.cur] = trimEmptyLines(p.buffers[p.cur])
+	}
+}
+
+// trimEmptyLines removes the empty lines at the end of lines.
+func trimEmptyLines(lines []string) []string {
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
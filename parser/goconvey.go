@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// regexConveyScope matches the line the story reporter of GoConvey
+	// prints with -v for a Convey scope: its title indented by its level,
+	// followed by a space and a mark for every assertion in the scope, e.g.
+	// "    When the integer is incremented ✔✘".
+	regexConveyScope = regexp.MustCompile(`^((?:  )+)(\S.*?) ([✔✘🔥⚠]*)$`)
+	// regexConveyProblems matches the headers of the details of failed
+	// assertions and of panics, which are listed in the order of their
+	// marks after a story.
+	regexConveyProblems = regexp.MustCompile(`^(Errors|Failures):$`)
+	regexConveyTotal    = regexp.MustCompile(`^\d+ total assertions`)
+)
+
+// conveyStory is the state of a GoConvey story, the scopes of a top-level
+// Convey call, while its output is parsed.
+type conveyStory struct {
+	// test running the story
+	parent string
+	// titles of the scopes enclosing the current scope and its own
+	titles []string
+	// whether scope lines are being parsed
+	inStory bool
+
+	// scenarios in the order of their failed assertions and panics, and
+	// those whose details are being parsed and the current one
+	failures, errors []*Test
+	problems         []*Test
+	problem          int
+}
+
+// parseConveyLine handles line if it's part of the output of a GoConvey story
+// run with go test -v. Every Convey scope with assertions is reported as a
+// subtest of the running test, named after the titles of the scope and its
+// enclosing scopes, e.g. "TestSpec/Given an integer/When it's incremented",
+// which fails if an assertion in it failed or panicked. The details of these
+// problems are the output of the subtest. Scope lines stay output of the
+// running test. afterEmpty is whether the previous line was empty, which
+// precedes every story. It returns whether the line was handled.
+func (p *lineParser) parseConveyLine(line, plain string, afterEmpty bool) bool {
+	s := p.convey
+	if matches := regexConveyScope.FindStringSubmatch(plain); matches != nil && (s == nil || s.problems == nil) {
+		if s == nil || !s.inStory {
+			if !afterEmpty || findTest(p.tests, p.cur) == nil {
+				return false
+			}
+			// a new story
+			s = &conveyStory{parent: p.cur, inStory: true}
+			p.convey = s
+		}
+		level := len(matches[1]) / 2
+		if s.parent != p.cur || level > len(s.titles)+1 {
+			s.inStory = false
+			return false
+		}
+		s.titles = append(s.titles[:level-1], matches[2])
+		if matches[3] != "" {
+			p.conveyScenario(s, matches[3])
+		}
+		return false
+	}
+	if s == nil || s.parent != p.cur {
+		return false
+	}
+	s.inStory = false
+
+	if matches := regexConveyProblems.FindStringSubmatch(plain); matches != nil {
+		s.problems, s.problem = s.failures, -1
+		if matches[1] == "Errors" {
+			s.problems = s.errors
+		}
+		return false
+	}
+	if s.problems == nil {
+		return false
+	}
+	if regexConveyTotal.MatchString(plain) {
+		p.convey = nil
+		return false
+	}
+	if strings.TrimSpace(plain) != "" && !strings.HasPrefix(plain, "  ") {
+		s.problems = nil
+		return false
+	}
+
+	if strings.HasPrefix(plain, "  * ") {
+		// the file of the assertion starts its details
+		s.problem++
+	}
+	if s.problem < 0 || s.problem >= len(s.problems) {
+		return false
+	}
+	test := s.problems[s.problem]
+	if strings.TrimSpace(plain) != "" {
+		p.appendOutput(test, strings.TrimPrefix(line, "  "))
+	}
+	p.decide(Decision{Kind: "goconvey", Text: line, Test: test.Name})
+	return true
+}
+
+// conveyScenario adds the results of the assertions in marks to the scenario
+// of the current scope of story s.
+func (p *lineParser) conveyScenario(s *conveyStory, marks string) {
+	name := s.parent + "/" + strings.Join(s.titles, "/")
+	test := findTest(p.tests, name)
+	if test == nil {
+		test = &Test{Name: name, Result: SKIP, Output: make([]string, 0)}
+		p.tests = append(p.tests, test)
+		p.finished[test] = true
+	}
+	for _, mark := range marks {
+		switch mark {
+		case '✔':
+			if test.Result == SKIP {
+				test.Result = PASS
+			}
+		case '✘':
+			test.Result = FAIL
+			s.failures = append(s.failures, test)
+		case '🔥':
+			test.Result = FAIL
+			s.errors = append(s.errors, test)
+		}
+	}
+}
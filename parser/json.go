@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The JSON encoding of a Report is meant to be consumed by tools that don't
+// use these types. Durations are encoded as a number of seconds and results
+// as lower case strings. Deprecated fields and fields derived from others,
+// such as the subtest tree, are left out and restored when decoding.
+
+// MarshalText implements encoding.TextMarshaler.
+func (r Result) MarshalText() ([]byte, error) {
+	if r < PASS || r > ERROR {
+		return nil, fmt.Errorf("invalid result %d", int(r))
+	}
+	return []byte(strings.ToLower(r.String())), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (r *Result) UnmarshalText(text []byte) error {
+	for res := PASS; res <= ERROR; res++ {
+		if strings.EqualFold(string(text), res.String()) {
+			*r = res
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid result %q", text)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p Package) MarshalJSON() ([]byte, error) {
+	type pkg Package
+	return json.Marshal(struct {
+		*pkg
+		Duration float64 `json:"duration"`
+	}{(*pkg)(&p), p.Duration.Seconds()})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *Package) UnmarshalJSON(data []byte) error {
+	type pkg Package
+	v := struct {
+		*pkg
+		Duration float64 `json:"duration"`
+	}{pkg: (*pkg)(p)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	p.Duration = secondsDuration(v.Duration)
+	p.Time = int(p.Duration / time.Millisecond)
+	p.Coverage = parseCoverage(p.CoveragePct)
+	linkSubtests(p.Tests)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. Spilled output is read back, see
+// AllOutput.
+func (t *Test) MarshalJSON() ([]byte, error) {
+	type test Test
+	output, err := t.AllOutput()
+	if err != nil {
+		return nil, err
+	}
+	if output == nil {
+		output = []string{}
+	}
+	return json.Marshal(struct {
+		*test
+		Output   []string `json:"output"`
+		Duration float64  `json:"duration"`
+		Start    string   `json:"start,omitempty"`
+		End      string   `json:"end,omitempty"`
+		Paused   float64  `json:"paused,omitempty"`
+	}{(*test)(t), output, t.Duration.Seconds(), formatJSONTime(t.Start), formatJSONTime(t.End), t.Paused.Seconds()})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Test) UnmarshalJSON(data []byte) error {
+	type test Test
+	v := struct {
+		*test
+		Duration float64 `json:"duration"`
+		Start    string  `json:"start"`
+		End      string  `json:"end"`
+		Paused   float64 `json:"paused"`
+	}{test: (*test)(t)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	t.Duration = secondsDuration(v.Duration)
+	t.Time = int(t.Duration / time.Millisecond)
+	t.Paused = secondsDuration(v.Paused)
+	var err error
+	if t.Start, err = parseJSONTime(v.Start); err != nil {
+		return err
+	}
+	t.End, err = parseJSONTime(v.End)
+	return err
+}
+
+// formatJSONTime formats t for the JSON encoding, the zero time as an empty
+// string.
+func formatJSONTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// parseJSONTime parses a time formatted by formatJSONTime.
+func parseJSONTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+func secondsDuration(s float64) time.Duration {
+	return time.Duration(s*float64(time.Second) + 0.5)
+}
@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// WithMaxLineBytes shortens input lines longer than maxBytes bytes while
+// reading, keeping their start and a note saying how many bytes were removed.
+// A test2json event whose output is cut off is still parsed as event. By
+// default lines of any length are read.
+func WithMaxLineBytes(maxBytes int) Option {
+	return func(o *options) { o.maxLineBytes = maxBytes }
+}
+
+// WithMaxTestBytes keeps at most about maxBytes bytes of output of each test
+// in memory while parsing. Like TruncateOutput, the first and last lines of
+// output are kept and the lines in between are replaced by a line saying how
+// many lines were removed, lines aren't shortened though. It's not used with
+// WithSpill, which limits the output in memory already.
+func WithMaxTestBytes(maxBytes int) Option {
+	return func(o *options) { o.maxTestBytes = maxBytes }
+}
+
+// readLine reads the next line from r, without the line ending. Lines longer
+// than p.maxLineBytes are shortened, the remainder of the line is discarded
+// as it is read. It returns io.EOF if there are no more lines.
+func (p *lineParser) readLine(r *bufio.Reader) (string, error) {
+	var line []byte
+	removed := 0
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		if err == io.EOF && (len(line) > 0 || removed > 0) {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		if n := p.maxLineBytes - len(line); p.maxLineBytes > 0 && len(chunk) > n {
+			line = append(line, chunk[:n]...)
+			removed += len(chunk) - n
+		} else {
+			line = append(line, chunk...)
+		}
+		if !isPrefix {
+			break
+		}
+	}
+	if removed == 0 {
+		return string(line), nil
+	}
+
+	// don't split UTF-8 sequences
+	n := len(line)
+	if i := lastRuneStart(line); n > 0 && !utf8.FullRune(line[i:]) {
+		n = i
+	}
+	removed += len(line) - n
+	if event, ok := truncatedEvent(string(line[:n]), removed); ok {
+		return event, nil
+	}
+	return fmt.Sprintf("%s … %d bytes truncated …", line[:n], removed), nil
+}
+
+// lastRuneStart returns the index of the first byte of the last UTF-8
+// sequence in b.
+func lastRuneStart(b []byte) int {
+	i := len(b) - 1
+	for i > 0 && !utf8.RuneStart(b[i]) {
+		i--
+	}
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+// truncatedEvent completes a test2json event that was cut off in its output,
+// given the start of the event and the number of bytes removed from it. The
+// note saying how many bytes were removed ends the output. It returns false
+// if prefix isn't the start of an event with output.
+func truncatedEvent(prefix string, removed int) (string, bool) {
+	if !strings.HasPrefix(prefix, "{") || !strings.Contains(prefix, `"Output":"`) {
+		return "", false
+	}
+	// don't end within an escape sequence, e.g. \n or \u001b
+	line := prefix
+	if i := strings.LastIndexByte(line, '\\'); i >= 0 && i >= len(line)-6 {
+		for i > 0 && line[i-1] == '\\' {
+			i--
+		}
+		line = line[:i]
+	}
+	removed += len(prefix) - len(line)
+	line += fmt.Sprintf(` … %d bytes truncated …\n"}`, removed)
+	if _, ok := parseEvent(line); !ok {
+		return "", false
+	}
+	return line, true
+}
+
+// cappedOutput is the state of the output of a test limited by
+// WithMaxTestBytes. The output consists of head lines, which are kept, and
+// tail lines, of which the oldest are removed when they get too large.
+type cappedOutput struct {
+	head      int
+	headBytes int
+	headDone  bool
+	tailBytes int
+	removed   int
+}
+
+// appendCapped appends lines to the output of test, removing the oldest lines
+// after the first ones if it gets larger than p.maxTestBytes.
+func (p *lineParser) appendCapped(test *Test, lines ...string) {
+	c := p.capped[test]
+	if c == nil {
+		c = &cappedOutput{}
+		p.capped[test] = c
+	}
+	for _, line := range lines {
+		size := len(line) + 1
+		test.Output = append(test.Output, line)
+		if !c.headDone && c.headBytes+size <= p.maxTestBytes/2 {
+			c.head++
+			c.headBytes += size
+			continue
+		}
+		c.headDone = true
+		c.tailBytes += size
+		// shorten the tail in batches, so lines aren't moved for every line
+		if c.tailBytes > p.maxTestBytes {
+			p.shortenTail(test, c, p.maxTestBytes/2)
+		}
+	}
+}
+
+// shortenTail removes the oldest tail lines of test until they're at most
+// maxBytes bytes, except for the last line.
+func (p *lineParser) shortenTail(test *Test, c *cappedOutput, maxBytes int) {
+	if c.head > len(test.Output) {
+		// lines were removed from the output, e.g. a benchmark header
+		c.head = len(test.Output)
+	}
+	n := c.head
+	for c.tailBytes > maxBytes && n < len(test.Output)-1 {
+		c.tailBytes -= len(test.Output[n]) + 1
+		c.removed++
+		n++
+	}
+	test.Output = append(test.Output[:c.head], test.Output[n:]...)
+}
+
+// finishCapped shortens the output of tests limited by WithMaxTestBytes to
+// its final size and adds a line saying how many lines were removed.
+func (p *lineParser) finishCapped() {
+	for test, c := range p.capped {
+		p.shortenTail(test, c, p.maxTestBytes-p.maxTestBytes/2)
+		if c.removed > 0 {
+			note := fmt.Sprintf("… %d lines truncated …", c.removed)
+			test.Output = append(test.Output[:c.head], append([]string{note}, test.Output[c.head:]...)...)
+		}
+		delete(p.capped, test)
+	}
+}
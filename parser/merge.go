@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"time"
+)
+
+// severity orders results from best to worst for Merge. A test that passed in
+// one report and was skipped in another is considered to have passed.
+var severity = map[Result]int{SKIP: 0, PASS: 1, FAIL: 2, ERROR: 3}
+
+// Merge combines reports into a single new report, the given reports are not
+// modified. Packages with the same name are merged into one package, in the
+// order they first appear:
+//
+//   - durations are summed
+//   - the highest coverage is kept
+//   - properties and attachments are concatenated, leaving out exact
+//     duplicates
+//
+// Tests with the same name in the same package are merged: their durations
+// are summed, the worst result (error, fail, pass, skip) is kept along with
+// its error type and flags, and their output is concatenated. If a test
+// occurs more than once in a package, for example when run with -count, the
+// n-th occurrence is merged with the n-th occurrence of the other reports.
+// Stderr of all reports, the output of packages that isn't part of any test
+// and the output of build errors is concatenated.
+func Merge(reports ...*Report) *Report {
+	merged := &Report{Packages: []Package{}}
+	index := map[string]int{}
+	tests := map[string]map[string][]*Test{}
+
+	for _, report := range reports {
+		if report == nil {
+			continue
+		}
+		merged.Stderr = append(merged.Stderr, report.Stderr...)
+
+		for _, pkg := range report.Packages {
+			i, ok := index[pkg.Name]
+			if !ok {
+				i = len(merged.Packages)
+				index[pkg.Name] = i
+				tests[pkg.Name] = map[string][]*Test{}
+				merged.Packages = append(merged.Packages, Package{Name: pkg.Name, Tests: []*Test{}})
+			}
+			dst := &merged.Packages[i]
+
+			dst.Duration += pkg.Duration
+			dst.Output = append(dst.Output, pkg.Output...)
+			dst.Attachments = appendAttachments(dst.Attachments, pkg.Attachments...)
+			if pkg.BuildError != nil {
+				if dst.BuildError == nil {
+					dst.BuildError = &BuildError{Name: pkg.BuildError.Name, Type: pkg.BuildError.Type}
+				}
+				dst.BuildError.Output = append(dst.BuildError.Output, pkg.BuildError.Output...)
+			}
+			dst.Time = int(dst.Duration / time.Millisecond)
+			if pkg.CoveragePct != "" && (dst.CoveragePct == "" || pkg.Coverage > dst.Coverage) {
+				dst.CoveragePct = pkg.CoveragePct
+				dst.Coverage = pkg.Coverage
+			}
+			for _, prop := range pkg.Properties {
+				if !hasProperty(dst.Properties, prop) {
+					dst.Properties = append(dst.Properties, prop)
+				}
+			}
+
+			byName := tests[pkg.Name]
+			seen := map[string]int{}
+			for _, test := range pkg.Tests {
+				n := seen[test.Name]
+				seen[test.Name]++
+				if n < len(byName[test.Name]) {
+					mergeTest(byName[test.Name][n], test)
+					continue
+				}
+				t := *test
+				t.Output = append([]string{}, test.Output...)
+				t.Attachments = append([]string(nil), test.Attachments...)
+				t.Parent, t.Subtests = nil, nil
+				byName[test.Name] = append(byName[test.Name], &t)
+				dst.Tests = append(dst.Tests, &t)
+			}
+		}
+	}
+
+	merged.LinkSubtests()
+	return merged
+}
+
+func mergeTest(dst, src *Test) {
+	dst.Duration += src.Duration
+	dst.Time = int(dst.Duration / time.Millisecond)
+	if severity[src.Result] > severity[dst.Result] {
+		dst.Result = src.Result
+		dst.ErrorType = src.ErrorType
+		dst.Incomplete = src.Incomplete
+		dst.Quarantined = src.Quarantined
+		dst.FailureIgnored = src.FailureIgnored
+	}
+	if dst.Owner == "" {
+		dst.Owner = src.Owner
+	}
+	if dst.File == "" {
+		dst.File = src.File
+	}
+	if !src.Start.IsZero() && (dst.Start.IsZero() || src.Start.Before(dst.Start)) {
+		dst.Start = src.Start
+	}
+	// the durations of the runs are added up, their wall and active
+	// durations are unknown
+	dst.End, dst.Paused = time.Time{}, 0
+	dst.Output = append(dst.Output, src.Output...)
+	dst.Attachments = appendAttachments(dst.Attachments, src.Attachments...)
+}
+
+func hasProperty(props []Property, prop Property) bool {
+	for _, p := range props {
+		if p == prop {
+			return true
+		}
+	}
+	return false
+}
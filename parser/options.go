@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"io"
+)
+
+// SubtestMode controls how ParseWithOptions reports tests with subtests.
+type SubtestMode int
+
+const (
+	// SubtestsAll reports parent tests and subtests.
+	SubtestsAll SubtestMode = iota
+	// SubtestsExcludeParents removes tests that have subtests, see
+	// ExcludeParents.
+	SubtestsExcludeParents
+	// SubtestsIgnoreParentResults reports failed parent tests whose
+	// failure is explained by their subtests as passed, see
+	// IgnoreParentResults.
+	SubtestsIgnoreParentResults
+)
+
+// InputFormat is the format of the test output read by ParseWithOptions.
+type InputFormat int
+
+const (
+	// InputAuto accepts both plain text output and test2json events, as
+	// written by go test -json, which may be mixed.
+	InputAuto InputFormat = iota
+	// InputText parses every line as plain text output, even if it looks
+	// like a test2json event.
+	InputText
+	// InputJSON expects test2json events. Other lines, such as the standard
+	// error output of go test -json when both are redirected to the same
+	// file, are added to Report.Stderr.
+	InputJSON
+)
+
+// Option configures ParseWithOptions.
+type Option func(*options)
+
+type options struct {
+	pkgName            string
+	subtestMode        SubtestMode
+	stripANSI          bool
+	maxLines, maxBytes int
+	format             InputFormat
+	spillDir           string
+	spillLines         int
+	recorder           func(Record)
+	maxLineBytes       int
+	maxTestBytes       int
+}
+
+// WithPackageName sets the name of the package of tests whose output doesn't
+// end with a package result, e.g. the output of a compiled test binary.
+func WithPackageName(name string) Option {
+	return func(o *options) { o.pkgName = name }
+}
+
+// WithSubtestMode sets how tests with subtests are reported, the default is
+// SubtestsAll.
+func WithSubtestMode(mode SubtestMode) Option {
+	return func(o *options) { o.subtestMode = mode }
+}
+
+// WithStripANSI removes ANSI escape codes from the output in the report. They
+// are always ignored when parsing.
+func WithStripANSI() Option {
+	return func(o *options) { o.stripANSI = true }
+}
+
+// WithMaxOutput limits the output of every test to about maxLines lines and
+// maxBytes bytes, see TruncateOutput.
+func WithMaxOutput(maxLines, maxBytes int) Option {
+	return func(o *options) { o.maxLines, o.maxBytes = maxLines, maxBytes }
+}
+
+// WithInputFormat sets the format of the input, the default is InputAuto.
+func WithInputFormat(format InputFormat) Option {
+	return func(o *options) { o.format = format }
+}
+
+// WithSpill keeps at most maxLines lines of output of each test in memory and
+// moves earlier lines to temporary files in dir, see ParseSpill.
+func WithSpill(dir string, maxLines int) Option {
+	return func(o *options) { o.spillDir, o.spillLines = dir, maxLines }
+}
+
+// WithRecorder calls rec with a Record of the parser decisions for every line
+// of input, see ParseRecorded.
+func WithRecorder(rec func(Record)) Option {
+	return func(o *options) { o.recorder = rec }
+}
+
+// ParseWithOptions parses go test output from reader r configured by opts and
+// returns a report.
+func ParseWithOptions(r io.Reader, opts ...Option) (*Report, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	p := newLineParser(o.pkgName)
+	p.recorder = o.recorder
+	p.stripOutput = o.stripANSI
+	p.format = o.format
+	p.maxLineBytes = o.maxLineBytes
+	p.maxTestBytes = o.maxTestBytes
+	if o.spillLines > 0 {
+		p.spillDir = o.spillDir
+		p.spillLines = o.spillLines
+		if p.spillLines < minSpillLines {
+			p.spillLines = minSpillLines
+		}
+	}
+	report, err := p.parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if p.spillErr != nil {
+		return report, p.spillErr
+	}
+
+	switch o.subtestMode {
+	case SubtestsExcludeParents:
+		report.ExcludeParents()
+	case SubtestsIgnoreParentResults:
+		report.IgnoreParentResults()
+	}
+	if err := report.TruncateOutput(o.maxLines, o.maxBytes); err != nil {
+		return report, err
+	}
+	return report, nil
+}
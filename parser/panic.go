@@ -0,0 +1,226 @@
+package parser
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// regexGoroutine matches the first line of a goroutine in a stack dump,
+	// e.g. "goroutine 7 [running]:".
+	regexGoroutine = regexp.MustCompile(`^goroutine \d+ .*\[.*\]:$`)
+	// regexTestFrame matches the frame of a test function, or a closure in
+	// it, in a goroutine dump and captures the name of the test, e.g.
+	// "package/name.TestA.func1(...)".
+	regexTestFrame = regexp.MustCompile(`^(?:[^\s(]*/)?[^\s/(]+?\.((?:Test|Benchmark|Fuzz|Example)[^.(]*)[.(]`)
+	// regexUnsafeFileChars matches characters that are replaced in the names
+	// of goroutine dump files.
+	regexUnsafeFileChars = regexp.MustCompile(`[^\w.-]`)
+)
+
+// TrimGoroutineDumps shortens the goroutine dumps printed when a test panics
+// to the panicking goroutine, which is printed first, and the goroutines
+// running the test, the other goroutines are replaced by a line saying how
+// many were removed. If dir is not empty, the complete output of each test
+// whose dump was shortened is written to a file in dir, which is attached to
+// the test. Spilled output is read back and its spill file removed.
+func (r *Report) TrimGoroutineDumps(dir string) error {
+	for _, pkg := range r.Packages {
+		for _, test := range pkg.Tests {
+			if err := test.trimGoroutineDump(pkg.Name, dir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *Test) trimGoroutineDump(pkgName, dir string) error {
+	lines, err := t.AllOutput()
+	if err != nil {
+		return err
+	}
+	trimmed, removed := trimGoroutines(lines, t.Name)
+	if removed == 0 {
+		return nil
+	}
+
+	if dir != "" {
+		name := regexUnsafeFileChars.ReplaceAllString(pkgName+"."+t.Name, "_")
+		f, err := ioutil.TempFile(dir, name+"-*.goroutines.txt")
+		if err != nil {
+			return err
+		}
+		_, err = f.WriteString(strings.Join(lines, "\n") + "\n")
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		t.Attachments = appendAttachments(t.Attachments, f.Name())
+	}
+
+	t.Output = trimmed
+	if t.SpillFile != "" {
+		err = os.Remove(t.SpillFile)
+		t.SpillFile = ""
+		t.SpilledLines = 0
+	}
+	return err
+}
+
+// trimGoroutines removes the goroutines of the goroutine dump in lines except
+// for the first one and those whose stack contains the function of the test
+// with the given name. It returns the remaining lines and the number of
+// removed goroutines.
+func trimGoroutines(lines []string, testName string) ([]string, int) {
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "panic: ") || strings.HasPrefix(line, "fatal error: ") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return lines, 0
+	}
+
+	// subtests run in closures of the top-level test function, e.g.
+	// "package/name.TestA.func1(...)"
+	top := strings.SplitN(testName, "/", 2)[0]
+	regexTestFunc := regexp.MustCompile(`\.` + regexp.QuoteMeta(top) + `[.(]`)
+
+	kept := append([]string{}, lines[:start]...)
+	first, removed, noteAt := true, 0, 0
+	for i := start; i < len(lines); {
+		if !regexGoroutine.MatchString(lines[i]) {
+			kept = append(kept, lines[i])
+			i++
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && isStackLine(lines, end) {
+			end++
+		}
+		keep := first
+		for _, line := range lines[i:end] {
+			keep = keep || regexTestFunc.MatchString(line)
+		}
+		first = false
+		if keep {
+			kept = append(kept, lines[i:end]...)
+		} else {
+			// drop the empty line separating it from the previous goroutine
+			if n := len(kept); n > start && kept[n-1] == "" {
+				kept = kept[:n-1]
+			}
+			if removed == 0 {
+				noteAt = len(kept)
+			}
+			removed++
+		}
+		i = end
+	}
+	if removed == 0 {
+		return lines, 0
+	}
+	note := []string{"", fmt.Sprintf("… %d goroutines trimmed …", removed)}
+	return append(kept[:noteAt], append(note, kept[noteAt:]...)...), removed
+}
+
+// isStackLine returns whether lines[i] is part of the stack of a goroutine
+// in a goroutine dump: a function, followed by its file and line indented by
+// a tab, or a note about elided frames.
+func isStackLine(lines []string, i int) bool {
+	line := lines[i]
+	if line == "" || regexGoroutine.MatchString(line) {
+		return false
+	}
+	return strings.HasPrefix(line, "\t") ||
+		strings.HasPrefix(line, "...") ||
+		(i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t"))
+}
+
+// panickedTest returns the name of the test that caused the panic in output,
+// the output of a package that failed without reporting a failed test. This is
+// the top-level test found in the stack of the panicking goroutine, or cur, the
+// last test that was reported, if it's the same test or one of its subtests or
+// if the stack doesn't contain a test. It returns an empty string if output
+// doesn't contain a panic or the test is unknown.
+func panickedTest(cur string, output []string) string {
+	start := -1
+	for i, line := range output {
+		if strings.HasPrefix(line, "panic: ") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+
+	// the panicking goroutine is printed first
+	name, inGoroutine := "", false
+	for _, line := range output[start:] {
+		if regexGoroutine.MatchString(line) {
+			if inGoroutine {
+				break
+			}
+			inGoroutine = true
+		} else if matches := regexTestFrame.FindStringSubmatch(line); inGoroutine && matches != nil {
+			name = matches[1]
+			break
+		}
+	}
+	if cur != "" && (name == "" || strings.SplitN(cur, "/", 2)[0] == name) {
+		return cur
+	}
+	return name
+}
+
+// markTimedOut marks the tests that were running when the test binary of the
+// current package timed out as errors of type timeout. Tests that haven't
+// been started in the output, because go test was run without -v, are added.
+// The timeout panic and goroutine dump are moved to the last of them if they
+// aren't part of the output of a test.
+func (p *lineParser) markTimedOut() {
+	if p.timeout == "" {
+		return
+	}
+	// without -v the panic is buffered
+	var dump []string
+	if findTest(p.tests, p.timeoutOutput) == nil {
+		buffer := p.buffers[p.timeoutOutput]
+		for i, line := range buffer {
+			if line == p.timeout {
+				dump = buffer[i:]
+				p.buffers[p.timeoutOutput] = buffer[:i]
+				break
+			}
+		}
+	}
+
+	for i, t := range p.timedOut {
+		test := findTest(p.tests, t.Name)
+		if test == nil {
+			test = &Test{Name: t.Name, Output: make([]string, 0)}
+			p.tests = append(p.tests, test)
+		}
+		test.Result = ERROR
+		test.ErrorType = "timeout"
+		test.Duration = t.Duration
+		test.Time = int(t.Duration / time.Millisecond) // deprecated
+		p.finished[test] = true
+		if i == len(p.timedOut)-1 && dump != nil {
+			p.appendOutput(test, dump...)
+		} else if test.Name != p.timeoutOutput {
+			// for the error message of tests without the panic
+			p.appendOutput(test, p.timeout)
+		}
+	}
+}
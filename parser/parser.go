@@ -4,8 +4,12 @@ import (
 	"bufio"
 	"io"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
+
+	"github.com/acarl005/stripansi"
 )
 
 // Result represents a test result.
@@ -21,264 +25,775 @@ const (
 
 // Report is a collection of package tests.
 type Report struct {
-	Packages []Package
+	Packages []Package `json:"packages"`
+
+	// Stderr contains the standard error output of the test command, when
+	// it was captured separately.
+	Stderr []string `json:"stderr,omitempty"`
 }
 
 // Package contains the test results of a single package.
 type Package struct {
-	Name        string
-	Duration    time.Duration
-	Tests       []*Test
-	CoveragePct string
+	Name        string        `json:"name"`
+	Duration    time.Duration `json:"-"`
+	Tests       []*Test       `json:"tests"`
+	CoveragePct string        `json:"coverage_pct,omitempty"`
+
+	// Coverage is the statement coverage percentage parsed from CoveragePct,
+	// it is only meaningful when CoveragePct is not empty.
+	Coverage float64 `json:"-"`
+
+	// Properties contains additional metadata for this package, formatters
+	// emit them as testsuite properties.
+	Properties []Property `json:"properties,omitempty"`
+
+	// Output contains the output of the package that isn't part of any test,
+	// such as output of TestMain or init functions.
+	Output []string `json:"output,omitempty"`
+
+	// Attachments are the paths of files attached to the package, see
+	// CollectAttachments.
+	Attachments []string `json:"attachments,omitempty"`
+
+	// BuildError is set if the tests of the package could not be run
+	// because building or setting up the test binary failed.
+	BuildError *BuildError `json:"build_error,omitempty"`
 
 	// Time is deprecated, use Duration instead.
-	Time int // in milliseconds
+	Time int `json:"-"` // in milliseconds
+}
+
+// BuildError describes why the test binary of a package could not be built or
+// set up.
+type BuildError struct {
+	// Name is the reason reported by go test, e.g. [build failed] or
+	// [setup failed].
+	Name   string   `json:"name"`
+	Output []string `json:"output"`
+
+	// Type classifies the error, it's "vet" if the build failed because
+	// of the go vet checks run by go test, and empty otherwise.
+	Type string `json:"type,omitempty"`
+}
+
+// AllTests returns the tests of the package followed by a test with result
+// ERROR for its build error, if any, for consumers that report build errors
+// like failed tests.
+func (p Package) AllTests() []*Test {
+	if p.BuildError == nil {
+		return p.Tests
+	}
+	tests := append([]*Test{}, p.Tests...)
+	return append(tests, &Test{
+		Name:      p.BuildError.Name,
+		Result:    ERROR,
+		Output:    p.BuildError.Output,
+		ErrorType: p.BuildError.Type,
+	})
+}
+
+// Property is a name/value pair of package metadata.
+type Property struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
 }
 
 // Test contains the results of a single test.
 type Test struct {
-	Name     string
-	Duration time.Duration
-	Result   Result
-	Output   []string
-
-	SubtestIndent int
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"-"`
+	Result   Result        `json:"result"`
+	Output   []string      `json:"output"`
+
+	SubtestIndent int `json:"-"`
+
+	// Incomplete is set for tests that were still running when the output
+	// ended: the test was started, but neither the test nor its package has
+	// a result in the output.
+	Incomplete bool `json:"incomplete,omitempty"`
+
+	// ErrorType classifies the error of tests with result ERROR, see
+	// BuildError.Type.
+	ErrorType string `json:"error_type,omitempty"`
+
+	// Quarantined is set for failed tests of a quarantine list of known
+	// flaky tests, which are reported as skipped. It's not set by the
+	// parser.
+	Quarantined bool `json:"quarantined,omitempty"`
+
+	// FailureIgnored is set for failed tests whose failure is known to be
+	// caused by the environment, which are reported as passed. It's not set
+	// by the parser.
+	FailureIgnored bool `json:"failure_ignored,omitempty"`
+
+	// Owner lists the owners of the test, separated by spaces, as found in
+	// a CODEOWNERS file. It's not set by the parser.
+	Owner string `json:"owner,omitempty"`
+
+	// Start and End are the times the test started and ended, and Paused
+	// is how long it was paused in between by t.Parallel, waiting for the
+	// sequential tests to finish. They're only known for tests parsed from
+	// go test -json output. See WallDuration and ActiveDuration.
+	Start  time.Time     `json:"-"`
+	End    time.Time     `json:"-"`
+	Paused time.Duration `json:"-"`
+
+	// CPU is the GOMAXPROCS value a benchmark was run with, see go test
+	// -cpu. It's 0 for tests.
+	CPU int `json:"cpu,omitempty"`
+
+	// File is the source file declaring the test, relative to the module
+	// root, if known. It's not set by the parser.
+	File string `json:"file,omitempty"`
+
+	// Attachments are the paths of files attached to the test, see
+	// CollectAttachments.
+	Attachments []string `json:"attachments,omitempty"`
+
+	// SpillFile is the name of a temporary file containing the first
+	// SpilledLines lines of output of the test, which precede the lines in
+	// Output. Output is only spilled to disk by ParseSpill, use
+	// EachOutputLine or AllOutput to read the complete output.
+	SpillFile    string `json:"-"`
+	SpilledLines int    `json:"-"`
+
+	// Parent is the test that ran this test as a subtest, or nil for top
+	// level tests and subtests whose parent is missing from the output.
+	// Subtests contains the direct subtests of this test, in the order they
+	// were started. Both are derived from the /-separated test names.
+	Parent   *Test   `json:"-"`
+	Subtests []*Test `json:"-"`
 
 	// Time is deprecated, use Duration instead.
-	Time int // in milliseconds
+	Time int `json:"-"` // in milliseconds
+}
+
+// WallDuration returns the time from the start to the end of the test, or
+// Duration if they aren't known. Unlike Duration, as reported by go test, it
+// includes the time the test was paused.
+func (t *Test) WallDuration() time.Duration {
+	if t.Start.IsZero() || t.End.Before(t.Start) {
+		return t.Duration
+	}
+	return t.End.Sub(t.Start)
 }
 
+// ActiveDuration returns the time the test was running, the time from its
+// start to its end without the time it was paused, or Duration if they
+// aren't known.
+func (t *Test) ActiveDuration() time.Duration {
+	if t.Start.IsZero() || t.End.Before(t.Start) || t.Paused > t.End.Sub(t.Start) {
+		return t.Duration
+	}
+	return t.End.Sub(t.Start) - t.Paused
+}
+
+// numberPattern matches a decimal number, allowing for the digit grouping
+// and decimal separators that are added when logs pass through localizing
+// post-processors. Use normalizeNumber to parse the matched text.
+const numberPattern = `\d+(?:[.,'\x{2019}\x{00A0}\x{202F}]\d+)*`
+
 var (
-	regexStatus   = regexp.MustCompile(`--- (PASS|FAIL|SKIP): ([^ ]+)(?: \((\d+\.\d+)(?: seconds|s)\))?`)
+	regexStatus   = regexp.MustCompile(`--- (PASS|FAIL|SKIP): ([^ ]+)(?:\s+\((` + numberPattern + `)\s*(?: seconds|s)\))?`)
 	regexIndent   = regexp.MustCompile(`^(    |\t)+---`)
-	regexCoverage = regexp.MustCompile(`^coverage:\s+(\d+\.\d+)%\s+of\s+statements(?:\sin\s.+)?$`)
-	regexResult   = regexp.MustCompile(`^(ok|FAIL|\?)\s+([^ ]+)\s+(?:(\d+\.\d+)s|\(cached\)|\[no test files\]|(\[\w+ failed]))(?:\s+coverage:\s+(\d+\.\d+)%\sof\sstatements(?:\sin\s.+)?)?$`)
-	// regexBenchmark captures 3-5 groups: benchmark name, number of times ran, ns/op (with or without decimal), B/op (optional), and allocs/op (optional).
-	regexBenchmark       = regexp.MustCompile(`^(Benchmark[^ -]+)(?:(?:-\d+\s+|\s+)(\d+)\s+(\d+|\d+\.\d+)\sns/op(?:\s+(\d+)\sB/op)?(?:\s+(\d+)\sallocs/op)?)?$`)
+	regexCoverage = regexp.MustCompile(`^coverage:\s+(` + numberPattern + `)\s*%\s+of\s+statements(?:\sin\s.+)?$`)
+	regexResult   = regexp.MustCompile(`^(ok|FAIL|\?)\s+(\S+)\s+(?:(` + numberPattern + `)\s*s|\(cached\)|\[no test files\]|(\[\w+ failed]))(?:\s+coverage:\s+(` + numberPattern + `)\s*%\sof\sstatements(?:\sin\s.+)?)?$`)
+	// regexBenchmark captures 4-6 groups: benchmark name, GOMAXPROCS (optional), number of times ran, ns/op (with or without decimal), B/op (optional), and allocs/op (optional).
+	regexBenchmark       = regexp.MustCompile(`^(Benchmark[^ -]+)(?:(?:-(\d+)\s+|\s+)(` + numberPattern + `)\s+(` + numberPattern + `)\s+ns/op(?:\s+(` + numberPattern + `)\s+B/op)?(?:\s+(` + numberPattern + `)\s+allocs/op)?)?$`)
 	regexLog             = regexp.MustCompile(`^(    |\t)+(.+\.go:\d+: .*)$`)
 	regexSummary         = regexp.MustCompile(`^(PASS|FAIL|SKIP)$`)
+	regexShuffle         = regexp.MustCompile(`^-test\.shuffle (-?\d+)$`)
 	regexPackageWithTest = regexp.MustCompile(`^([^\[\]]+) \[[^\]]+\]$`)
+	// regexTimeout matches the panic of a test binary that ran longer than
+	// the -timeout of go test, which is followed by the running tests, e.g.
+	// "\t\tTestA (10m0s)".
+	regexTimeout     = regexp.MustCompile(`^panic: test timed out after \S+$`)
+	regexRunningTest = regexp.MustCompile(`^\t\t(\S+) \(([^)]+)\)$`)
+	// regexVet matches the header go test prints before the output of the
+	// go vet checks of a package, e.g. "[package/name]".
+	regexVet = regexp.MustCompile(`^\[([^\[\]]+)\]$`)
 )
 
 // Parse parses go test output from reader r and returns a report with the
 // results. An optional pkgName can be given, which is used in case a package
 // result line is missing.
+//
+// Both the plain text output of `go test -v` and the JSON output of
+// `go test -json` are accepted, including a mix of both: lines containing a
+// test2json event are recognized as such and all other lines are parsed as
+// plain text.
 func Parse(r io.Reader, pkgName string) (*Report, error) {
+	return ParseWithOptions(r, WithPackageName(pkgName))
+}
+
+// parse parses all lines read from r and returns the report.
+func (p *lineParser) parse(r io.Reader) (*Report, error) {
 	reader := bufio.NewReader(r)
 
-	report := &Report{make([]Package, 0)}
+	// parse lines
+	for {
+		l, err := p.readLine(reader)
+		if err != nil && err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		p.parseLine(l)
+	}
+
+	return p.report(), nil
+}
+
+// lineParser contains the state of a running Parse.
+type lineParser struct {
+	pkgName  string
+	packages []Package
 
 	// keep track of tests we find
-	var tests []*Test
+	tests []*Test
 
 	// sum of tests' time, use this if current test has no result line (when it is compiled test)
-	var testsTime time.Duration
+	testsTime time.Duration
 
 	// current test
-	var cur string
+	cur string
+
+	// tests of the current package that have a result
+	finished map[*Test]bool
 
 	// coverage percentage report for current package
-	var coveragePct string
+	coveragePct string
+
+	// seed printed by go test -shuffle for the current package
+	shuffleSeed string
 
 	// stores mapping between package name and output of build failures
-	var packageCaptures = map[string][]string{}
+	packageCaptures map[string][]string
 
 	// the name of the package which it's build failure output is being captured
-	var capturedPackage string
+	capturedPackage string
+
+	// packages whose captured output contains go vet diagnostics
+	vetPackages map[string]bool
+
+	// the timeout panic of the current package, the name of the test or
+	// buffer whose output contains it and the tests it lists as running
+	timeout       string
+	timeoutOutput string
+	timedOut      []Test
+
+	// set while parsing the output of gopkg.in/check.v1 suites, which run
+	// in the test gocheckParent, see parseGocheckLine
+	inGocheck     bool
+	gocheckParent string
+
+	// the GoConvey story being parsed, and whether the previous line of
+	// plain text output was empty, see parseConveyLine
+	convey    *conveyStory
+	lastEmpty bool
 
 	// capture any non-test output
-	var buffers = map[string][]string{}
+	buffers map[string][]string
+
+	logContinuing bool
+
+	// set after the result of the current test if it's a top-level test,
+	// output that follows it is package output, unless it's a log line of
+	// the test
+	curFinished bool
+
+	// output of the last test2json event that was not terminated by a newline
+	partial string
+
+	// test and time of the last test2json run event, which precedes the
+	// === RUN line of the test
+	runTest  string
+	runStart time.Time
+
+	// times tests that didn't continue yet were paused, and the time of the
+	// test2json event whose output is being parsed, see pauseTest
+	pausedAt  map[*Test]time.Time
+	eventTime time.Time
+
+	// number of input lines parsed
+	line int
+
+	// format of the input, and whether ANSI escape codes are removed from
+	// the output
+	format      InputFormat
+	stripOutput bool
+
+	// lines that aren't test2json events with InputJSON
+	stderr []string
+
+	// limits of the length of input lines and the output of tests kept in
+	// memory, see WithMaxLineBytes and WithMaxTestBytes
+	maxLineBytes int
+	maxTestBytes int
+	capped       map[*Test]*cappedOutput
+
+	// output lines of tests beyond spillLines are spilled to files in
+	// spillDir, see ParseSpill
+	spillDir   string
+	spillLines int
+	spillErr   error
+
+	// receives the decisions made for each line, see ParseRecorded
+	recorder  func(Record)
+	decisions []Decision
+}
 
-	// parse lines
-	logContinuing := false
-	for {
-		l, _, err := reader.ReadLine()
-		if err != nil && err == io.EOF {
+func newLineParser(pkgName string) *lineParser {
+	return &lineParser{
+		pkgName:         pkgName,
+		packages:        make([]Package, 0),
+		packageCaptures: map[string][]string{},
+		vetPackages:     map[string]bool{},
+		capped:          map[*Test]*cappedOutput{},
+		pausedAt:        map[*Test]time.Time{},
+		finished:        map[*Test]bool{},
+		buffers:         map[string][]string{},
+	}
+}
+
+// parseLine parses a single line of input, which is either a test2json event
+// or a line of plain text output.
+func (p *lineParser) parseLine(l string) {
+	p.line++
+	defer p.record(Record{Line: p.line, Input: l})
+
+	line := strings.TrimSuffix(l, "\r")
+	if p.format != InputText {
+		if ev, ok := parseEvent(line); ok {
+			p.parseEvent(ev)
+			return
+		}
+	}
+	if p.format == InputJSON {
+		if p.stripOutput {
+			line = stripANSI(line)
+		}
+		p.stderr = append(p.stderr, line)
+		p.decide(Decision{Kind: "stderr", Text: line})
+		return
+	}
+	p.flushPartial()
+	p.parseTextLine(line)
+}
+
+// parseEvent handles a test2json event. The output contained in the event is
+// parsed as plain text, attributed to the test named in the event.
+func (p *lineParser) parseEvent(ev *event) {
+	switch ev.Action {
+	case "run":
+		p.runTest, p.runStart = ev.Test, ev.Time
+	case "pause":
+		p.pauseTest(ev.Test, ev.Time)
+	case "cont":
+		p.continueTest(ev.Test, ev.Time)
+	case "pass", "fail", "skip":
+		if test := findTest(p.tests, ev.Test); test != nil && ev.Test != "" {
+			test.End = ev.Time
+		}
+	}
+	if ev.Action != "output" && ev.Action != "build-output" {
+		return
+	}
+
+	output := p.partial + ev.Output
+	p.partial = ""
+	p.eventTime = ev.Time
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		if i == len(lines)-1 {
+			// either empty or an incomplete line
+			p.partial = line
 			break
-		} else if err != nil {
-			return nil, err
 		}
+		if ev.Test != "" && findTest(p.tests, ev.Test) != nil {
+			p.cur = ev.Test
+			p.curFinished = false
+		}
+		p.parseTextLine(strings.TrimSuffix(line, "\r"))
+	}
+	p.eventTime = time.Time{}
+}
+
+// pauseTest records that test name was paused by t.Parallel at t, if the time
+// is known. Versions of go test -json that have no pause events only print the
+// === PAUSE line.
+func (p *lineParser) pauseTest(name string, t time.Time) {
+	test := findTest(p.tests, name)
+	if test == nil || t.IsZero() {
+		return
+	}
+	if _, ok := p.pausedAt[test]; !ok {
+		p.pausedAt[test] = t
+	}
+}
+
+// continueTest adds the time test name was paused until t to its Paused time.
+// Other === CONT lines, printed when the output switches between parallel
+// tests, are ignored.
+func (p *lineParser) continueTest(name string, t time.Time) {
+	test := findTest(p.tests, name)
+	if test == nil || t.IsZero() {
+		return
+	}
+	if paused, ok := p.pausedAt[test]; ok {
+		test.Paused += t.Sub(paused)
+		delete(p.pausedAt, test)
+	}
+}
+
+// flushPartial parses any incomplete test2json output line that is pending.
+func (p *lineParser) flushPartial() {
+	if p.partial != "" {
+		line := p.partial
+		p.partial = ""
+		p.parseTextLine(line)
+	}
+}
 
-		line := string(l)
-
-		wasOutput := false
-		if strings.HasPrefix(line, "=== RUN ") {
-			// new test
-			cur = strings.TrimSpace(line[8:])
-			tests = append(tests, &Test{
-				Name:   cur,
-				Result: FAIL,
-				Output: make([]string, 0),
-			})
-
-			// clear the current build package, so output lines won't be added to that build
-			capturedPackage = ""
-		} else if matches := regexBenchmark.FindStringSubmatch(line); len(matches) > 0 {
-			if test := findTest(tests, cur); test != nil &&
-				len(test.Output) >= 3 &&
-				strings.HasPrefix(test.Output[len(test.Output)-3], "goos: ") &&
-				strings.HasPrefix(test.Output[len(test.Output)-2], "goarch: ") &&
-				strings.HasPrefix(test.Output[len(test.Output)-1], "pkg: ") {
-				// benchmarks header was interpreted as test output for the last test; discard it
-				test.Output = test.Output[:len(test.Output)-3]
+// parseTextLine parses a single line of plain text go test output.
+func (p *lineParser) parseTextLine(line string) {
+	if p.stripOutput {
+		line = stripANSI(line)
+	}
+	// lines are matched without ANSI escape codes, which tools like gotest
+	// and richgo use to colorize the output, and in a whitespace normalized
+	// form, output is kept as is
+	plain := stripANSI(line)
+	norm := normalizeSpace(plain)
+	afterEmpty := p.lastEmpty
+	p.lastEmpty = plain == ""
+
+	wasOutput := false
+	if strings.HasPrefix(plain, "=== RUN ") {
+		// new test
+		p.cur = strings.TrimSpace(plain[8:])
+		p.curFinished = false
+		test := &Test{
+			Name:   p.cur,
+			Result: FAIL,
+			Output: make([]string, 0),
+		}
+		if p.runTest == p.cur {
+			test.Start = p.runStart
+			p.runTest = ""
+		}
+		p.tests = append(p.tests, test)
+
+		// clear the current build package, so output lines won't be added to that build
+		p.capturedPackage = ""
+		p.decide(Decision{Kind: "run", Text: line, Test: p.cur})
+	} else if matches := regexBenchmark.FindStringSubmatch(norm); len(matches) > 0 {
+		if test := findTest(p.tests, p.cur); test != nil &&
+			len(test.Output) >= 3 &&
+			strings.HasPrefix(test.Output[len(test.Output)-3], "goos: ") &&
+			strings.HasPrefix(test.Output[len(test.Output)-2], "goarch: ") &&
+			strings.HasPrefix(test.Output[len(test.Output)-1], "pkg: ") {
+			// benchmarks header was interpreted as test output for the last test; discard it
+			test.Output = test.Output[:len(test.Output)-3]
+		}
+		//bytes, _ := strconv.Atoi(matches[5])
+		//allocs, _ := strconv.Atoi(matches[6])
+
+		cpu := 0
+		if matches[4] != "" {
+			// go test omits the suffix if GOMAXPROCS is 1
+			cpu = 1
+			if matches[2] != "" {
+				cpu, _ = strconv.Atoi(matches[2])
 			}
-			cur = matches[1]
-
-			//bytes, _ := strconv.Atoi(matches[4])
-			//allocs, _ := strconv.Atoi(matches[5])
-
-			test := findTest(tests, cur)
-			if test == nil {
-				// first execution of this benchmark
-				test = &Test{
-					Name:     cur,
-					Result:   PASS,
-					Duration: parseNanoseconds(matches[3]),
-					Output:   make([]string, 0),
-				}
-				tests = append(tests, test)
-			} else {
-				// repeated execution of the same benchmark with different N
-				test.Duration = parseNanoseconds(matches[3])
+		}
+		// repeated executions of the same benchmark with different N update
+		// its duration
+		test := p.benchmarkTest(matches[1], cpu)
+		test.Duration = parseNanoseconds(matches[4])
+		p.cur = test.Name
+		p.curFinished = false
+		if matches[4] != "" {
+			p.finished[test] = true
+		}
+		p.appendOutput(test, line)
+		p.decide(Decision{Kind: "benchmark", Text: line, Test: p.cur})
+	} else if strings.HasPrefix(plain, "=== PAUSE ") {
+		name := strings.TrimSpace(plain[9:])
+		p.pauseTest(name, p.eventTime)
+		p.decide(Decision{Kind: "pause", Text: line, Test: name})
+		return
+	} else if strings.HasPrefix(plain, "=== CONT ") {
+		p.cur = strings.TrimSpace(plain[8:])
+		p.curFinished = false
+		p.continueTest(p.cur, p.eventTime)
+		p.decide(Decision{Kind: "cont", Text: line, Test: p.cur})
+		return
+	} else if matches := regexResult.FindStringSubmatch(norm); len(matches) == 6 {
+		if matches[5] != "" {
+			p.coveragePct = normalizeNumber(matches[5])
+		}
+		p.markTimedOut()
+		var buildError *BuildError
+		if strings.HasSuffix(matches[4], "failed]") {
+			// the build of the package failed, the captured build output
+			// describes the error
+			buildError = &BuildError{
+				Name:   matches[4],
+				Output: p.packageCaptures[matches[2]],
 			}
-			test.Output = append(test.Output, line)
-		} else if strings.HasPrefix(line, "=== PAUSE ") {
-			continue
-		} else if strings.HasPrefix(line, "=== CONT ") {
-			cur = strings.TrimSpace(line[8:])
-			continue
-		} else if matches := regexResult.FindStringSubmatch(line); len(matches) == 6 {
-			if matches[5] != "" {
-				coveragePct = matches[5]
+			if p.vetPackages[matches[2]] {
+				buildError.Type = "vet"
 			}
-			if strings.HasSuffix(matches[4], "failed]") {
-				// the build of the package failed, inject a test error into the package
-				// which indicate about the error and contain the error description.
-				tests = append(tests, &Test{
-					Name:   matches[4],
-					Result: ERROR,
-					Output: packageCaptures[matches[2]],
-				})
-			} else if matches[1] == "FAIL" && !containsFailures(tests) && len(buffers[cur]) > 0 {
+		} else if matches[1] == "FAIL" && !containsFailures(p.tests) && len(p.buffers[p.cur]) > 0 {
+			if name := panickedTest(p.cur, p.buffers[p.cur]); name != "" {
+				// a test panicked, the test binary reports the panicking
+				// test as failed even without -v
+				test := findTest(p.tests, name)
+				if test == nil {
+					test = &Test{Name: name, Output: make([]string, 0)}
+					p.tests = append(p.tests, test)
+				}
+				test.Result = FAIL
+				p.finished[test] = true
+				p.appendOutput(test, p.buffers[p.cur]...)
+			} else {
 				// This package didn't have any failing tests, but still it
 				// failed with some output. Create a dummy test with the
 				// output.
-				tests = append(tests, &Test{
+				test := &Test{
 					Name:   "Error",
 					Result: ERROR,
-					Output: buffers[cur],
-				})
-				buffers[cur] = buffers[cur][0:0]
+					Output: p.buffers[p.cur],
+				}
+				p.tests = append(p.tests, test)
+				p.finished[test] = true
 			}
+			p.buffers[p.cur] = nil
+		}
+		p.markUnfinished()
+
+		// all tests in this package are finished
+		linkSubtests(p.tests)
+		p.packages = append(p.packages, Package{
+			Name:        matches[2],
+			Duration:    parseSeconds(matches[3]),
+			Tests:       p.tests,
+			CoveragePct: p.coveragePct,
+			Coverage:    parseCoverage(p.coveragePct),
+			Output:      p.buffers[""],
+			Properties:  p.shuffleProperties(),
+			BuildError:  buildError,
+
+			Time: int(parseSeconds(matches[3]) / time.Millisecond), // deprecated
+		})
 
-			// all tests in this package are finished
-			report.Packages = append(report.Packages, Package{
-				Name:        matches[2],
-				Duration:    parseSeconds(matches[3]),
-				Tests:       tests,
-				CoveragePct: coveragePct,
+		p.buffers[p.cur] = nil
+		p.buffers[""] = nil
+		p.tests = make([]*Test, 0)
+		p.finished = map[*Test]bool{}
+		p.coveragePct = ""
+		p.shuffleSeed = ""
+		p.timeout, p.timeoutOutput, p.timedOut = "", "", nil
+		p.cur = ""
+		p.curFinished = false
+		p.inGocheck, p.gocheckParent = false, ""
+		p.convey = nil
+		p.testsTime = 0
+		p.decide(Decision{Kind: "package", Text: line, Package: matches[2], Result: matches[1]})
+	} else if matches := regexStatus.FindStringSubmatch(norm); len(matches) == 4 {
+		p.cur = matches[2]
+		p.curFinished = !strings.Contains(p.cur, "/")
+		test := findTest(p.tests, p.cur)
+		if test == nil {
+			p.decide(Decision{Kind: "unknown-status", Text: line, Test: p.cur, Result: matches[1]})
+			return
+		}
+		p.decide(Decision{Kind: "status", Text: line, Test: p.cur, Result: matches[1]})
+		p.finished[test] = true
+
+		// test status
+		if matches[1] == "PASS" {
+			test.Result = PASS
+		} else if matches[1] == "SKIP" {
+			test.Result = SKIP
+		} else {
+			test.Result = FAIL
+		}
 
-				Time: int(parseSeconds(matches[3]) / time.Millisecond), // deprecated
-			})
+		if matches := regexIndent.FindStringSubmatch(plain); len(matches) == 2 {
+			test.SubtestIndent = countIndent(matches[1])
+		}
 
-			buffers[cur] = buffers[cur][0:0]
-			tests = make([]*Test, 0)
-			coveragePct = ""
+		p.appendOutput(test, p.buffers[p.cur]...)
+
+		test.Name = matches[2]
+		test.Duration = parseSeconds(matches[3])
+		p.testsTime += test.Duration
+
+		test.Time = int(test.Duration / time.Millisecond) // deprecated
+	} else if matches := regexCoverage.FindStringSubmatch(norm); len(matches) == 2 {
+		p.coveragePct = normalizeNumber(matches[1])
+		p.decide(Decision{Kind: "coverage", Text: line, Test: p.cur})
+	} else if matches := regexShuffle.FindStringSubmatch(norm); len(matches) == 2 {
+		p.shuffleSeed = matches[1]
+		p.decide(Decision{Kind: "shuffle", Text: line})
+	} else if p.parseGocheckLine(line, plain) {
+		return
+	} else if p.parseConveyLine(line, plain, afterEmpty) {
+		return
+	} else if strings.HasPrefix(plain, "# ") {
+		// indicates a capture of build output of a package. set the current build package.
+
+		line = strings.TrimPrefix(plain, "# ")
+		// when go test -cover is run, a build error looks different
+		// e.g.: "# cover package/name"
+		line = strings.TrimPrefix(line, "cover ")
+
+		packageWithTestBinary := regexPackageWithTest.FindStringSubmatch(line)
+		if vet := regexVet.FindStringSubmatch(line); vet != nil {
+			// the output of the go vet checks go test runs before the
+			// tests, e.g.: "# [package/name]"
+			p.capturedPackage = vet[1]
+			p.vetPackages[p.capturedPackage] = true
+			p.decide(Decision{Kind: "vet", Text: line, Package: p.capturedPackage})
+			return
+		} else if packageWithTestBinary != nil {
+			// Sometimes, the text after "# " shows the name of the test binary
+			// ("<package>.test") in addition to the package
+			// e.g.: "# package/name [package/name.test]"
+			p.capturedPackage = packageWithTestBinary[1]
+		} else {
+			p.capturedPackage = line
+		}
+		p.decide(Decision{Kind: "build", Text: line, Package: p.capturedPackage})
+	} else if p.capturedPackage != "" {
+		// current line is build failure capture for the current built package
+		p.packageCaptures[p.capturedPackage] = append(p.packageCaptures[p.capturedPackage], line)
+		p.decide(Decision{Kind: "build-output", Text: line, Package: p.capturedPackage})
+	} else if regexSummary.MatchString(norm) {
+		// unset current test name so any additional output after the
+		// summary is captured separately.
+		p.cur = ""
+		p.decide(Decision{Kind: "summary", Text: line, Result: norm})
+	} else {
+		// if we have a current test, append to its output
+		if strings.HasPrefix(plain, "panic: ") {
+			// a test that panics is reported as failed before the panic
+			p.curFinished = false
+		}
+		cur := p.cur
+		if p.curFinished && !regexLog.MatchString(plain) && !p.logContinuing {
+			// output after the result of a top-level test that isn't
+			// logged by it, e.g. of TestMain, belongs to the package
 			cur = ""
-			testsTime = 0
-		} else if matches := regexStatus.FindStringSubmatch(line); len(matches) == 4 {
-			cur = matches[2]
-			test := findTest(tests, cur)
-			if test == nil {
-				continue
-			}
-
-			// test status
-			if matches[1] == "PASS" {
-				test.Result = PASS
-			} else if matches[1] == "SKIP" {
-				test.Result = SKIP
-			} else {
-				test.Result = FAIL
-			}
-
-			if matches := regexIndent.FindStringSubmatch(line); len(matches) == 2 {
-				test.SubtestIndent = countIndent(matches[1])
-			}
+		}
+		test := findTest(p.tests, cur)
+		if regexTimeout.MatchString(plain) {
+			p.timeout, p.timeoutOutput, p.timedOut = plain, cur, nil
+		} else if matches := regexRunningTest.FindStringSubmatch(plain); matches != nil && p.timeout != "" {
+			duration, _ := time.ParseDuration(matches[2])
+			p.timedOut = append(p.timedOut, Test{Name: matches[1], Duration: duration})
+		}
 
-			test.Output = append(test.Output, buffers[cur]...)
-
-			test.Name = matches[2]
-			test.Duration = parseSeconds(matches[3])
-			testsTime += test.Duration
-
-			test.Time = int(test.Duration / time.Millisecond) // deprecated
-		} else if matches := regexCoverage.FindStringSubmatch(line); len(matches) == 2 {
-			coveragePct = matches[1]
-		} else if strings.HasPrefix(line, "# ") {
-			// indicates a capture of build output of a package. set the current build package.
-
-			line = strings.TrimPrefix(line, "# ")
-			// when go test -cover is run, a build error looks different
-			// e.g.: "# cover package/name"
-			line = strings.TrimPrefix(line, "cover ")
-
-			packageWithTestBinary := regexPackageWithTest.FindStringSubmatch(line)
-			if packageWithTestBinary != nil {
-				// Sometimes, the text after "# " shows the name of the test binary
-				// ("<package>.test") in addition to the package
-				// e.g.: "# package/name [package/name.test]"
-				capturedPackage = packageWithTestBinary[1]
-			} else {
-				capturedPackage = line
-			}
-		} else if capturedPackage != "" {
-			// current line is build failure capture for the current built package
-			packageCaptures[capturedPackage] = append(packageCaptures[capturedPackage], line)
-		} else if regexSummary.MatchString(line) {
-			// unset current test name so any additional output after the
-			// summary is captured separately.
-			cur = ""
+		if test != nil && regexLog.MatchString(plain) {
+			// strip the correct amount of indentation
+			line = stripIndent(line, test.SubtestIndent+1)
+			p.logContinuing = true
+		} else if p.logContinuing && countIndent(plain) >= test.SubtestIndent+2 {
+			// continuation of the previous log line
+			line = stripIndent(line, test.SubtestIndent+1)
 		} else {
-			// if we have a current test, append to its output
-			test := findTest(tests, cur)
-
-			if test != nil && regexLog.MatchString(line) {
-				// strip the correct amount of indentation
-				line = stripIndent(line, test.SubtestIndent+1)
-				logContinuing = true
-			} else if logContinuing && countIndent(line) >= test.SubtestIndent+2 {
-				// continuation of the previous log line
-				line = stripIndent(line, test.SubtestIndent+1)
-			} else {
-				logContinuing = false
-			}
-
-			if test != nil {
-				test.Output = append(test.Output, line)
-			} else {
-				// buffer anything else that we didn't recognize
-				buffers[cur] = append(buffers[cur], line)
-			}
-			wasOutput = true
+			p.logContinuing = false
 		}
-		if !wasOutput {
-			logContinuing = false
+
+		if test != nil {
+			p.appendOutput(test, line)
+			p.decide(Decision{Kind: "output", Text: line, Test: test.Name})
+		} else {
+			// buffer anything else that we didn't recognize
+			p.buffers[cur] = append(p.buffers[cur], line)
+			p.decide(Decision{Kind: "buffered", Text: line, Test: cur})
 		}
+		wasOutput = true
+	}
+	if !wasOutput {
+		p.logContinuing = false
 	}
+}
 
-	if len(tests) > 0 {
+// report returns the report containing all packages parsed so far.
+func (p *lineParser) report() *Report {
+	p.flushPartial()
+	p.record(Record{Line: p.line + 1, EOF: true})
+	p.finishCapped()
+
+	report := &Report{Packages: append([]Package{}, p.packages...), Stderr: p.stderr}
+	if len(p.tests) > 0 {
 		// no result line found
+		linkSubtests(p.tests)
+		p.markUnfinished()
+		for _, test := range p.tests {
+			test.Incomplete = !p.finished[test]
+		}
 		report.Packages = append(report.Packages, Package{
-			Name:        pkgName,
-			Duration:    testsTime,
-			Time:        int(testsTime / time.Millisecond),
-			Tests:       tests,
-			CoveragePct: coveragePct,
+			Name:        p.pkgName,
+			Duration:    p.testsTime,
+			Time:        int(p.testsTime / time.Millisecond),
+			Tests:       p.tests,
+			CoveragePct: p.coveragePct,
+			Coverage:    parseCoverage(p.coveragePct),
+			Output:      p.buffers[""],
+			Properties:  p.shuffleProperties(),
 		})
 	}
+	return report
+}
 
-	return report, nil
+// markUnfinished reports the tests of the current package that were started
+// but have no result, because the test binary was killed or exited early, as
+// errors of type incomplete. A test whose output contains a panic it caused,
+// e.g. in a goroutine it started, and its parents are reported as failed
+// instead.
+func (p *lineParser) markUnfinished() {
+	var panicked []string
+	for _, test := range p.tests {
+		if p.finished[test] {
+			continue
+		}
+		output, err := test.AllOutput()
+		if err != nil && p.spillErr == nil {
+			p.spillErr = err
+		}
+		if panickedTest(test.Name, output) == test.Name {
+			panicked = append(panicked, test.Name)
+		}
+	}
+	for _, test := range p.tests {
+		if p.finished[test] {
+			continue
+		}
+		test.Result = ERROR
+		test.ErrorType = "incomplete"
+		for _, name := range panicked {
+			if name == test.Name || strings.HasPrefix(name, test.Name+"/") {
+				test.Result = FAIL
+				test.ErrorType = ""
+				p.finished[test] = true
+				break
+			}
+		}
+	}
+}
+
+// shuffleProperties returns the go.test.shuffle property with the seed used
+// to shuffle the tests of the current package, if it was printed.
+func (p *lineParser) shuffleProperties() []Property {
+	if p.shuffleSeed == "" {
+		return nil
+	}
+	return []Property{{Name: "go.test.shuffle", Value: p.shuffleSeed}}
 }
 
 func parseSeconds(t string) time.Duration {
@@ -286,7 +801,7 @@ func parseSeconds(t string) time.Duration {
 		return time.Duration(0)
 	}
 	// ignore error
-	d, _ := time.ParseDuration(t + "s")
+	d, _ := time.ParseDuration(normalizeNumber(t) + "s")
 	return d
 }
 
@@ -296,10 +811,94 @@ func parseNanoseconds(t string) time.Duration {
 		return time.Duration(0)
 	}
 	// ignore error
-	d, _ := time.ParseDuration(t + "ns")
+	d, _ := time.ParseDuration(normalizeNumber(t) + "ns")
 	return d
 }
 
+func parseCoverage(pct string) float64 {
+	if pct == "" {
+		return 0
+	}
+	// ignore error
+	f, _ := strconv.ParseFloat(normalizeNumber(pct), 64)
+	return f
+}
+
+// normalizeNumber converts a number matched by numberPattern to the plain
+// notation understood by strconv and time.ParseDuration: digit grouping is
+// removed and the decimal separator becomes a dot. When both commas and dots
+// are present, the last one is the decimal separator. A single comma is only
+// treated as thousands separator when it looks like one, i.e. it follows one
+// to three digits that aren't all zero and precedes exactly three digits.
+func normalizeNumber(s string) string {
+	grouped := false
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '\'', '\u2019', '\u00A0', '\u202F', '_':
+			grouped = true
+			return -1
+		}
+		return r
+	}, s)
+
+	lastDot, lastComma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	switch {
+	case lastDot > -1 && lastComma > -1:
+		if lastDot > lastComma {
+			return strings.Replace(s, ",", "", -1)
+		}
+		s = strings.Replace(s, ".", "", -1)
+		return strings.Replace(s, ",", ".", -1)
+	case lastDot > -1:
+		if strings.Count(s, ".") > 1 {
+			return strings.Replace(s, ".", "", -1)
+		}
+		return s
+	case lastComma > -1:
+		if strings.Count(s, ",") > 1 {
+			return strings.Replace(s, ",", "", -1)
+		}
+		if !grouped && lastComma <= 3 && len(s)-lastComma-1 == 3 && strings.TrimLeft(s[:lastComma], "0") != "" {
+			return s[:lastComma] + s[lastComma+1:]
+		}
+		return strings.Replace(s, ",", ".", -1)
+	}
+	return s
+}
+
+// stripANSI removes ANSI escape codes from line.
+func stripANSI(line string) string {
+	if strings.IndexByte(line, '\x1b') < 0 {
+		return line
+	}
+	return stripansi.Strip(line)
+}
+
+// normalizeSpace trims trailing whitespace (such as the \r of CRLF line
+// endings) from line and replaces non-ASCII spaces by regular spaces, except
+// when they are used as digit grouping separator between two digits.
+func normalizeSpace(line string) string {
+	line = strings.TrimRightFunc(line, unicode.IsSpace)
+
+	runes := []rune(line)
+	changed := false
+	for i, r := range runes {
+		if r == ' ' || r == '\t' || !unicode.IsSpace(r) {
+			continue
+		}
+		if (r == '\u00A0' || r == '\u202F') && i > 0 && i < len(runes)-1 &&
+			unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1]) {
+			continue
+		}
+		runes[i] = ' '
+		changed = true
+	}
+	if !changed {
+		return line
+	}
+	return string(runes)
+}
+
 func findTest(tests []*Test, name string) *Test {
 	for i := len(tests) - 1; i >= 0; i-- {
 		if tests[i].Name == name {
@@ -323,16 +922,66 @@ func (r *Report) Failures() int {
 	count := 0
 
 	for _, p := range r.Packages {
-		for _, t := range p.Tests {
-			if t.Result == FAIL {
-				count++
-			}
-		}
+		count += p.Failures()
 	}
 
 	return count
 }
 
+// Counts returns the number of tests in the report and of its failed, errored
+// and skipped tests. Build errors of packages count as errored tests, see
+// Package.AllTests.
+func (r *Report) Counts() (tests, failures, errors, skipped int) {
+	for _, p := range r.Packages {
+		t, f, e, s := p.Counts()
+		tests += t
+		failures += f
+		errors += e
+		skipped += s
+	}
+	return tests, failures, errors, skipped
+}
+
+// TotalDuration returns the sum of the durations of all packages, the time
+// spent running tests. Packages may have been tested in parallel, so it can
+// be longer than the run took.
+func (r *Report) TotalDuration() time.Duration {
+	var d time.Duration
+	for _, p := range r.Packages {
+		d += p.Duration
+	}
+	return d
+}
+
+// Failures counts the number of failed tests in the package.
+func (p Package) Failures() int {
+	count := 0
+	for _, t := range p.Tests {
+		if t.Result == FAIL {
+			count++
+		}
+	}
+	return count
+}
+
+// Counts returns the number of tests in the package and of its failed,
+// errored and skipped tests. A build error counts as errored test, see
+// AllTests.
+func (p Package) Counts() (tests, failures, errors, skipped int) {
+	all := p.AllTests()
+	for _, t := range all {
+		switch t.Result {
+		case FAIL:
+			failures++
+		case ERROR:
+			errors++
+		case SKIP:
+			skipped++
+		}
+	}
+	return len(all), failures, errors, skipped
+}
+
 func countIndent(s string) int {
 	n := 0
 	for {
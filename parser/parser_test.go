@@ -1,6 +1,18 @@
 package parser
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 )
@@ -45,3 +57,1305 @@ func TestParseNanoseconds(t *testing.T) {
 		}
 	}
 }
+
+func TestNormalizeNumber(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"", ""},
+		{"0", "0"},
+		{"0.050", "0.050"},
+		{"0,050", "0.050"},
+		{"12,5", "12.5"},
+		{"1,234", "1234"},
+		{"1,234.5", "1234.5"},
+		{"1.234,5", "1234.5"},
+		{"1,234,567", "1234567"},
+		{"1.234.567", "1234567"},
+		{"1.234.567,89", "1234567.89"},
+		{"1'234'567", "1234567"},
+		{"1’234.5", "1234.5"},
+		{"1 234,5", "1234.5"},
+		{"1 234 567", "1234567"},
+		{"1_000", "1000"},
+	}
+
+	for _, test := range tests {
+		if out := normalizeNumber(test.in); out != test.out {
+			t.Errorf("normalizeNumber(%q) == %q, want %q", test.in, out, test.out)
+		}
+	}
+}
+
+func TestNormalizeNumberGenerated(t *testing.T) {
+	groupings := []string{"", ",", "'", " ", " "}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		n := rnd.Int63n(1e9)
+		frac := rnd.Intn(1000)
+		want := fmt.Sprintf("%d.%03d", n, frac)
+
+		for _, sep := range groupings {
+			// "123,456" is ambiguous and always read as thousands
+			decimal := "."
+			if sep != "," && n >= 1000 && rnd.Intn(2) == 0 {
+				decimal = ","
+			}
+			in := groupDigits(fmt.Sprint(n), sep) + decimal + fmt.Sprintf("%03d", frac)
+			if !regexp.MustCompile(`^` + numberPattern + `$`).MatchString(in) {
+				t.Fatalf("numberPattern does not match %q", in)
+			}
+			if out := normalizeNumber(in); out != want {
+				t.Fatalf("normalizeNumber(%q) == %q, want %q", in, out, want)
+			}
+		}
+	}
+}
+
+func groupDigits(s, sep string) string {
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + sep + s[i:]
+	}
+	return s
+}
+
+func TestNormalizeSpace(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"ok  \tpackage/name\t0.1s", "ok  \tpackage/name\t0.1s"},
+		{"PASS\r", "PASS"},
+		{"--- PASS: TestOne (0.02s)  \t", "--- PASS: TestOne (0.02s)"},
+		{"ok package/name  0.1s", "ok package/name  0.1s"},
+		{"BenchmarkOne  1 000 ns/op", "BenchmarkOne  1 000 ns/op"},
+	}
+
+	for _, test := range tests {
+		if out := normalizeSpace(test.in); out != test.out {
+			t.Errorf("normalizeSpace(%q) == %q, want %q", test.in, out, test.out)
+		}
+	}
+}
+
+func TestParseCoverage(t *testing.T) {
+	tests := []struct {
+		in  string
+		pct float64
+	}{
+		{"", 0},
+		{"0.0", 0},
+		{"13.37", 13.37},
+		{"100.0", 100},
+		{"12,5", 12.5},
+	}
+
+	for _, test := range tests {
+		if pct := parseCoverage(test.in); pct != test.pct {
+			t.Errorf("parseCoverage(%q) == %v, want %v", test.in, pct, test.pct)
+		}
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		in     string
+		ok     bool
+		action string
+	}{
+		{`{"Action":"output","Package":"pkg","Output":"ok\n"}`, true, "output"},
+		{`{"Time":"2020-01-01T00:00:00Z","Action":"pass","Test":"TestA","Elapsed":0.1}`, true, "pass"},
+		{`{"Action":"unknown"}`, false, ""},
+		{`{"key":"value"}`, false, ""},
+		{`{not json}`, false, ""},
+		{`=== RUN   TestA`, false, ""},
+	}
+
+	for _, test := range tests {
+		ev, ok := parseEvent(test.in)
+		if ok != test.ok {
+			t.Errorf("parseEvent(%q) ok == %v, want %v", test.in, ok, test.ok)
+			continue
+		}
+		if ok && ev.Action != test.action {
+			t.Errorf("parseEvent(%q).Action == %q, want %q", test.in, ev.Action, test.action)
+		}
+	}
+}
+
+func TestParseCoverFunc(t *testing.T) {
+	in := "example.com/pkg/file.go:12:\t\tFuncName\t\t85.7%\n" +
+		"example.com/pkg/file.go:20:\t\tother\t\t0.0%\n" +
+		"total:\t\t\t\t(statements)\t80.0%\n"
+
+	funcs, err := ParseCoverFunc(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FuncCoverage{
+		{"example.com/pkg/file.go", 12, "FuncName", 85.7},
+		{"example.com/pkg/file.go", 20, "other", 0},
+	}
+	if !reflect.DeepEqual(funcs, want) {
+		t.Errorf("ParseCoverFunc() == %v, want %v", funcs, want)
+	}
+}
+
+func TestParseRecorded(t *testing.T) {
+	in := "=== RUN   TestOne\n" +
+		"    one_test.go:10: message\n" +
+		`{"Action":"output","Package":"pkg","Test":"TestOne","Output":"--- PASS: TestOne (0.01s)\n"}` + "\n" +
+		`{"Action":"pass","Package":"pkg","Test":"TestOne"}` + "\n" +
+		"ok  \tpkg\t0.02s\n" +
+		"trailing"
+
+	var records []Record
+	report, err := ParseRecorded(strings.NewReader(in), "", func(r Record) {
+		records = append(records, r)
+	})
+	if err != nil {
+		t.Fatalf("ParseRecorded: %s", err)
+	}
+	if len(report.Packages) != 1 || len(report.Packages[0].Tests) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	expected := [][]string{
+		{"run"},
+		{"output"},
+		{"status"},
+		nil,
+		{"package"},
+		{"buffered"},
+		nil,
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("got %d records, want %d: %+v", len(records), len(expected), records)
+	}
+	for i, rec := range records {
+		if rec.Line != i+1 {
+			t.Errorf("record %d: Line = %d, want %d", i, rec.Line, i+1)
+		}
+		var kinds []string
+		for _, d := range rec.Decisions {
+			kinds = append(kinds, d.Kind)
+		}
+		if !reflect.DeepEqual(kinds, expected[i]) {
+			t.Errorf("record %d (%q): decisions %v, want %v", i, rec.Input, kinds, expected[i])
+		}
+	}
+	if !records[len(records)-1].EOF {
+		t.Errorf("last record is not marked EOF")
+	}
+	if d := records[4].Decisions[0]; d.Package != "pkg" || d.Result != "ok" {
+		t.Errorf("package decision = %+v", d)
+	}
+}
+
+func TestFilterPackages(t *testing.T) {
+	names := []string{
+		"example.com/app",
+		"example.com/app/internal/gen",
+		"example.com/app/vendor/lib",
+		"example.com/tool",
+	}
+	tests := []struct {
+		include, exclude []string
+		want             []string
+	}{
+		{nil, nil, names},
+		{[]string{"example.com/app"}, nil, names[:1]},
+		{[]string{"example.com/*"}, nil, []string{names[0], names[3]}},
+		{[]string{"example.com/app/**"}, nil, names[1:3]},
+		{nil, []string{"**/vendor/**", "**/gen"}, []string{names[0], names[3]}},
+		{[]string{"example.com/app**"}, []string{"*/app/[iv]*/*"}, names[:1]},
+		{[]string{"example.com/t??l"}, nil, names[3:]},
+	}
+	for _, test := range tests {
+		report := &Report{}
+		for _, name := range names {
+			report.Packages = append(report.Packages, Package{Name: name})
+		}
+		if err := report.FilterPackages(test.include, test.exclude); err != nil {
+			t.Fatalf("FilterPackages(%v, %v): %s", test.include, test.exclude, err)
+		}
+		var got []string
+		for _, pkg := range report.Packages {
+			got = append(got, pkg.Name)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("FilterPackages(%v, %v) kept %v, want %v", test.include, test.exclude, got, test.want)
+		}
+	}
+}
+
+func TestFilterTests(t *testing.T) {
+	report := &Report{Packages: []Package{
+		{Name: "a", Tests: []*Test{{Name: "TestOne"}, {Name: "TestTwo"}}},
+		{Name: "b", Tests: []*Test{{Name: "TestThree"}}},
+	}}
+	report.FilterTests(func(test *Test) bool {
+		return strings.Contains(test.Name, "T")
+	})
+	report.FilterTests(func(test *Test) bool {
+		return test.Name != "TestTwo"
+	})
+
+	if len(report.Packages) != 2 {
+		t.Fatalf("FilterTests removed packages: %+v", report.Packages)
+	}
+	if tests := report.Packages[0].Tests; len(tests) != 1 || tests[0].Name != "TestOne" {
+		t.Errorf("package a tests == %+v, want only TestOne", tests)
+	}
+	if tests := report.Packages[1].Tests; len(tests) != 1 {
+		t.Errorf("package b tests == %+v, want TestThree", tests)
+	}
+}
+
+func TestRename(t *testing.T) {
+	report := &Report{Packages: []Package{
+		{Name: "github.com/company/app", Tests: []*Test{{Name: "TestOne"}, {Name: "TestOne/sub"}}},
+	}}
+	report.Rename(func(name string) string {
+		return strings.TrimPrefix(strings.Replace(name, "One", "1", -1), "github.com/company/")
+	})
+
+	if name := report.Packages[0].Name; name != "app" {
+		t.Errorf("package name == %q, want %q", name, "app")
+	}
+	for i, want := range []string{"Test1", "Test1/sub"} {
+		if name := report.Packages[0].Tests[i].Name; name != want {
+			t.Errorf("test %d name == %q, want %q", i, name, want)
+		}
+	}
+}
+
+func TestTrimPackagePrefix(t *testing.T) {
+	report := &Report{Packages: []Package{{Name: "github.com/company/repo/pkg/a"}, {Name: "github.com/company/repo"}, {Name: "example.com/b"}}}
+	report.TrimPackagePrefix("github.com/company/repo/")
+
+	for i, want := range []string{"pkg/a", "github.com/company/repo", "example.com/b"} {
+		if name := report.Packages[i].Name; name != want {
+			t.Errorf("package %d name == %q, want %q", i, name, want)
+		}
+	}
+}
+
+func TestSubtestTree(t *testing.T) {
+	in := `=== RUN   TestA
+=== RUN   TestA/b
+=== RUN   TestA/b/c
+=== RUN   TestA/d
+--- PASS: TestA (0.00s)
+    --- PASS: TestA/b (0.00s)
+        --- PASS: TestA/b/c (0.00s)
+    --- PASS: TestA/d (0.00s)
+=== RUN   TestA
+=== RUN   TestA/b
+--- PASS: TestA (0.00s)
+    --- PASS: TestA/b (0.00s)
+=== RUN   TestE/f
+--- PASS: TestE/f (0.00s)
+ok  	pkg	0.01s
+`
+	report, err := Parse(strings.NewReader(in), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := report.Packages[0]
+	tests := pkg.Tests
+
+	tree := map[int][]int{0: {1, 3}, 1: {2}, 4: {5}}
+	for i, test := range tests {
+		var subtests []int
+		for _, sub := range test.Subtests {
+			for j := range tests {
+				if tests[j] == sub {
+					subtests = append(subtests, j)
+				}
+			}
+		}
+		if !reflect.DeepEqual(subtests, tree[i]) {
+			t.Errorf("test %d (%s) subtests == %v, want %v", i, test.Name, subtests, tree[i])
+		}
+		for _, sub := range test.Subtests {
+			if sub.Parent != test {
+				t.Errorf("parent of %s is not %s", sub.Name, test.Name)
+			}
+		}
+	}
+
+	if d := tests[2].Depth(); d != 2 {
+		t.Errorf("TestA/b/c depth == %d, want 2", d)
+	}
+	if top := pkg.TopLevelTests(); len(top) != 3 || top[0] != tests[0] || top[1] != tests[4] || top[2] != tests[6] {
+		t.Errorf("TopLevelTests == %v", top)
+	}
+
+	report.FilterTests(func(test *Test) bool { return test.Name != "TestA/b" })
+	if c := report.Packages[0].Tests[1]; c.Name != "TestA/b/c" || c.Parent != tests[0] {
+		t.Errorf("after filtering TestA/b, TestA/b/c parent == %v, want TestA", c.Parent)
+	}
+}
+
+func TestSubtestModes(t *testing.T) {
+	newReport := func() *Report {
+		tests := []*Test{
+			{Name: "TestA", Result: FAIL},
+			{Name: "TestA/b", Result: FAIL},
+			{Name: "TestA/c", Result: PASS},
+			{Name: "TestD", Result: FAIL},
+			{Name: "TestE", Result: SKIP},
+			{Name: "TestE/f", Result: SKIP},
+		}
+		linkSubtests(tests)
+		return &Report{Packages: []Package{{Name: "pkg", Tests: tests}}}
+	}
+
+	report := newReport()
+	report.ExcludeParents()
+	var names []string
+	for _, test := range report.Packages[0].Tests {
+		names = append(names, test.Name)
+	}
+	if want := []string{"TestA/b", "TestA/c", "TestD", "TestE/f"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ExcludeParents kept %v, want %v", names, want)
+	}
+
+	report = newReport()
+	report.IgnoreParentResults()
+	var results []Result
+	for _, test := range report.Packages[0].Tests {
+		results = append(results, test.Result)
+	}
+	if want := []Result{PASS, FAIL, PASS, FAIL, SKIP, SKIP}; !reflect.DeepEqual(results, want) {
+		t.Errorf("IgnoreParentResults results == %v, want %v", results, want)
+	}
+	if report.Failures() != 2 {
+		t.Errorf("Failures() == %d, want 2", report.Failures())
+	}
+}
+
+func TestJSON(t *testing.T) {
+	report := &Report{
+		Packages: []Package{{
+			Name:        "pkg",
+			Duration:    1500 * time.Millisecond,
+			CoveragePct: "12.5",
+			Properties:  []Property{{Name: "a", Value: "b"}},
+			Tests: []*Test{
+				{Name: "TestA", Duration: 10 * time.Millisecond, Result: FAIL, Output: []string{"out"}},
+				{Name: "TestA/b", Duration: 0, Result: ERROR, Output: []string{}},
+				{Name: "TestC", Duration: 2 * time.Millisecond, Result: SKIP, Output: []string{}, Start: time.Date(2026, 10, 17, 7, 25, 47, 5e8, time.UTC)},
+			},
+		}},
+		Stderr: []string{"err"},
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"packages":[{"name":"pkg","tests":[` +
+		`{"name":"TestA","result":"fail","output":["out"],"duration":0.01},` +
+		`{"name":"TestA/b","result":"error","output":[],"duration":0},` +
+		`{"name":"TestC","result":"skip","output":[],"duration":0.002,"start":"2026-10-17T07:25:47.5Z"}],` +
+		`"coverage_pct":"12.5","properties":[{"name":"a","value":"b"}],"duration":1.5}],"stderr":["err"]}`
+	if string(data) != expected {
+		t.Errorf("json.Marshal:\n got %s\nwant %s", data, expected)
+	}
+
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	pkg := decoded.Packages[0]
+	if pkg.Duration != report.Packages[0].Duration || pkg.Time != 1500 || pkg.Coverage != 12.5 {
+		t.Errorf("decoded package == %+v", pkg)
+	}
+	for i, test := range pkg.Tests {
+		want := report.Packages[0].Tests[i]
+		if test.Name != want.Name || test.Duration != want.Duration || test.Result != want.Result || !reflect.DeepEqual(test.Output, want.Output) || !test.Start.Equal(want.Start) {
+			t.Errorf("decoded test %d == %+v, want %+v", i, test, want)
+		}
+	}
+	if pkg.Tests[1].Parent != pkg.Tests[0] {
+		t.Errorf("decoded subtest is not linked to its parent")
+	}
+
+	if err := json.Unmarshal([]byte(`{"name":"T","result":"unknown"}`), &Test{}); err == nil {
+		t.Errorf("decoding an invalid result did not return an error")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a := &Report{
+		Packages: []Package{
+			{Name: "p1", Duration: time.Second, CoveragePct: "50.0", Coverage: 50, Properties: []Property{{"k", "v"}}, Tests: []*Test{
+				{Name: "TestA", Duration: time.Millisecond, Result: PASS, Output: []string{"a1"}},
+				{Name: "TestA/b", Result: PASS},
+				{Name: "TestC", Result: SKIP},
+				{Name: "TestC", Result: PASS},
+			}},
+		},
+		Stderr: []string{"err a"},
+	}
+	b := &Report{
+		Packages: []Package{
+			{Name: "p2", Tests: []*Test{{Name: "TestD", Result: PASS}}},
+			{Name: "p1", Duration: 2 * time.Second, CoveragePct: "75.5", Coverage: 75.5, Properties: []Property{{"k", "v"}, {"k", "w"}}, Tests: []*Test{
+				{Name: "TestA", Duration: 2 * time.Millisecond, Result: FAIL, Output: []string{"a2"}},
+				{Name: "TestC", Result: PASS},
+				{Name: "TestC", Result: SKIP},
+				{Name: "TestE", Result: ERROR},
+			}},
+		},
+		Stderr: []string{"err b"},
+	}
+
+	merged := Merge(a, nil, b)
+
+	if len(merged.Packages) != 2 || merged.Packages[0].Name != "p1" || merged.Packages[1].Name != "p2" {
+		t.Fatalf("merged packages == %+v", merged.Packages)
+	}
+	p1 := merged.Packages[0]
+	if p1.Duration != 3*time.Second || p1.Time != 3000 || p1.CoveragePct != "75.5" || p1.Coverage != 75.5 {
+		t.Errorf("merged package == %+v", p1)
+	}
+	if want := []Property{{"k", "v"}, {"k", "w"}}; !reflect.DeepEqual(p1.Properties, want) {
+		t.Errorf("merged properties == %v, want %v", p1.Properties, want)
+	}
+
+	expected := []struct {
+		name     string
+		result   Result
+		duration time.Duration
+		output   []string
+	}{
+		{"TestA", FAIL, 3 * time.Millisecond, []string{"a1", "a2"}},
+		{"TestA/b", PASS, 0, nil},
+		{"TestC", PASS, 0, nil},
+		{"TestC", PASS, 0, nil},
+		{"TestE", ERROR, 0, nil},
+	}
+	if len(p1.Tests) != len(expected) {
+		t.Fatalf("merged tests == %d, want %d", len(p1.Tests), len(expected))
+	}
+	for i, want := range expected {
+		test := p1.Tests[i]
+		if test.Name != want.name || test.Result != want.result || test.Duration != want.duration || strings.Join(test.Output, "\n") != strings.Join(want.output, "\n") {
+			t.Errorf("merged test %d == %+v, want %+v", i, test, want)
+		}
+	}
+	if p1.Tests[1].Parent != p1.Tests[0] {
+		t.Errorf("merged subtest is not linked to its parent")
+	}
+	if !reflect.DeepEqual(merged.Stderr, []string{"err a", "err b"}) {
+		t.Errorf("merged stderr == %v", merged.Stderr)
+	}
+
+	// the input reports are not modified
+	if test := a.Packages[0].Tests[0]; test.Result != PASS || len(test.Output) != 1 || test.Duration != time.Millisecond {
+		t.Errorf("Merge modified input test: %+v", test)
+	}
+}
+
+func TestMergeKeepsFields(t *testing.T) {
+	test := &Test{
+		Name:           "TestA",
+		Duration:       time.Second,
+		Result:         ERROR,
+		Output:         []string{"out"},
+		SubtestIndent:  1,
+		Incomplete:     true,
+		ErrorType:      "timeout",
+		Quarantined:    true,
+		FailureIgnored: true,
+		Owner:          "@org/team",
+		Start:          time.Unix(1, 0),
+		End:            time.Unix(2, 0),
+		Paused:         time.Millisecond,
+		CPU:            4,
+		File:           "a_test.go",
+		Attachments:    []string{"a.png"},
+		SpillFile:      "spill",
+		SpilledLines:   1,
+		Parent:         &Test{},
+		Subtests:       []*Test{{}},
+		Time:           1000,
+	}
+	// every field is set above, so that fields added to Test are covered
+	v := reflect.ValueOf(*test)
+	for i := 0; i < v.NumField(); i++ {
+		if reflect.DeepEqual(v.Field(i).Interface(), reflect.Zero(v.Field(i).Type()).Interface()) {
+			t.Fatalf("field %s is not set in the test of TestMergeKeepsFields", v.Type().Field(i).Name)
+		}
+	}
+
+	merged := Merge(&Report{Packages: []Package{{Name: "p", Tests: []*Test{test}}}})
+	got := *merged.Packages[0].Tests[0]
+	want := *test
+	want.Parent, want.Subtests = nil, nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("merged test == %+v, want %+v", got, want)
+	}
+
+	// the worst result is kept with its error type and flags
+	other := &Test{Name: "TestA", Result: FAIL}
+	merged = Merge(&Report{Packages: []Package{{Name: "p", Tests: []*Test{other}}}},
+		&Report{Packages: []Package{{Name: "p", Tests: []*Test{test}}}})
+	if got := merged.Packages[0].Tests[0]; got.Result != ERROR || got.ErrorType != "timeout" || !got.Incomplete || got.Owner != "@org/team" {
+		t.Errorf("merged test == %+v, want the result, error type and owner of the error", got)
+	}
+}
+
+func TestParseSpill(t *testing.T) {
+	var in bytes.Buffer
+	in.WriteString("=== RUN   TestLoud\n")
+	for i := 0; i < 25; i++ {
+		fmt.Fprintf(&in, "    loud_test.go:10: line %d\n", i)
+	}
+	in.WriteString("--- FAIL: TestLoud (0.01s)\n=== RUN   TestQuiet\n--- PASS: TestQuiet (0.00s)\nFAIL\nFAIL\tpkg\t0.02s\n")
+
+	dir, err := ioutil.TempDir("", "spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	report, err := ParseSpill(bytes.NewReader(in.Bytes()), "", dir, 4)
+	if err != nil {
+		t.Fatalf("ParseSpill: %s", err)
+	}
+	expected, err := Parse(bytes.NewReader(in.Bytes()), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loud := report.Packages[0].Tests[0]
+	if loud.SpillFile == "" || filepath.Dir(loud.SpillFile) != dir {
+		t.Fatalf("output of TestLoud was not spilled to %s: %+v", dir, loud)
+	}
+	if len(loud.Output) >= 8 || loud.SpilledLines+len(loud.Output) != 25 {
+		t.Errorf("TestLoud has %d lines in memory and %d spilled", len(loud.Output), loud.SpilledLines)
+	}
+	all, err := loud.AllOutput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(all, expected.Packages[0].Tests[0].Output) {
+		t.Errorf("AllOutput == %q, want %q", all, expected.Packages[0].Tests[0].Output)
+	}
+	if quiet := report.Packages[0].Tests[1]; quiet.SpillFile != "" {
+		t.Errorf("output of TestQuiet was spilled")
+	}
+
+	if err := report.RemoveSpillFiles(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("RemoveSpillFiles left %d files", len(files))
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	in := `=== RUN   TestDone
+--- PASS: TestDone (0.01s)
+ok  	pkg/done	0.01s
+=== RUN   TestFinished
+--- PASS: TestFinished (0.00s)
+=== RUN   TestRunning
+=== RUN   TestRunning/sub
+    --- PASS: TestRunning/sub (0.00s)
+`
+	report, err := Parse(strings.NewReader(in), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	incomplete := map[string]bool{}
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.Tests {
+			incomplete[test.Name] = test.Incomplete
+		}
+	}
+	want := map[string]bool{
+		"TestDone":        false,
+		"TestFinished":    false,
+		"TestRunning":     true,
+		"TestRunning/sub": false,
+	}
+	if !reflect.DeepEqual(incomplete, want) {
+		t.Errorf("Incomplete == %v, want %v", incomplete, want)
+	}
+}
+
+func TestUnfinished(t *testing.T) {
+	in := `=== RUN   TestDone
+--- PASS: TestDone (0.01s)
+=== RUN   TestKilled
+=== RUN   TestKilled/sub
+    --- PASS: TestKilled/sub (0.00s)
+signal: killed
+FAIL	pkg/killed	0.05s
+=== RUN   TestRunning
+`
+	report, err := Parse(strings.NewReader(in), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		Result    Result
+		ErrorType string
+	}
+	results := map[string]result{}
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.AllTests() {
+			results[test.Name] = result{test.Result, test.ErrorType}
+		}
+	}
+	want := map[string]result{
+		"TestDone":       {PASS, ""},
+		"TestKilled":     {ERROR, "incomplete"},
+		"TestKilled/sub": {PASS, ""},
+		"TestRunning":    {ERROR, "incomplete"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results == %v, want %v", results, want)
+	}
+	if _, _, errors, _ := report.Packages[0].Counts(); errors != 1 {
+		t.Errorf("errors of %s == %d, want 1", report.Packages[0].Name, errors)
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	lines := func(n int) []string {
+		var out []string
+		for i := 1; i <= n; i++ {
+			out = append(out, fmt.Sprintf("line %d", i))
+		}
+		return out
+	}
+	tests := []struct {
+		output             []string
+		maxLines, maxBytes int
+		want               []string
+	}{
+		{lines(4), 4, 0, lines(4)},
+		{lines(10), 4, 0, []string{"line 1", "line 2", "… 6 lines truncated …", "line 9", "line 10"}},
+		{lines(10), 5, 0, []string{"line 1", "line 2", "… 5 lines truncated …", "line 8", "line 9", "line 10"}},
+		{lines(10), 0, 30, []string{"line 1", "line 2", "… 6 lines truncated …", "line 9", "line 10"}},
+		{[]string{strings.Repeat("x", 1000)}, 0, 200, []string{strings.Repeat("x", 70) + " … 930 bytes truncated …"}},
+	}
+	for _, test := range tests {
+		report := &Report{Packages: []Package{{Tests: []*Test{{Name: "TestOne", Output: test.output}}}}}
+		if err := report.TruncateOutput(test.maxLines, test.maxBytes); err != nil {
+			t.Fatal(err)
+		}
+		if got := report.Packages[0].Tests[0].Output; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("TruncateOutput(%d, %d) of %q == %q, want %q", test.maxLines, test.maxBytes, test.output, got, test.want)
+		}
+	}
+}
+
+func TestTruncateSpilledOutput(t *testing.T) {
+	var in bytes.Buffer
+	in.WriteString("=== RUN   TestLoud\n")
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&in, "    loud_test.go:10: line %d\n", i)
+	}
+	in.WriteString("--- FAIL: TestLoud (0.01s)\nFAIL\tpkg\t0.02s\n")
+
+	dir, err := ioutil.TempDir("", "spill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	report, err := ParseSpill(&in, "", dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.TruncateOutput(4, 0); err != nil {
+		t.Fatal(err)
+	}
+	test := report.Packages[0].Tests[0]
+	want := []string{"loud_test.go:10: line 1", "loud_test.go:10: line 2", "… 96 lines truncated …", "loud_test.go:10: line 99", "loud_test.go:10: line 100"}
+	if !reflect.DeepEqual(test.Output, want) {
+		t.Errorf("Output == %q, want %q", test.Output, want)
+	}
+	if test.SpillFile != "" {
+		t.Errorf("SpillFile == %q after truncation", test.SpillFile)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("truncation left %d spill files", len(files))
+	}
+}
+
+func TestTrimGoroutineDumps(t *testing.T) {
+	output := []string{
+		"panic_test.go:12: before",
+		"panic: boom [recovered]",
+		"\tpanic: boom",
+		"",
+		"goroutine 7 [running]:",
+		"testing.tRunner.func1.2({0x6b6f60, 0x6ef0e0})",
+		"\t/usr/local/go/src/testing/testing.go:2123 +0x232",
+		"panic({0x6b6f60?, 0x6ef0e0?})",
+		"\t/usr/local/go/src/runtime/panic.go:859 +0x125",
+		"",
+		"goroutine 1 [chan receive]:",
+		"testing.(*T).Run(0xc000007a00, {0x5eb2e1, 0x9}, 0x60e8b0)",
+		"\t/usr/local/go/src/testing/testing.go:1751 +0x3ab",
+		"",
+		"goroutine 8 [sleep]:",
+		"time.Sleep(0x34630b8a000)",
+		"\t/usr/local/go/src/runtime/time.go:338 +0x165",
+		"example.com/pkg.TestPanic.func1()",
+		"\t/src/panic_test.go:9 +0x25",
+		"created by example.com/pkg.TestPanic in goroutine 7",
+		"\t/src/panic_test.go:9 +0x1a",
+		"",
+		"goroutine 9 [select]:",
+		"example.com/pkg.worker()",
+		"\t/src/worker.go:20 +0x45",
+		"...additional frames elided...",
+		"exit status 2",
+	}
+	want := []string{
+		"panic_test.go:12: before",
+		"panic: boom [recovered]",
+		"\tpanic: boom",
+		"",
+		"goroutine 7 [running]:",
+		"testing.tRunner.func1.2({0x6b6f60, 0x6ef0e0})",
+		"\t/usr/local/go/src/testing/testing.go:2123 +0x232",
+		"panic({0x6b6f60?, 0x6ef0e0?})",
+		"\t/usr/local/go/src/runtime/panic.go:859 +0x125",
+		"",
+		"… 2 goroutines trimmed …",
+		"",
+		"goroutine 8 [sleep]:",
+		"time.Sleep(0x34630b8a000)",
+		"\t/usr/local/go/src/runtime/time.go:338 +0x165",
+		"example.com/pkg.TestPanic.func1()",
+		"\t/src/panic_test.go:9 +0x25",
+		"created by example.com/pkg.TestPanic in goroutine 7",
+		"\t/src/panic_test.go:9 +0x1a",
+		"exit status 2",
+	}
+
+	dir, err := ioutil.TempDir("", "goroutines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	test := &Test{Name: "TestPanic/sub", Result: FAIL, Output: output}
+	other := &Test{Name: "TestOther", Result: PASS, Output: []string{"no panic"}}
+	report := &Report{Packages: []Package{{Name: "example.com/pkg", Tests: []*Test{test, other}}}}
+	if err := report.TrimGoroutineDumps(dir); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(test.Output, want) {
+		t.Errorf("Output ==\n%s\nwant\n%s", strings.Join(test.Output, "\n"), strings.Join(want, "\n"))
+	}
+	if len(test.Attachments) != 1 || len(other.Attachments) != 0 {
+		t.Fatalf("Attachments == %q and %q, want the full output attached to the panicked test", test.Attachments, other.Attachments)
+	}
+	full, err := ioutil.ReadFile(test.Attachments[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(full) != strings.Join(output, "\n")+"\n" {
+		t.Errorf("attached output ==\n%s", full)
+	}
+}
+
+func TestPanickedTest(t *testing.T) {
+	dump := []string{
+		"panic: boom",
+		"",
+		"goroutine 7 [running]:",
+		"gopkg.in/yaml.v3.TestDecode.func1({0x6b6f60})",
+		"\t/src/decode_test.go:14 +0x69",
+		"",
+		"goroutine 1 [chan receive]:",
+		"gopkg.in/yaml.v3.TestOther(0xc000007a00)",
+		"\t/src/other_test.go:3 +0x10",
+	}
+	tests := []struct {
+		cur    string
+		output []string
+		want   string
+	}{
+		{"", []string{"no panic"}, ""},
+		{"TestA", []string{"panic: init"}, "TestA"},
+		{"", []string{"panic: init"}, ""},
+		{"", dump, "TestDecode"},
+		{"TestDecode/sub", dump, "TestDecode/sub"},
+		{"TestA", dump, "TestDecode"},
+	}
+	for _, test := range tests {
+		if got := panickedTest(test.cur, test.output); got != test.want {
+			t.Errorf("panickedTest(%q, %q) == %q, want %q", test.cur, test.output, got, test.want)
+		}
+	}
+}
+
+func TestParseWithOptions(t *testing.T) {
+	input := strings.Join([]string{
+		`{"Action":"output","Package":"pkg/json","Test":"TestJSON","Output":"=== RUN   TestJSON\n"}`,
+		`{"Action":"output","Package":"pkg/json","Test":"TestJSON","Output":"--- PASS: TestJSON (0.00s)\n"}`,
+		`# pkg/broken`,
+		`=== RUN   TestParent`,
+		`=== RUN   TestParent/sub`,
+		"    sub_test.go:5: \x1b[31mred\x1b[0m",
+		`    sub_test.go:6: line 2`,
+		`    sub_test.go:7: line 3`,
+		`--- FAIL: TestParent (0.00s)`,
+		`    --- FAIL: TestParent/sub (0.00s)`,
+	}, "\n")
+
+	names := func(report *Report) []string {
+		var names []string
+		for _, pkg := range report.Packages {
+			for _, test := range pkg.Tests {
+				names = append(names, pkg.Name+":"+test.Name)
+			}
+		}
+		return names
+	}
+
+	report, err := ParseWithOptions(strings.NewReader(input), WithPackageName("pkg/text"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(report), []string{"pkg/text:TestJSON", "pkg/text:TestParent", "pkg/text:TestParent/sub"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tests == %q, want %q", got, want)
+	}
+
+	report, err = ParseWithOptions(strings.NewReader(input),
+		WithPackageName("pkg/text"),
+		WithSubtestMode(SubtestsExcludeParents),
+		WithStripANSI(),
+		WithMaxOutput(2, 0),
+		WithInputFormat(InputText),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(report), []string{"pkg/text:TestParent/sub"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tests in text mode without parents == %q, want %q", got, want)
+	}
+	sub := report.Packages[0].Tests[0]
+	if want := []string{"sub_test.go:5: red", "… 1 lines truncated …", "sub_test.go:7: line 3"}; !reflect.DeepEqual(sub.Output, want) {
+		t.Errorf("Output == %q, want %q", sub.Output, want)
+	}
+
+	report, err = ParseWithOptions(strings.NewReader(input), WithInputFormat(InputJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(report), []string{":TestJSON"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tests in JSON mode == %q, want %q", got, want)
+	}
+	if len(report.Stderr) != 8 || report.Stderr[0] != "# pkg/broken" {
+		t.Errorf("Stderr in JSON mode == %q", report.Stderr)
+	}
+}
+
+func TestParseContext(t *testing.T) {
+	report, err := ParseContext(context.Background(), strings.NewReader("=== RUN   TestA\n--- PASS: TestA (0.00s)\nok  \tpkg\t0.01s\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Packages) != 1 || len(report.Packages[0].Tests) != 1 {
+		t.Fatalf("report == %+v, want 1 package with 1 test", report)
+	}
+
+	// the input never ends
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		io.WriteString(pw, "=== RUN   TestHang\n    hang_test.go:5: waiting\n")
+		// returns once the first write was passed on to the parser
+		io.WriteString(pw, "    hang_test.go:5: still waiting\n")
+		cancel()
+	}()
+	report, err = ParseContext(ctx, pr, WithPackageName("pkg/hang"))
+	if err != context.Canceled {
+		t.Fatalf("ParseContext returned error %v, want %v", err, context.Canceled)
+	}
+	if len(report.Packages) != 1 || len(report.Packages[0].Tests) != 1 {
+		t.Fatalf("partial report == %+v, want 1 package with 1 test", report)
+	}
+	if test := report.Packages[0].Tests[0]; !test.Incomplete || test.Name != "TestHang" {
+		t.Errorf("test == %+v, want incomplete TestHang", test)
+	}
+
+	if _, err := ParseContext(ctx, strings.NewReader("")); err != context.Canceled {
+		t.Errorf("ParseContext with a done context returned error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestLongLines(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+	input := "=== RUN   TestLong\n    long_test.go:5: " + long + "\n--- PASS: TestLong (0.00s)\nok  \tpkg\t0.01s\n"
+
+	report, err := Parse(strings.NewReader(input), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := report.Packages[0].Tests[0].Output, []string{"long_test.go:5: " + long}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Output has %d lines, want the long line", len(got))
+	}
+
+	report, err = ParseWithOptions(strings.NewReader(input), WithMaxLineBytes(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := report.Packages[0].Tests[0].Output, []string{"long_test.go:5: xxxxxxxxxx … 9990 bytes truncated …"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Output == %q, want %q", got, want)
+	}
+
+	// multi-byte characters and escape sequences are not split
+	event := `{"Action":"output","Package":"pkg","Test":"TestLong","Output":"    long_test.go:5: é\n` + long + `\n"}`
+	input = `{"Action":"output","Package":"pkg","Test":"TestLong","Output":"=== RUN   TestLong\n"}` + "\n" + event + "\n" +
+		`{"Action":"output","Package":"pkg","Test":"TestLong","Output":"--- PASS: TestLong (0.00s)\n"}` + "\n"
+	for _, max := range []int{88, 89, 90, 91} {
+		report, err = ParseWithOptions(strings.NewReader(input), WithMaxLineBytes(max))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := report.Packages[0].Tests[0].Output
+		if len(got) != 1 || !strings.HasPrefix(got[0], "long_test.go:5: ") || !strings.HasSuffix(got[0], " bytes truncated …") {
+			t.Errorf("Output with %d byte lines == %q", max, got)
+		}
+	}
+}
+
+func TestMaxTestBytes(t *testing.T) {
+	var in bytes.Buffer
+	in.WriteString("=== RUN   TestLoud\n")
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&in, "    loud_test.go:10: line %d\n", i)
+	}
+	in.WriteString("--- FAIL: TestLoud (0.01s)\nFAIL\tpkg\t0.02s\n")
+
+	report, err := ParseWithOptions(bytes.NewReader(in.Bytes()), WithMaxTestBytes(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"loud_test.go:10: line 1", "loud_test.go:10: line 2", "… 97 lines truncated …", "loud_test.go:10: line 100"}
+	if got := report.Packages[0].Tests[0].Output; !reflect.DeepEqual(got, want) {
+		t.Errorf("Output == %q, want %q", got, want)
+	}
+
+	full, err := Parse(bytes.NewReader(in.Bytes()), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := full.TruncateOutput(0, 100); err != nil {
+		t.Fatal(err)
+	}
+	if got := full.Packages[0].Tests[0].Output; !reflect.DeepEqual(got, want) {
+		t.Errorf("TruncateOutput(0, 100) == %q, want the same output %q", got, want)
+	}
+}
+
+func TestGocheck(t *testing.T) {
+	// without -check.v only problems are printed, with -check.vv the log
+	// of every method is streamed after a START line
+	inputs := map[string]string{
+		"plain": `
+----------------------------------------------------------------------
+FAIL: a_test.go:16: S.TestBaz
+
+a_test.go:18:
+    c.Assert(1, Equals, 2)
+
+OOPS: 1 passed, 1 FAILED
+--- FAIL: Test (0.00s)
+FAIL
+FAIL	pkg	0.01s
+`,
+		"stream": `=== RUN   Test
+START: a_test.go:15: S.TestBar
+bar log
+PASS: a_test.go:15: S.TestBar	0.001s
+
+START: a_test.go:16: S.TestBaz
+a_test.go:18:
+    c.Assert(1, Equals, 2)
+
+FAIL: a_test.go:16: S.TestBaz
+
+OOPS: 1 passed, 1 FAILED
+--- FAIL: Test (0.00s)
+FAIL
+FAIL	pkg	0.01s
+`,
+	}
+	for name, input := range inputs {
+		report, err := Parse(strings.NewReader(input), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		test := findTest(report.Packages[0].Tests, "S.TestBaz")
+		if test == nil {
+			t.Errorf("%s: S.TestBaz not found in %+v", name, report.Packages[0].Tests)
+			continue
+		}
+		want := []string{"a_test.go:18:", "    c.Assert(1, Equals, 2)"}
+		if test.Result != FAIL || !reflect.DeepEqual(test.Output, want) {
+			t.Errorf("%s: S.TestBaz == %s %q, want FAIL %q", name, test.Result, test.Output, want)
+		}
+	}
+}
+
+func TestSkipReason(t *testing.T) {
+	tests := []struct {
+		output, reason, rest []string
+	}{
+		{nil, nil, nil},
+		{[]string{"a_test.go:10: not now"}, []string{"a_test.go:10: not now"}, []string{}},
+		{
+			[]string{"a_test.go:8: setting up", "a_test.go:10: needs", "    a database"},
+			[]string{"a_test.go:10: needs", "    a database"},
+			[]string{"a_test.go:8: setting up"},
+		},
+		{[]string{"no log entries"}, []string{"no log entries"}, nil},
+	}
+	for _, test := range tests {
+		reason, rest := (&Test{Output: test.output}).SkipReason()
+		if !reflect.DeepEqual(reason, test.reason) || !reflect.DeepEqual(rest, test.rest) {
+			t.Errorf("SkipReason() of %q == %q, %q, want %q, %q", test.output, reason, rest, test.reason, test.rest)
+		}
+	}
+}
+
+func TestCollectAttachments(t *testing.T) {
+	report := &Report{Packages: []Package{{
+		Name:   "a",
+		Output: []string{"setup [[ATTACHMENT|/logs/setup.log]]"},
+		Tests: []*Test{
+			{Name: "TestA", Output: []string{
+				"    a_test.go:5: [[ATTACHMENT|one.png]] and [[ATTACHMENT|two.png]]",
+				"    a_test.go:6: [[ATTACHMENT|one.png]]",
+				"[[ATTACHMENT|]]",
+			}},
+			{Name: "TestB", Output: []string{"no attachments"}},
+		},
+	}}}
+	if err := report.CollectAttachments(); err != nil {
+		t.Fatal(err)
+	}
+	pkg := report.Packages[0]
+	if !reflect.DeepEqual(pkg.Attachments, []string{"/logs/setup.log"}) {
+		t.Errorf("package attachments == %q, want /logs/setup.log", pkg.Attachments)
+	}
+	if want := []string{"one.png", "two.png"}; !reflect.DeepEqual(pkg.Tests[0].Attachments, want) {
+		t.Errorf("TestA attachments == %q, want %q", pkg.Tests[0].Attachments, want)
+	}
+	if pkg.Tests[1].Attachments != nil {
+		t.Errorf("TestB attachments == %q, want none", pkg.Tests[1].Attachments)
+	}
+
+	if !report.Attach("a", "TestB", "b.log") || !report.Attach("a", "", "a.log") || report.Attach("a", "TestC", "c.log") {
+		t.Errorf("Attach() did not report whether the test or package exists")
+	}
+	if !reflect.DeepEqual(pkg.Tests[1].Attachments, []string{"b.log"}) {
+		t.Errorf("TestB attachments == %q, want b.log", pkg.Tests[1].Attachments)
+	}
+}
+
+func TestParseCoverProfile(t *testing.T) {
+	in := `mode: set
+example.com/a/a.go:3.10,5.2 2 1
+example.com/a/a.go:7.10,9.2 3 0
+example.com/b/b.go:3.10,5.2 5 0
+mode: set
+example.com/a/a.go:7.10,9.2 3 1
+`
+	profile, err := ParseCoverProfile(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Mode != "set" || len(profile.Blocks) != 3 {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+
+	for pkg, want := range map[string]float64{"": 50, "example.com/a": 100, "example.com/b": 0} {
+		if pct, ok := profile.Coverage(pkg); !ok || pct != want {
+			t.Errorf("Coverage(%q) == %v, %v, want %v", pkg, pct, ok, want)
+		}
+	}
+	if _, ok := profile.Coverage("example.com/c"); ok {
+		t.Errorf("Coverage() of a package without statements returned true")
+	}
+
+	for _, in := range []string{"", "example.com/a/a.go:3.10,5.2 2 1\n", "mode: set\nnot a block\n"} {
+		if _, err := ParseCoverProfile(strings.NewReader(in)); err == nil {
+			t.Errorf("ParseCoverProfile(%q) did not return an error", in)
+		}
+	}
+}
+
+func TestAllTests(t *testing.T) {
+	pkg := Package{Tests: []*Test{{Name: "TestA", Result: PASS}}}
+	if tests := pkg.AllTests(); len(tests) != 1 {
+		t.Errorf("AllTests() returned %d tests, want 1", len(tests))
+	}
+
+	pkg.BuildError = &BuildError{Name: "[setup failed]", Output: []string{"no such package"}}
+	tests := pkg.AllTests()
+	if len(tests) != 2 || tests[1].Name != "[setup failed]" || tests[1].Result != ERROR || tests[1].Output[0] != "no such package" {
+		t.Errorf("AllTests() == %+v, want TestA and the build error", tests)
+	}
+	if len(pkg.Tests) != 1 {
+		t.Errorf("AllTests() modified the tests of the package")
+	}
+
+	merged := Merge(&Report{Packages: []Package{pkg}}, &Report{Packages: []Package{pkg}})
+	if err := merged.Packages[0].BuildError; err == nil || len(err.Output) != 2 {
+		t.Errorf("merged build error == %+v, want the output of both", err)
+	}
+}
+
+func TestBenchmarkCPU(t *testing.T) {
+	// with -v the name of a benchmark is printed before its results, with
+	// -count every variant is repeated
+	input := `BenchmarkHash
+BenchmarkHash       	 1000000	      1020 ns/op
+BenchmarkHash       	 1000000	      1030 ns/op
+BenchmarkHash-4     	 1000000	      1011 ns/op
+BenchmarkHash-4     	 1000000	      1012 ns/op
+BenchmarkSort-4     	  100000	     12873 ns/op
+PASS
+ok  	pkg	4.120s
+`
+	report, err := Parse(strings.NewReader(input), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type variant struct {
+		name     string
+		cpu      int
+		duration time.Duration
+	}
+	var got []variant
+	for _, test := range report.Packages[0].Tests {
+		got = append(got, variant{test.Name, test.CPU, test.Duration})
+	}
+	want := []variant{
+		{"BenchmarkHash", 1, 1030 * time.Nanosecond},
+		{"BenchmarkHash-4", 4, 1012 * time.Nanosecond},
+		{"BenchmarkSort", 4, 12873 * time.Nanosecond},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("benchmarks == %v, want %v", got, want)
+	}
+}
+
+func TestPausedDuration(t *testing.T) {
+	input := `{"Time":"2026-10-17T10:00:53.000Z","Action":"run","Package":"par","Test":"TestA"}
+{"Time":"2026-10-17T10:00:53.000Z","Action":"output","Package":"par","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Time":"2026-10-17T10:00:53.001Z","Action":"output","Package":"par","Test":"TestA","Output":"=== PAUSE TestA\n"}
+{"Time":"2026-10-17T10:00:53.001Z","Action":"pause","Package":"par","Test":"TestA"}
+{"Time":"2026-10-17T10:00:53.301Z","Action":"cont","Package":"par","Test":"TestA"}
+{"Time":"2026-10-17T10:00:53.301Z","Action":"output","Package":"par","Test":"TestA","Output":"=== CONT  TestA\n"}
+{"Time":"2026-10-17T10:00:53.401Z","Action":"output","Package":"par","Test":"TestA","Output":"--- PASS: TestA (0.10s)\n"}
+{"Time":"2026-10-17T10:00:53.401Z","Action":"pass","Package":"par","Test":"TestA","Elapsed":0.1}
+{"Time":"2026-10-17T10:00:53.402Z","Action":"output","Package":"par","Output":"ok  \tpar\t0.402s\n"}
+`
+	// older versions of test2json only print the === PAUSE and === CONT lines
+	markers := regexp.MustCompile(`(?m)^.*"Action":"(pause|cont)".*\n`).ReplaceAllString(input, "")
+	for _, in := range []string{markers, input} {
+		report, err := Parse(strings.NewReader(in), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		test := report.Packages[0].Tests[0]
+		if test.Duration != 100*time.Millisecond || test.Paused != 300*time.Millisecond {
+			t.Errorf("TestA Duration == %s, Paused == %s, want 100ms and 300ms", test.Duration, test.Paused)
+		}
+	}
+
+	report, err := Parse(strings.NewReader(input), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := report.Packages[0].Tests[0]
+	if d := test.WallDuration(); d != 401*time.Millisecond {
+		t.Errorf("WallDuration() == %s, want 401ms", d)
+	}
+	if d := test.ActiveDuration(); d != 101*time.Millisecond {
+		t.Errorf("ActiveDuration() == %s, want 101ms", d)
+	}
+
+	// without event times both are the reported duration
+	text := &Test{Duration: time.Second}
+	if text.WallDuration() != time.Second || text.ActiveDuration() != time.Second {
+		t.Errorf("durations of test without times == %s, %s, want 1s", text.WallDuration(), text.ActiveDuration())
+	}
+}
+
+func TestCounts(t *testing.T) {
+	report := &Report{Packages: []Package{
+		{
+			Name:     "a",
+			Duration: time.Second,
+			Tests: []*Test{
+				{Name: "TestA", Result: PASS},
+				{Name: "TestB", Result: FAIL},
+				{Name: "TestC", Result: SKIP},
+				{Name: "TestD", Result: ERROR},
+			},
+		},
+		{
+			Name:       "b",
+			Duration:   500 * time.Millisecond,
+			Tests:      []*Test{{Name: "TestE", Result: FAIL}},
+			BuildError: &BuildError{Name: "[build failed]"},
+		},
+	}}
+
+	if n := report.Packages[1].Failures(); n != 1 {
+		t.Errorf("Package.Failures() == %d, want 1", n)
+	}
+	if n := report.Failures(); n != 2 {
+		t.Errorf("Report.Failures() == %d, want 2", n)
+	}
+	tests, failures, errors, skipped := report.Counts()
+	if tests != 6 || failures != 2 || errors != 2 || skipped != 1 {
+		t.Errorf("Report.Counts() == %d, %d, %d, %d, want 6, 2, 2, 1", tests, failures, errors, skipped)
+	}
+	if d := report.TotalDuration(); d != 1500*time.Millisecond {
+		t.Errorf("TotalDuration() == %s, want 1.5s", d)
+	}
+}
+
+func TestRestoreSubtestSpaces(t *testing.T) {
+	report := &Report{Packages: []Package{{Name: "my_pkg", Tests: []*Test{
+		{Name: "Test_X"},
+		{Name: "Test_X/my_case"},
+		{Name: "Test_X/my_case/sub_case"},
+	}}}}
+	report.RestoreSubtestSpaces()
+
+	var names []string
+	for _, test := range report.Packages[0].Tests {
+		names = append(names, test.Name)
+	}
+	if want := []string{"Test_X", "Test_X/my case", "Test_X/my case/sub case"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("RestoreSubtestSpaces() names == %q, want %q", names, want)
+	}
+	if report.Packages[0].Name != "my_pkg" {
+		t.Errorf("package renamed to %q", report.Packages[0].Name)
+	}
+}
@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"io"
+)
+
+// Decision describes how the parser classified a single line of plain text
+// test output. Kind is one of run, pause, cont, benchmark, status,
+// unknown-status, package, coverage, shuffle, build, vet, build-output,
+// summary, gocheck, goconvey, output, buffered or stderr.
+type Decision struct {
+	Kind    string `json:"kind"`
+	Text    string `json:"text"`
+	Test    string `json:"test,omitempty"`
+	Package string `json:"package,omitempty"`
+	Result  string `json:"result,omitempty"`
+}
+
+// Record contains the decisions made while parsing a single line of input.
+// A test2json line may result in any number of decisions, one for each line
+// of output contained in the event. The last Record of a parse has EOF set
+// and contains the decisions made for pending output at the end of the input.
+type Record struct {
+	Line      int        `json:"line"`
+	Input     string     `json:"input"`
+	EOF       bool       `json:"eof,omitempty"`
+	Decisions []Decision `json:"decisions,omitempty"`
+}
+
+// ParseRecorded parses go test output like Parse and calls rec with a Record
+// of the parser decisions for every line of input. Replaying the recorded
+// input with a different version of the parser can be used to detect changes
+// in its behavior.
+func ParseRecorded(r io.Reader, pkgName string, rec func(Record)) (*Report, error) {
+	return ParseWithOptions(r, WithPackageName(pkgName), WithRecorder(rec))
+}
+
+// decide records decision d for the line being parsed.
+func (p *lineParser) decide(d Decision) {
+	if p.recorder != nil {
+		p.decisions = append(p.decisions, d)
+	}
+}
+
+// record passes the decisions made so far to the recorder.
+func (p *lineParser) record(r Record) {
+	if p.recorder == nil {
+		return
+	}
+	r.Decisions = p.decisions
+	p.decisions = nil
+	p.recorder(r)
+}
@@ -0,0 +1,31 @@
+package parser
+
+import "strings"
+
+// Rename replaces the name of every package and test in the report by the
+// result of calling rename with the old name.
+func (r *Report) Rename(rename func(string) string) {
+	for i := range r.Packages {
+		pkg := &r.Packages[i]
+		pkg.Name = rename(pkg.Name)
+		for _, test := range pkg.Tests {
+			test.Name = rename(test.Name)
+		}
+	}
+}
+
+// TrimPackagePrefix removes prefix from the start of the names of the
+// packages in the report, e.g. github.com/company/repo/ to shorten
+// github.com/company/repo/pkg/a to pkg/a. Packages whose name is prefix
+// without its trailing slash are not renamed.
+func (r *Report) TrimPackagePrefix(prefix string) {
+	if prefix == "" {
+		return
+	}
+	for i := range r.Packages {
+		pkg := &r.Packages[i]
+		if name := strings.TrimPrefix(pkg.Name, prefix); name != "" {
+			pkg.Name = name
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package parser
+
+import "regexp"
+
+// regexLogEntry matches the first line of an entry logged by a test, with its
+// indentation stripped.
+var regexLogEntry = regexp.MustCompile(`^\S+\.go:\d+: `)
+
+// SkipReason splits the output of a skipped test into the reason given to
+// t.Skip, which is the last entry logged by the test, and the output logged
+// before it. If no logged entry is found, all output is returned as reason.
+func (t *Test) SkipReason() (reason, output []string) {
+	for i := len(t.Output) - 1; i >= 0; i-- {
+		if regexLogEntry.MatchString(t.Output[i]) {
+			return t.Output[i:], t.Output[:i]
+		}
+	}
+	return t.Output, nil
+}
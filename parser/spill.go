@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// minSpillLines is the smallest in-memory window of output lines, the parser
+// needs to look back at the last few lines of output of a test.
+const minSpillLines = 3
+
+// ParseSpill parses go test output like Parse, but keeps at most maxLines
+// lines of output of each test in memory. When a test logs more, its earlier
+// output lines are moved to a temporary file in dir (the default directory
+// for temporary files if dir is empty), see Test.SpillFile. The caller is
+// responsible for removing these files, for example with RemoveSpillFiles.
+func ParseSpill(r io.Reader, pkgName string, dir string, maxLines int) (*Report, error) {
+	if maxLines < minSpillLines {
+		maxLines = minSpillLines
+	}
+	return ParseWithOptions(r, WithPackageName(pkgName), WithSpill(dir, maxLines))
+}
+
+// appendOutput appends lines to the output of test, spilling the oldest lines
+// to disk if needed.
+func (p *lineParser) appendOutput(test *Test, lines ...string) {
+	if p.maxTestBytes > 0 && p.spillLines == 0 {
+		p.appendCapped(test, lines...)
+		return
+	}
+	test.Output = append(test.Output, lines...)
+	// spill in chunks, so the spill file isn't opened for every line
+	if p.spillLines > 0 && len(test.Output) >= 2*p.spillLines && p.spillErr == nil {
+		n := len(test.Output) - p.spillLines
+		if p.spillErr = spill(test, p.spillDir, test.Output[:n]); p.spillErr == nil {
+			test.Output = append(test.Output[:0:0], test.Output[n:]...)
+		}
+	}
+}
+
+// spill appends lines to the spill file of test, creating it in dir if the
+// test has none.
+func spill(test *Test, dir string, lines []string) error {
+	var f *os.File
+	var err error
+	if test.SpillFile == "" {
+		if f, err = ioutil.TempFile(dir, "go-junit-report-output-"); err != nil {
+			return err
+		}
+		test.SpillFile = f.Name()
+	} else if f, err = os.OpenFile(test.SpillFile, os.O_WRONLY|os.O_APPEND, 0); err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for _, line := range lines {
+		w.WriteString(line)
+		w.WriteByte('\n')
+	}
+	err = w.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	test.SpilledLines += len(lines)
+	return err
+}
+
+// EachOutputLine calls fn for every line of output of the test in order,
+// first the lines in SpillFile, if any, followed by the lines in Output. It
+// stops at the first error returned by fn.
+func (t *Test) EachOutputLine(fn func(line string) error) error {
+	if t.SpillFile != "" {
+		f, err := os.Open(t.SpillFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		r := bufio.NewReader(f)
+		for {
+			line, err := r.ReadString('\n')
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return err
+			}
+			if err := fn(line[:len(line)-1]); err != nil {
+				return err
+			}
+		}
+	}
+	for _, line := range t.Output {
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AllOutput returns all output lines of the test, including those that were
+// spilled to disk.
+func (t *Test) AllOutput() ([]string, error) {
+	if t.SpillFile == "" {
+		return t.Output, nil
+	}
+	var lines []string
+	err := t.EachOutputLine(func(line string) error {
+		lines = append(lines, line)
+		return nil
+	})
+	return lines, err
+}
+
+// RemoveSpillFiles removes the spill files of all tests in the report.
+func (r *Report) RemoveSpillFiles() error {
+	var err error
+	for _, pkg := range r.Packages {
+		for _, test := range pkg.Tests {
+			if test.SpillFile == "" {
+				continue
+			}
+			if rerr := os.Remove(test.SpillFile); rerr != nil && err == nil {
+				err = rerr
+			}
+			test.SpillFile = ""
+			test.SpilledLines = 0
+		}
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"strings"
+)
+
+// linkSubtests sets Parent and Subtests of all tests based on their names.
+// The parent of a subtest is the closest ancestor by name that was started
+// before it, so tests that ran more than once (e.g. with -count) get linked
+// to the right run of their parent.
+func linkSubtests(tests []*Test) {
+	seen := make(map[string]*Test, len(tests))
+	for _, test := range tests {
+		test.Parent = nil
+		test.Subtests = nil
+	}
+	for _, test := range tests {
+		for name := test.Name; ; {
+			idx := strings.LastIndex(name, "/")
+			if idx < 0 {
+				break
+			}
+			name = name[:idx]
+			if parent := seen[name]; parent != nil {
+				test.Parent = parent
+				parent.Subtests = append(parent.Subtests, test)
+				break
+			}
+		}
+		seen[test.Name] = test
+	}
+}
+
+// Depth returns the number of ancestors of the test.
+func (t *Test) Depth() int {
+	n := 0
+	for p := t.Parent; p != nil; p = p.Parent {
+		n++
+	}
+	return n
+}
+
+// TopLevelTests returns the tests of the package that have no parent.
+func (p *Package) TopLevelTests() []*Test {
+	var tests []*Test
+	for _, test := range p.Tests {
+		if test.Parent == nil {
+			tests = append(tests, test)
+		}
+	}
+	return tests
+}
+
+// ExcludeParents removes all tests that have subtests from the report, so
+// only the leaves of the subtest tree are reported.
+func (r *Report) ExcludeParents() {
+	r.FilterTests(func(test *Test) bool {
+		return len(test.Subtests) == 0
+	})
+}
+
+// IgnoreParentResults marks failed tests that have subtests as passed, so a
+// failing subtest is only counted once.
+func (r *Report) IgnoreParentResults() {
+	for _, pkg := range r.Packages {
+		for _, test := range pkg.Tests {
+			if len(test.Subtests) > 0 && test.Result == FAIL {
+				test.Result = PASS
+			}
+		}
+	}
+}
+
+// LinkSubtests sets Parent and Subtests of all tests in the report based on
+// their names. Reports returned by Parse are already linked, this is only
+// needed for reports that are built or modified in other ways.
+func (r *Report) LinkSubtests() {
+	for _, pkg := range r.Packages {
+		linkSubtests(pkg.Tests)
+	}
+}
+
+// RestoreSubtestSpaces replaces the underscores in the names of subtests by
+// spaces. The testing package replaces the spaces in the names passed to t.Run
+// by underscores, e.g. t.Run("my case") runs TestX/my_case, so this makes the
+// names readable again, at the cost of also replacing underscores that were
+// part of the names. The names of top-level tests, which are function names,
+// are kept.
+func (r *Report) RestoreSubtestSpaces() {
+	for _, pkg := range r.Packages {
+		for _, test := range pkg.Tests {
+			if i := strings.Index(test.Name, "/"); i >= 0 {
+				test.Name = test.Name[:i] + strings.Replace(test.Name[i:], "_", " ", -1)
+			}
+		}
+	}
+}
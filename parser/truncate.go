@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"unicode/utf8"
+)
+
+// TruncateOutput limits the output of every test to about maxLines lines and
+// maxBytes bytes, a limit of 0 means no limit. The first and last lines of
+// output are kept, the lines in between are replaced by a line saying how
+// many lines were removed. Lines that are longer than half of maxBytes are
+// shortened. Spilled output is read back and its spill file removed.
+func (r *Report) TruncateOutput(maxLines, maxBytes int) error {
+	if maxLines <= 0 && maxBytes <= 0 {
+		return nil
+	}
+	for _, pkg := range r.Packages {
+		for _, test := range pkg.Tests {
+			if err := test.truncateOutput(maxLines, maxBytes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *Test) truncateOutput(maxLines, maxBytes int) error {
+	lines, size := 0, 0
+	err := t.EachOutputLine(func(line string) error {
+		lines++
+		size += len(line) + 1
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if (maxLines <= 0 || lines <= maxLines) && (maxBytes <= 0 || size <= maxBytes) {
+		return nil
+	}
+
+	// the limits are split evenly between the first and last lines
+	headLines, tailLines := lines, lines
+	if maxLines > 0 {
+		headLines = maxLines / 2
+		tailLines = maxLines - headLines
+	}
+	headBytes, tailBytes := size, size
+	if maxBytes > 0 {
+		headBytes = maxBytes / 2
+		tailBytes = maxBytes - headBytes
+	}
+
+	var head, tail []string
+	headSize, tailSize := 0, 0
+	headDone := false
+	removed := 0
+	err = t.EachOutputLine(func(line string) error {
+		// leave room for the newline
+		line = shortenLine(line, tailBytes-1)
+		if !headDone {
+			if len(head) < headLines && headSize+len(line)+1 <= headBytes {
+				head = append(head, line)
+				headSize += len(line) + 1
+				return nil
+			}
+			headDone = true
+		}
+		tail = append(tail, line)
+		tailSize += len(line) + 1
+		for len(tail) > tailLines || tailSize > tailBytes {
+			tailSize -= len(tail[0]) + 1
+			tail = tail[1:]
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if removed > 0 {
+		head = append(head, fmt.Sprintf("… %d lines truncated …", removed))
+	}
+	t.Output = append(head, tail...)
+	if t.SpillFile != "" {
+		err = os.Remove(t.SpillFile)
+		t.SpillFile = ""
+		t.SpilledLines = 0
+	}
+	return err
+}
+
+// shortenLine shortens line to at most max bytes, including a note saying how
+// many bytes were removed, without splitting UTF-8 sequences.
+func shortenLine(line string, max int) string {
+	if len(line) <= max {
+		return line
+	}
+	// the note may get a digit shorter once the kept bytes are known
+	n := max - len(fmt.Sprintf(" … %d bytes truncated …", len(line)))
+	if n < 0 {
+		n = 0
+	}
+	for n > 0 && !utf8.RuneStart(line[n]) {
+		n--
+	}
+	return fmt.Sprintf("%s … %d bytes truncated …", line[:n], len(line)-n)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// progressWriter writes a live view of the test progress, for -progress,
+// while the input is parsed. It's driven by the parser decisions, so plain
+// text and test2json input are rendered the same way.
+type progressWriter struct {
+	w      io.Writer
+	format string
+
+	// for the failures format, the output of tests that haven't finished
+	// and the tests that failed
+	output map[string][]string
+	failed map[string]bool
+}
+
+func newProgressWriter(w io.Writer, format string) (*progressWriter, error) {
+	switch format {
+	case "verbose", "testname", "pkgname", "failures":
+	default:
+		return nil, fmt.Errorf("unknown progress format %q", format)
+	}
+	return &progressWriter{
+		w:      w,
+		format: format,
+		output: map[string][]string{},
+		failed: map[string]bool{},
+	}, nil
+}
+
+// record writes the progress for the decisions in r.
+func (pw *progressWriter) record(r parser.Record) {
+	if pw.format == "verbose" && !r.EOF && !strings.HasPrefix(r.Input, "{") {
+		// plain text output is written as is, the text of decisions is
+		// without the indentation of log lines
+		pw.println(r.Input)
+		return
+	}
+	for _, d := range r.Decisions {
+		switch pw.format {
+		case "verbose":
+			pw.println(d.Text)
+		case "testname":
+			if d.Kind == "status" || d.Kind == "unknown-status" || d.Kind == "package" ||
+				(d.Kind == "gocheck" && d.Result != "") {
+				pw.println(d.Text)
+			}
+		case "pkgname":
+			if d.Kind == "package" {
+				pw.println(d.Text)
+			}
+		case "failures":
+			pw.failure(d)
+		}
+	}
+}
+
+// failure writes d if it's part of a failure: the status and output of a
+// failed test, the output of a package that failed without a failed test,
+// build and vet errors, and package results.
+func (pw *progressWriter) failure(d parser.Decision) {
+	switch d.Kind {
+	case "output", "buffered":
+		if pw.failed[d.Test] {
+			// without -v the output of a test follows its status
+			pw.println(d.Text)
+		} else {
+			pw.output[d.Test] = append(pw.output[d.Test], d.Text)
+		}
+	case "status", "unknown-status", "gocheck":
+		if d.Result == "" {
+			// a gocheck separator or method that started
+			break
+		}
+		if d.Result == "FAIL" {
+			pw.failed[d.Test] = true
+			pw.println(d.Text)
+			for _, line := range pw.output[d.Test] {
+				pw.println(line)
+			}
+		}
+		delete(pw.output, d.Test)
+	case "package":
+		if d.Result == "FAIL" && len(pw.failed) == 0 {
+			// e.g. a panic, which isn't reported as test failure
+			for _, line := range pw.output[""] {
+				pw.println(line)
+			}
+		}
+		pw.println(d.Text)
+		pw.output = map[string][]string{}
+		pw.failed = map[string]bool{}
+	case "build", "build-output", "vet":
+		pw.println(d.Text)
+	}
+}
+
+func (pw *progressWriter) println(line string) {
+	fmt.Fprintln(pw.w, line)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestProgressWriter(t *testing.T) {
+	input := `=== RUN   TestOne
+    one_test.go:5: one
+--- PASS: TestOne (0.00s)
+=== RUN   TestTwo
+    two_test.go:8: two
+--- FAIL: TestTwo (0.00s)
+FAIL
+FAIL	package/one	0.002s
+--- FAIL: TestThree (0.00s)
+    three_test.go:3: three
+FAIL
+FAIL	package/two	0.001s
+panic: boom
+FAIL	package/three	0.001s
+ok  	package/four	0.001s
+`
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"verbose", input},
+		{"pkgname", `FAIL	package/one	0.002s
+FAIL	package/two	0.001s
+FAIL	package/three	0.001s
+ok  	package/four	0.001s
+`},
+		{"testname", `--- PASS: TestOne (0.00s)
+--- FAIL: TestTwo (0.00s)
+FAIL	package/one	0.002s
+--- FAIL: TestThree (0.00s)
+FAIL	package/two	0.001s
+FAIL	package/three	0.001s
+ok  	package/four	0.001s
+`},
+		{"failures", `--- FAIL: TestTwo (0.00s)
+two_test.go:8: two
+FAIL	package/one	0.002s
+--- FAIL: TestThree (0.00s)
+    three_test.go:3: three
+FAIL	package/two	0.001s
+panic: boom
+FAIL	package/three	0.001s
+ok  	package/four	0.001s
+`},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		pw, err := newProgressWriter(&buf, test.format)
+		if err != nil {
+			t.Fatalf("newProgressWriter(%q) error: %s", test.format, err)
+		}
+		if _, err := parser.ParseWithOptions(strings.NewReader(input), parser.WithRecorder(pw.record)); err != nil {
+			t.Fatalf("ParseWithOptions() error: %s", err)
+		}
+		if buf.String() != test.want {
+			t.Errorf("progress %s output\nEXP:\n%s\nGOT:\n%s", test.format, test.want, buf.String())
+		}
+	}
+
+	if _, err := newProgressWriter(&bytes.Buffer{}, "dots"); err == nil {
+		t.Errorf("newProgressWriter(%q) returned no error", "dots")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// pushPrometheus replaces the metrics of the go-junit-report job in the
+// Pushgateway at url by the metrics of report.
+func pushPrometheus(report *parser.Report, url string) error {
+	var body bytes.Buffer
+	if err := formatter.WritePrometheus(report, &body); err != nil {
+		return err
+	}
+	url = strings.TrimSuffix(url, "/") + "/metrics/job/go-junit-report"
+	return sendRequest("PUT", url, "text/plain; version=0.0.4", nil, &body)
+}
+
+// writePrometheusTextfile writes the metrics of report to filename for the
+// textfile collector of the node exporter. The file is replaced atomically so
+// the collector never reads a partially written file.
+func writePrometheusTextfile(report *parser.Report, filename string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = formatter.WritePrometheus(report, tmp)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+var metricsReport = &parser.Report{Packages: []parser.Package{{
+	Name:  "example.com/a",
+	Tests: []*parser.Test{{Name: "TestA", Result: parser.PASS}},
+}}}
+
+func TestPushPrometheus(t *testing.T) {
+	var method, path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(data)
+	}))
+	defer server.Close()
+
+	if err := pushPrometheus(metricsReport, server.URL+"/"); err != nil {
+		t.Fatal(err)
+	}
+	if method != "PUT" || path != "/metrics/job/go-junit-report" || !strings.Contains(body, `go_test_tests_total{package="example.com/a"} 1`) {
+		t.Errorf("unexpected push: %s %s\n%s", method, path, body)
+	}
+}
+
+func TestWritePrometheusTextfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "textfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "tests.prom")
+	if err := writePrometheusTextfile(metricsReport, filename); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `go_test_tests_total{package="example.com/a"} 1`) {
+		t.Errorf("unexpected textfile:\n%s", data)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("textfile directory contains %d files, want 1", len(files))
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// propertyFlag is a repeatable flag of name=value pairs.
+type propertyFlag []parser.Property
+
+func (p *propertyFlag) String() string {
+	var pairs []string
+	for _, prop := range *p {
+		pairs = append(pairs, prop.Name+"="+prop.Value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (p *propertyFlag) Set(value string) error {
+	idx := strings.Index(value, "=")
+	if idx < 1 {
+		return errors.New("property must be of the form name=value")
+	}
+	*p = append(*p, parser.Property{Name: value[:idx], Value: value[idx+1:]})
+	return nil
+}
+
+// addProperties adds props to every package in report.
+func addProperties(report *parser.Report, props []parser.Property) {
+	if len(props) == 0 {
+		return
+	}
+	for i := range report.Packages {
+		report.Packages[i].Properties = append(report.Packages[i].Properties, props...)
+	}
+}
+
+// envProperties returns a property for each environment variable in the comma
+// separated list of names that is set.
+func envProperties(names string) []parser.Property {
+	var props []parser.Property
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			props = append(props, parser.Property{Name: name, Value: value})
+		}
+	}
+	return props
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestPropertyFlag(t *testing.T) {
+	var props propertyFlag
+	for _, value := range []string{"build=42", "url=http://ci/job?id=1", "empty="} {
+		if err := props.Set(value); err != nil {
+			t.Fatalf("Set(%q) returned error: %s", value, err)
+		}
+	}
+	for _, value := range []string{"", "novalue", "=value"} {
+		if err := props.Set(value); err == nil {
+			t.Errorf("Set(%q) did not return an error", value)
+		}
+	}
+
+	want := propertyFlag{
+		{Name: "build", Value: "42"},
+		{Name: "url", Value: "http://ci/job?id=1"},
+		{Name: "empty", Value: ""},
+	}
+	if !reflect.DeepEqual(props, want) {
+		t.Errorf("properties == %v, want %v", props, want)
+	}
+
+	report := &parser.Report{Packages: []parser.Package{{Name: "a"}, {Name: "b"}}}
+	addProperties(report, props)
+	for _, pkg := range report.Packages {
+		if !reflect.DeepEqual(pkg.Properties, []parser.Property(props)) {
+			t.Errorf("package %s properties == %v, want %v", pkg.Name, pkg.Properties, props)
+		}
+	}
+}
+
+func TestEnvProperties(t *testing.T) {
+	os.Setenv("GJR_TEST_JOB", "123")
+	os.Setenv("GJR_TEST_EMPTY", "")
+	os.Unsetenv("GJR_TEST_UNSET")
+	defer os.Unsetenv("GJR_TEST_JOB")
+	defer os.Unsetenv("GJR_TEST_EMPTY")
+
+	want := []parser.Property{
+		{Name: "GJR_TEST_JOB", Value: "123"},
+		{Name: "GJR_TEST_EMPTY", Value: ""},
+	}
+	if got := envProperties("GJR_TEST_JOB, GJR_TEST_UNSET,,GJR_TEST_EMPTY"); !reflect.DeepEqual(got, want) {
+		t.Errorf("envProperties() == %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// readQuarantine reads a quarantine list of known flaky tests from filename.
+// Each line is a test name or a regexp that must match the full name of a
+// test, empty lines and lines starting with # are ignored.
+func readQuarantine(filename string) ([]*regexp.Regexp, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []*regexp.Regexp
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + line + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", n, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, s.Err()
+}
+
+// quarantineTests reports the failed tests of report matching one of patterns
+// as skipped and marks them as quarantined, and returns their number. Parents
+// that failed only because of quarantined subtests are quarantined as well.
+func quarantineTests(report *parser.Report, patterns []*regexp.Regexp) int {
+	match := func(test *parser.Test) bool {
+		return matchesAny(patterns, test.Name)
+	}
+	return downgradeFailures(report, match, func(test *parser.Test) {
+		test.Result = parser.SKIP
+		test.Quarantined = true
+	})
+}
+
+// hasFailures returns whether report has failed or errored tests, including
+// build errors.
+func hasFailures(report *parser.Report) bool {
+	_, failures, errors, _ := report.Counts()
+	return failures+errors > 0
+}
+
+// downgradeFailures calls downgrade for the failed tests of report for which
+// match returns true, and for parents that failed only because of such
+// subtests, and returns their number. The error type of the tests is reset
+// before downgrade is called.
+//
+// Go's output doesn't tell failure messages from other logs, so a parent with
+// output of its own, which excludes the output of its subtests, may have
+// failed by itself and is only downgraded if match returns true for it.
+func downgradeFailures(report *parser.Report, match func(*parser.Test) bool, downgrade func(*parser.Test)) int {
+	n := 0
+	// visit returns whether test still fails
+	var visit func(test *parser.Test) bool
+	visit = func(test *parser.Test) bool {
+		subtestFailed, subtestFails := false, false
+		for _, sub := range test.Subtests {
+			if sub.Result == parser.FAIL || sub.Result == parser.ERROR {
+				subtestFailed = true
+			}
+			if visit(sub) {
+				subtestFails = true
+			}
+		}
+		if test.Result != parser.FAIL && test.Result != parser.ERROR {
+			return false
+		}
+		if !match(test) && (!subtestFailed || subtestFails || hasOwnOutput(test)) {
+			return true
+		}
+		test.ErrorType = ""
+		downgrade(test)
+		n++
+		return false
+	}
+
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.Tests {
+			if test.Parent == nil {
+				visit(test)
+			}
+		}
+	}
+	return n
+}
+
+// hasOwnOutput returns whether test has output, or its output can't be read.
+func hasOwnOutput(test *parser.Test) bool {
+	output, err := test.AllOutput()
+	return err != nil || len(output) > 0
+}
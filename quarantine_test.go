@@ -0,0 +1,114 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestReadQuarantine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quarantine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "quarantine.txt")
+	if err := ioutil.WriteFile(file, []byte("# flaky since the network upgrade\nTestDial\n\nTestRetry/.*\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	patterns, err := readQuarantine(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		"TestDial":          true,
+		"TestDialTimeout":   false,
+		"TestRetry/backoff": true,
+		"TestRetry":         false,
+	} {
+		if got := matchesAny(patterns, name); got != want {
+			t.Errorf("%s matches == %t, want %t", name, got, want)
+		}
+	}
+
+	if err := ioutil.WriteFile(file, []byte("TestA\nTest(\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readQuarantine(file); err == nil || !strings.HasPrefix(err.Error(), "line 2: ") {
+		t.Errorf("readQuarantine() == %v, want an error in line 2", err)
+	}
+}
+
+func TestQuarantineTests(t *testing.T) {
+	in := `=== RUN   TestDial
+--- FAIL: TestDial (0.01s)
+=== RUN   TestRetry
+=== RUN   TestRetry/backoff
+    --- FAIL: TestRetry/backoff (0.00s)
+--- FAIL: TestRetry (0.00s)
+=== RUN   TestMixed
+=== RUN   TestMixed/flaky
+    --- FAIL: TestMixed/flaky (0.00s)
+=== RUN   TestMixed/broken
+    --- FAIL: TestMixed/broken (0.00s)
+--- FAIL: TestMixed (0.00s)
+=== RUN   TestPass
+--- PASS: TestPass (0.00s)
+FAIL
+FAIL	pkg	0.01s
+`
+	report, err := parser.Parse(strings.NewReader(in), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var patterns regexpFlag
+	for _, p := range []string{"^TestDial$", "^TestRetry/", "^TestMixed/flaky$", "^TestPass$"} {
+		patterns.Set(p)
+	}
+	if n := quarantineTests(report, patterns); n != 4 {
+		t.Errorf("quarantineTests() == %d, want 4", n)
+	}
+
+	results := map[string]parser.Result{}
+	for _, test := range report.Packages[0].Tests {
+		if test.Quarantined != (test.Result == parser.SKIP) {
+			t.Errorf("%s: Quarantined == %t with result %s", test.Name, test.Quarantined, test.Result)
+		}
+		results[test.Name] = test.Result
+	}
+	want := map[string]parser.Result{
+		"TestDial":          parser.SKIP,
+		"TestRetry":         parser.SKIP,
+		"TestRetry/backoff": parser.SKIP,
+		"TestMixed":         parser.FAIL,
+		"TestMixed/flaky":   parser.SKIP,
+		"TestMixed/broken":  parser.FAIL,
+		"TestPass":          parser.PASS,
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results == %v, want %v", results, want)
+	}
+}
+
+func TestHasFailures(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{
+		{Name: "a", Tests: []*parser.Test{{Name: "TestFlaky", Result: parser.SKIP, Quarantined: true}}},
+	}}
+	if hasFailures(report) {
+		t.Errorf("hasFailures() == true with only quarantined failures")
+	}
+	report.Packages = append(report.Packages, parser.Package{Name: "b", Tests: []*parser.Test{{Name: "TestPanic", Result: parser.ERROR}}})
+	if !hasFailures(report) {
+		t.Errorf("hasFailures() == false with an errored test")
+	}
+	report.Packages[1] = parser.Package{Name: "b", BuildError: &parser.BuildError{Name: "b [build failed]"}}
+	if !hasFailures(report) {
+		t.Errorf("hasFailures() == false with a build error")
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// recordWriter writes parser records to a JSONL decision log.
+type recordWriter struct {
+	file *os.File
+	w    *bufio.Writer
+	enc  *json.Encoder
+	err  error
+}
+
+func newRecordWriter(filename string) (*recordWriter, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(file)
+	return &recordWriter{file: file, w: w, enc: json.NewEncoder(w)}, nil
+}
+
+// record writes r to the log, the first error is returned by Close.
+func (rw *recordWriter) record(r parser.Record) {
+	if rw.err == nil {
+		rw.err = rw.enc.Encode(r)
+	}
+}
+
+func (rw *recordWriter) Close() error {
+	err := rw.err
+	if ferr := rw.w.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := rw.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readRecords reads a decision log written by -record.
+func readRecords(r io.Reader) ([]parser.Record, error) {
+	var records []parser.Record
+	dec := json.NewDecoder(r)
+	for {
+		var rec parser.Record
+		if err := dec.Decode(&rec); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+}
+
+// replay parses the input stored in the decision log again and writes the
+// lines for which the parser made different decisions to w. It returns the
+// number of lines with changed decisions.
+func replay(w io.Writer, records []parser.Record) (int, error) {
+	var input strings.Builder
+	for _, rec := range records {
+		if !rec.EOF {
+			input.WriteString(rec.Input)
+			input.WriteString("\n")
+		}
+	}
+
+	var replayed []parser.Record
+	if _, err := parser.ParseRecorded(strings.NewReader(input.String()), "", func(rec parser.Record) {
+		replayed = append(replayed, rec)
+	}); err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for i := 0; i < len(records) || i < len(replayed); i++ {
+		var old, cur parser.Record
+		if i < len(records) {
+			old = records[i]
+		}
+		if i < len(replayed) {
+			cur = replayed[i]
+		}
+		if len(old.Decisions) == 0 && len(cur.Decisions) == 0 || reflect.DeepEqual(old.Decisions, cur.Decisions) {
+			continue
+		}
+
+		changed++
+		line := old
+		if i >= len(records) {
+			line = cur
+		}
+		if line.EOF {
+			fmt.Fprintf(w, "end of input:\n")
+		} else {
+			fmt.Fprintf(w, "line %d: %s\n", line.Line, line.Input)
+		}
+		for _, d := range old.Decisions {
+			fmt.Fprintf(w, "  - %s\n", formatDecision(d))
+		}
+		for _, d := range cur.Decisions {
+			fmt.Fprintf(w, "  + %s\n", formatDecision(d))
+		}
+	}
+	return changed, nil
+}
+
+func formatDecision(d parser.Decision) string {
+	s := d.Kind
+	if d.Package != "" {
+		s += " package=" + d.Package
+	}
+	if d.Test != "" {
+		s += " test=" + d.Test
+	}
+	if d.Result != "" {
+		s += " result=" + d.Result
+	}
+	return s
+}
+
+// replayFile replays the decision log in filename, see replay.
+func replayFile(w io.Writer, filename string) (int, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	records, err := readRecords(f)
+	if err != nil {
+		return 0, err
+	}
+	return replay(w, records)
+}
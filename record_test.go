@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-junit-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "decisions.jsonl")
+	rw, err := newRecordWriter(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := "=== RUN   TestOne\n--- PASS: TestOne (0.01s)\nok  \tpkg\t0.02s\n"
+	if _, err := parser.ParseRecorded(strings.NewReader(in), "", rw.record); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	changed, err := replayFile(&out, filename)
+	if err != nil {
+		t.Fatalf("replayFile: %s", err)
+	}
+	if changed != 0 {
+		t.Errorf("replay of unchanged parser reported %d changes:\n%s", changed, out.String())
+	}
+
+	// simulate a parser that used to classify the status line differently
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := readRecords(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	records[1].Decisions[0].Kind = "output"
+
+	out.Reset()
+	if changed, err = replay(&out, records); err != nil {
+		t.Fatalf("replay: %s", err)
+	}
+	expected := "line 2: --- PASS: TestOne (0.01s)\n" +
+		"  - output test=TestOne result=PASS\n" +
+		"  + status test=TestOne result=PASS\n"
+	if changed != 1 || out.String() != expected {
+		t.Errorf("replay reported %d changes:\n%s\nwant:\n%s", changed, out.String(), expected)
+	}
+}
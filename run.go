@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// command is a test command started by go-junit-report, whose standard output
+// is parsed.
+type command struct {
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+	stderr bytes.Buffer
+
+	mu          sync.Mutex
+	interrupted bool
+}
+
+// startCommand starts the command described by args. Its standard error is
+// both copied to os.Stderr and captured. If cpuTime is set, args must be a go
+// test command, whose test binaries are run by go-junit-report to record
+// their resource usage, see wrapTestBinaries.
+func startCommand(args []string, cpuTime bool) (*command, error) {
+	var env []string
+	if cpuTime {
+		self, err := os.Executable()
+		if err != nil {
+			return nil, err
+		}
+		if args, err = wrapTestBinaries(args, self); err != nil {
+			return nil, err
+		}
+		if err := newUsageFile(); err != nil {
+			return nil, err
+		}
+		env = append(os.Environ(), usageEnv+"="+usageFile)
+	}
+
+	c := &command{cmd: exec.Command(args[0], args[1:]...)}
+	c.cmd.Stdin = os.Stdin
+	c.cmd.Stderr = io.MultiWriter(os.Stderr, &c.stderr)
+	c.cmd.Env = env
+
+	var err error
+	if c.stdout, err = c.cmd.StdoutPipe(); err != nil {
+		return nil, err
+	}
+	if err := c.cmd.Start(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// wait waits for the command to exit. It returns an error if the command
+// could not be run or did not exit successfully.
+func (c *command) wait() error {
+	c.mu.Lock()
+	interrupted := c.interrupted
+	c.mu.Unlock()
+	if interrupted {
+		// processes started by the command may keep its standard error open
+		// after the command exits, only wait for the command itself
+		_, err := c.cmd.Process.Wait()
+		return err
+	}
+
+	// drain any remaining output so the command doesn't block on a full pipe
+	io.Copy(ioutil.Discard, c.stdout)
+	return c.cmd.Wait()
+}
+
+// commandLine returns args as a command line for a POSIX shell, quoting the
+// arguments that need it.
+func commandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// regexShellSafe matches arguments that don't need to be quoted in a shell.
+var regexShellSafe = regexp.MustCompile(`^[A-Za-z0-9_./=:,@%+-]+$`)
+
+func shellQuote(arg string) string {
+	if regexShellSafe.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
+
+// stderrLines returns the captured standard error output as lines.
+func (c *command) stderrLines() []string {
+	s := strings.TrimRight(c.stderr.String(), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// interrupt forwards sig to the command and stops reading its output, which
+// may otherwise stay open in processes the command started.
+func (c *command) interrupt(sig os.Signal) {
+	c.mu.Lock()
+	c.interrupted = true
+	c.mu.Unlock()
+	c.cmd.Process.Signal(sig)
+	c.stdout.Close()
+}
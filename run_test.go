@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	cmd, err := startCommand([]string{"sh", "-c", "echo out; echo err1 >&2; echo err2 >&2"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(cmd.stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.wait(); err != nil {
+		t.Fatalf("wait() returned error: %s", err)
+	}
+
+	if string(out) != "out\n" {
+		t.Errorf("stdout == %q, want %q", out, "out\n")
+	}
+	if want := []string{"err1", "err2"}; !reflect.DeepEqual(cmd.stderrLines(), want) {
+		t.Errorf("stderrLines() == %q, want %q", cmd.stderrLines(), want)
+	}
+
+	if _, err := startCommand([]string{"make", "test"}, true); err == nil {
+		t.Errorf("startCommand() with cpuTime did not reject a command other than go test")
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	args := []string{"go", "test", "-run", "TestA|TestB", "-tags=a,b", "it's", "./..."}
+	want := `go test -run 'TestA|TestB' -tags=a,b 'it'\''s' ./...`
+	if got := commandLine(args); got != want {
+		t.Errorf("commandLine(%q) == %s, want %s", args, got, want)
+	}
+}
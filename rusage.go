@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// usageEnv is the environment variable that makes go-junit-report run as the
+// -exec program of go test, see wrapTestBinaries. Its value is the file the
+// resource usage of each test binary is appended to.
+const usageEnv = "GO_JUNIT_REPORT_USAGE_FILE"
+
+// usageFile is the file the resource usage of the test binaries run by the
+// test command is written to with -cpu-time, if any.
+var usageFile string
+
+// binaryUsage is the wall clock and CPU time of a test binary in seconds. Go
+// test runs test binaries in the directory of their package.
+type binaryUsage struct {
+	Dir    string  `json:"dir"`
+	Wall   float64 `json:"wall"`
+	User   float64 `json:"user"`
+	System float64 `json:"system"`
+}
+
+// wrapTestBinaries returns the arguments of the go test command args with
+// self, the go-junit-report executable, as -exec program, which runs the test
+// binaries and records their resource usage. An -exec program given in args
+// is run by self. It returns an error if args is not a go test command.
+func wrapTestBinaries(args []string, self string) ([]string, error) {
+	if len(args) < 2 || strings.TrimSuffix(filepath.Base(args[0]), ".exe") != "go" || args[1] != "test" {
+		return nil, fmt.Errorf("-cpu-time requires a go test command")
+	}
+
+	wrapped := append([]string{}, args[:2]...)
+	execArg := quoteExecArg(self)
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-args" || arg == "--args" {
+			wrapped = append(wrapped, args[i:]...)
+			break
+		}
+		switch {
+		case arg == "-exec" || arg == "--exec":
+			if i+1 < len(args) {
+				i++
+				execArg += " " + args[i]
+			}
+			continue
+		case strings.HasPrefix(arg, "-exec=") || strings.HasPrefix(arg, "--exec="):
+			execArg += " " + arg[strings.Index(arg, "=")+1:]
+			continue
+		}
+		wrapped = append(wrapped, arg)
+	}
+	return append(wrapped[:2], append([]string{"-exec", execArg}, wrapped[2:]...)...), nil
+}
+
+// quoteExecArg quotes arg for the -exec flag of go test, which is split into
+// words at spaces outside of single or double quotes.
+func quoteExecArg(arg string) string {
+	if !strings.ContainsAny(arg, " \t\n'\"") {
+		return arg
+	}
+	if strings.Contains(arg, "'") {
+		return `"` + arg + `"`
+	}
+	return "'" + arg + "'"
+}
+
+// runTestBinary runs the test binary, or the -exec program running it, given
+// by args like go test does, appends its resource usage to file and returns
+// its exit code. Signals sent by go test, e.g. on timeout, are forwarded.
+func runTestBinary(file string, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "%s is set, but no test binary was given\n", usageEnv)
+		return 1
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, usageEnv+"=") {
+			cmd.Env = append(cmd.Env, env)
+		}
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT)
+	defer signal.Stop(sigs)
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error running %s: %s\n", args[0], err)
+		return 1
+	}
+	go func() {
+		for sig := range sigs {
+			cmd.Process.Signal(sig)
+		}
+	}()
+	err := cmd.Wait()
+	wall := time.Since(start)
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			fmt.Fprintf(os.Stderr, "Error running %s: %s\n", args[0], err)
+			return 1
+		}
+	}
+
+	dir, _ := os.Getwd()
+	usage := binaryUsage{
+		Dir:    dir,
+		Wall:   wall.Seconds(),
+		User:   cmd.ProcessState.UserTime().Seconds(),
+		System: cmd.ProcessState.SystemTime().Seconds(),
+	}
+	if err := appendUsage(file, usage); err != nil {
+		fmt.Fprintf(os.Stderr, "Error recording the CPU time of %s: %s\n", args[0], err)
+	}
+
+	if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.ExitStatus() >= 0 {
+		return status.ExitStatus()
+	}
+	if cmd.ProcessState.Success() {
+		return 0
+	}
+	return 1
+}
+
+// appendUsage appends usage as a JSON line to file. Test binaries of different
+// packages run in parallel, every line is written with a single write.
+func appendUsage(file string, usage binaryUsage) error {
+	line, err := json.Marshal(usage)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readUsage reads the resource usage of the test binaries written to file.
+func readUsage(file string) ([]binaryUsage, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var usages []binaryUsage
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var usage binaryUsage
+		if err := json.Unmarshal(s.Bytes(), &usage); err != nil {
+			return nil, err
+		}
+		usages = append(usages, usage)
+	}
+	return usages, s.Err()
+}
+
+// newUsageFile creates an empty usageFile.
+func newUsageFile() error {
+	f, err := ioutil.TempFile("", "go-junit-report-usage")
+	if err != nil {
+		return err
+	}
+	usageFile = f.Name()
+	return f.Close()
+}
+
+// addUsageProperties adds the wall clock and CPU time of the test binary of
+// each package of report, as recorded in usageFile, as testsuite properties.
+// The times of packages whose test binary ran more than once are added up,
+// packages with cached results have no times.
+func addUsageProperties(report *parser.Report) error {
+	usages, err := readUsage(usageFile)
+	if err != nil || len(usages) == 0 {
+		return err
+	}
+
+	var names []string
+	for _, pkg := range report.Packages {
+		if pkg.Name != "" {
+			names = append(names, pkg.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	listed, err := goList("", names...)
+	if err != nil {
+		return err
+	}
+	setUsageProperties(report, usages, listed)
+	return nil
+}
+
+// setUsageProperties adds the times of usages to the packages of report whose
+// directory, as listed, they were recorded in.
+func setUsageProperties(report *parser.Report, usages []binaryUsage, listed map[string]*goPackage) {
+	byDir := map[string]binaryUsage{}
+	for _, usage := range usages {
+		sum := byDir[usage.Dir]
+		sum.Wall += usage.Wall
+		sum.User += usage.User
+		sum.System += usage.System
+		byDir[usage.Dir] = sum
+	}
+
+	for i := range report.Packages {
+		pkg := &report.Packages[i]
+		gopkg := listed[pkg.Name]
+		if gopkg == nil || gopkg.Dir == "" {
+			continue
+		}
+		usage, ok := byDir[gopkg.Dir]
+		if !ok {
+			continue
+		}
+		pkg.Properties = append(pkg.Properties,
+			parser.Property{Name: "run.wall.seconds", Value: strconv.FormatFloat(usage.Wall, 'f', 3, 64)},
+			parser.Property{Name: "run.cpu.user.seconds", Value: strconv.FormatFloat(usage.User, 'f', 3, 64)},
+			parser.Property{Name: "run.cpu.system.seconds", Value: strconv.FormatFloat(usage.System, 'f', 3, 64)},
+		)
+	}
+}
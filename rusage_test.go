@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestWrapTestBinaries(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			[]string{"go", "test", "-v", "./..."},
+			[]string{"go", "test", "-exec", "/bin/gjr", "-v", "./..."},
+		},
+		{
+			[]string{"go", "test", "-exec", "qemu-arm -L /usr", "./...", "-args", "-exec=x"},
+			[]string{"go", "test", "-exec", "/bin/gjr qemu-arm -L /usr", "./...", "-args", "-exec=x"},
+		},
+		{
+			[]string{"/usr/local/go/bin/go", "test", "--exec=sudo", "."},
+			[]string{"/usr/local/go/bin/go", "test", "-exec", "/bin/gjr sudo", "."},
+		},
+	}
+	for _, test := range tests {
+		got, err := wrapTestBinaries(test.args, "/bin/gjr")
+		if err != nil {
+			t.Errorf("wrapTestBinaries(%q) returned error: %s", test.args, err)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("wrapTestBinaries(%q) == %q, want %q", test.args, got, test.want)
+		}
+	}
+
+	if _, err := wrapTestBinaries([]string{"go", "vet", "./..."}, "/bin/gjr"); err == nil {
+		t.Errorf("wrapTestBinaries() did not reject go vet")
+	}
+	if got := quoteExecArg("/my dir/gjr"); got != "'/my dir/gjr'" {
+		t.Errorf("quoteExecArg() == %s, want '/my dir/gjr'", got)
+	}
+}
+
+func TestRunTestBinary(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir, err := ioutil.TempDir("", "usage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "usage.jsonl")
+	if code := runTestBinary(file, []string{"sh", "-c", "exit 0"}); code != 0 {
+		t.Errorf("runTestBinary() of a passing binary == %d, want 0", code)
+	}
+	if code := runTestBinary(file, []string{"sh", "-c", "exit 3"}); code != 3 {
+		t.Errorf("runTestBinary() of a failing binary == %d, want 3", code)
+	}
+
+	usages, err := readUsage(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	if len(usages) != 2 || usages[0].Dir != wd || usages[1].Dir != wd {
+		t.Errorf("recorded usage == %+v, want 2 runs in %s", usages, wd)
+	}
+}
+
+func TestSetUsageProperties(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{
+		{Name: "example.com/a"},
+		{Name: "example.com/cached"},
+	}}
+	listed := map[string]*goPackage{
+		"example.com/a":      {Dir: "/src/a"},
+		"example.com/cached": {Dir: "/src/cached"},
+	}
+	usages := []binaryUsage{
+		{Dir: "/src/a", Wall: 1.5, User: 0.25, System: 0.125},
+		{Dir: "/src/a", Wall: 0.5, User: 0.25, System: 0},
+		{Dir: "/src/other", Wall: 1},
+	}
+	setUsageProperties(report, usages, listed)
+
+	want := []parser.Property{
+		{Name: "run.wall.seconds", Value: "2.000"},
+		{Name: "run.cpu.user.seconds", Value: "0.500"},
+		{Name: "run.cpu.system.seconds", Value: "0.125"},
+	}
+	if got := report.Packages[0].Properties; !reflect.DeepEqual(got, want) {
+		t.Errorf("properties == %v, want %v", got, want)
+	}
+	if got := report.Packages[1].Properties; len(got) != 0 {
+		t.Errorf("properties of a package that didn't run == %v, want none", got)
+	}
+}
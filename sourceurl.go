@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// sourceGit runs git for expandSourceURL, it's replaced in tests.
+var sourceGit = gitOutput
+
+// expandSourceURL replaces {sha} in the -source-url template by the commit
+// set with -git-sha or checked out in the current directory, and prefixes
+// {file} with the directory of the module in the git repository. The module is
+// the one of the go.mod file modfile or, if that's empty, of the closest
+// go.mod file of the current directory. The {file} and {line} placeholders
+// are replaced by the formatter, with the file relative to the module.
+func expandSourceURL(template, modfile string) (string, error) {
+	if template == "" {
+		return "", nil
+	}
+	if !strings.Contains(template, "{file}") {
+		return "", errors.New("the template has no {file} placeholder")
+	}
+	if strings.Contains(template, "{sha}") {
+		sha := *gitSHA
+		if sha == "" {
+			sha = sourceGit("", "rev-parse", "HEAD")
+		}
+		if sha == "" {
+			return "", errors.New("the commit for {sha} is unknown, the current directory is not in a git repository")
+		}
+		template = strings.Replace(template, "{sha}", sha, -1)
+	}
+
+	if modfile == "" {
+		var err error
+		if modfile, err = findModFile("."); err != nil {
+			return "", err
+		}
+	}
+	dir := "."
+	if modfile != "" {
+		dir = filepath.Dir(modfile)
+	}
+	// the directory relative to the repository root, with a trailing slash
+	if prefix := sourceGit(dir, "rev-parse", "--show-prefix"); prefix != "" {
+		template = strings.Replace(template, "{file}", prefix+"{file}", -1)
+	}
+	return template, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandSourceURL(t *testing.T) {
+	defer func(git func(string, ...string) string) { sourceGit = git }(sourceGit)
+	var dirs []string
+	sourceGit = func(dir string, args ...string) string {
+		switch strings.Join(args, " ") {
+		case "rev-parse HEAD":
+			return "abc123"
+		case "rev-parse --show-prefix":
+			dirs = append(dirs, dir)
+			return "src/api/"
+		}
+		return ""
+	}
+
+	if _, err := expandSourceURL("https://example.com/blob/main/#L{line}", ""); err == nil {
+		t.Errorf("expandSourceURL() without {file} returned no error")
+	}
+
+	url, err := expandSourceURL("https://example.com/blob/{sha}/{file}#L{line}", "src/api/go.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://example.com/blob/abc123/src/api/{file}#L{line}"; url != want {
+		t.Errorf("expandSourceURL() == %q, want %q", url, want)
+	}
+	if len(dirs) != 1 || dirs[0] != "src/api" {
+		t.Errorf("module directory looked up in %q, want src/api", dirs)
+	}
+
+	sourceGit = func(dir string, args ...string) string { return "" }
+	if _, err := expandSourceURL("https://example.com/blob/{sha}/{file}", "go.mod"); err == nil {
+		t.Errorf("expandSourceURL() outside a git repository returned no error for {sha}")
+	}
+	if url, err := expandSourceURL("https://example.com/blob/main/{file}", "go.mod"); err != nil || url != "https://example.com/blob/main/{file}" {
+		t.Errorf("expandSourceURL() outside a git repository == %q, %v", url, err)
+	}
+}
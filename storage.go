@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// gcsEndpoint is the XML API endpoint of Google Cloud Storage.
+var gcsEndpoint = "https://storage.googleapis.com"
+
+// objectUpload describes where and how report files are stored by -upload.
+type objectUpload struct {
+	scheme string // s3 or gs
+	bucket string
+	prefix string
+	// retainUntil, if not zero, is added as retain-until metadata
+	retainUntil time.Time
+	retries     int
+}
+
+// parseObjectURL parses an object storage URL of the form s3://bucket/prefix/
+// or gs://bucket/prefix/.
+func parseObjectURL(rawurl string) (objectUpload, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return objectUpload{}, err
+	}
+	if u.Scheme != "s3" && u.Scheme != "gs" {
+		return objectUpload{}, fmt.Errorf("unsupported URL %q, use s3://bucket/prefix/ or gs://bucket/prefix/", rawurl)
+	}
+	if u.Host == "" {
+		return objectUpload{}, fmt.Errorf("no bucket in URL %q", rawurl)
+	}
+	return objectUpload{scheme: u.Scheme, bucket: u.Host, prefix: strings.TrimPrefix(u.Path, "/")}, nil
+}
+
+// uploadObjects stores the written output files and the manifest, if any, at
+// the object storage URLs of -upload.
+func uploadObjects(urls []string) error {
+	files := outputFiles
+	if *manifestFile != "" {
+		files = append(files[:len(files):len(files)], *manifestFile)
+		if *manifestKey != "" {
+			files = append(files, *manifestFile+".sig")
+		}
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no output files to upload, use -out")
+	}
+	for _, rawurl := range urls {
+		o, err := parseObjectURL(rawurl)
+		if err != nil {
+			return err
+		}
+		o.retries = *uploadRetries
+		if *uploadRetention > 0 {
+			o.retainUntil = time.Now().Add(*uploadRetention)
+		}
+		if err := o.uploadFiles(files); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadFiles stores files as objects named after their path relative to
+// the current directory, or their base name for files outside of it, below
+// the prefix of the upload, which doesn't need a trailing slash.
+func (o objectUpload) uploadFiles(files []string) error {
+	for _, file := range files {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := o.put(path.Join(o.prefix, objectName(file)), body, time.Now()); err != nil {
+			return fmt.Errorf("uploading %s: %s", file, err)
+		}
+	}
+	return nil
+}
+
+// put stores body as the object key, signed for time t.
+func (o objectUpload) put(key string, body []byte, t time.Time) error {
+	headers := map[string]string{}
+	metaPrefix := "x-goog-meta-"
+	if o.scheme == "s3" {
+		metaPrefix = "x-amz-meta-"
+	}
+	if !o.retainUntil.IsZero() {
+		headers[metaPrefix+"retain-until"] = o.retainUntil.UTC().Format(time.RFC3339)
+	}
+	sum := md5.Sum(body)
+	headers["Content-MD5"] = base64.StdEncoding.EncodeToString(sum[:])
+	contentType := objectContentType(key)
+
+	var target string
+	if o.scheme == "gs" {
+		target = gcsEndpoint + "/" + o.bucket + "/" + s3Escape(key)
+		token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+		if token == "" {
+			return fmt.Errorf("no access token in GOOGLE_OAUTH_ACCESS_TOKEN, e.g. from gcloud auth print-access-token")
+		}
+		headers["Authorization"] = "Bearer " + token
+	} else {
+		creds := awsCredentialsFromEnv()
+		if creds.accessKey == "" || creds.secretKey == "" {
+			return fmt.Errorf("no credentials in AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		u, err := url.Parse(s3ObjectURL(o.bucket, key, creds.region))
+		if err != nil {
+			return err
+		}
+		target = u.String()
+		headers["Content-Type"] = contentType
+		signS3("PUT", u, headers, sha256Hex(body), creds, t)
+	}
+	return sendWithRetries("PUT", target, contentType, headers, body, o.retries)
+}
+
+// objectName returns the slash separated path of file relative to the
+// current directory, or its base name if it's not below it.
+func objectName(file string) string {
+	if rel := manifestPath("x", file); !filepath.IsAbs(rel) && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.Base(file)
+}
+
+// objectContentType returns the content type of the object key based on its
+// extension. Compressed reports are stored as gzip files.
+func objectContentType(key string) string {
+	if ext := path.Ext(key); ext == ".gz" {
+		return "application/gzip"
+	} else if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// awsCredentials are the credentials used to sign S3 requests.
+type awsCredentials struct {
+	accessKey, secretKey, sessionToken string
+	region                             string
+}
+
+// awsCredentialsFromEnv returns the credentials in the environment variables
+// used by the AWS CLI. The region defaults to us-east-1.
+func awsCredentialsFromEnv() awsCredentials {
+	creds := awsCredentials{
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		region:       os.Getenv("AWS_REGION"),
+	}
+	if creds.region == "" {
+		creds.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if creds.region == "" {
+		creds.region = "us-east-1"
+	}
+	return creds
+}
+
+// s3ObjectURL returns the URL of the object key in bucket. A custom endpoint,
+// e.g. of MinIO, can be set in $AWS_ENDPOINT_URL_S3 or $AWS_ENDPOINT_URL and is
+// addressed path-style.
+func s3ObjectURL(bucket, key, region string) string {
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_S3")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	if endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + s3Escape(key)
+	}
+	return "https://" + bucket + ".s3." + region + ".amazonaws.com/" + s3Escape(key)
+}
+
+// signS3 adds the headers of an AWS Signature Version 4 to the headers of a
+// request to u made at time t, given the hex encoded SHA-256 hash of its
+// payload. All headers and the host are signed.
+func signS3(method string, u *url.URL, headers map[string]string, payloadHash string, creds awsCredentials, t time.Time) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	headers["x-amz-date"] = amzDate
+	headers["x-amz-content-sha256"] = payloadHash
+	if creds.sessionToken != "" {
+		headers["x-amz-security-token"] = creds.sessionToken
+	}
+
+	canonical := map[string]string{"host": u.Host}
+	for name, value := range headers {
+		canonical[strings.ToLower(name)] = strings.TrimSpace(value)
+	}
+	var names []string
+	for name := range canonical {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + canonical[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	uri := u.EscapedPath()
+	if uri == "" {
+		uri = "/"
+	}
+	request := strings.Join([]string{method, uri, u.Query().Encode(), canonicalHeaders.String(), signedHeaders, payloadHash}, "\n")
+	scope := date + "/" + creds.region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(request))
+
+	key := hmacSHA256([]byte("AWS4"+creds.secretKey), date)
+	for _, part := range []string{creds.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	headers["Authorization"] = "AWS4-HMAC-SHA256 Credential=" + creds.accessKey + "/" + scope +
+		",SignedHeaders=" + signedHeaders + ",Signature=" + signature
+}
+
+// s3Escape escapes the object key for use in a URL path like the AWS
+// signature requires, keeping slashes.
+func s3Escape(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// summaryOptions control the contents of the console summary.
+type summaryOptions struct {
+	// maxSkipped is the maximum number of skipped tests that are listed, no
+	// skipped tests are listed if it's zero.
+	maxSkipped int
+	// clusters adds a section grouping failures with the same fingerprint.
+	clusters bool
+	// slowest is the number of slowest packages and tests that are listed.
+	slowest int
+}
+
+// maxClusterExamples is the number of tests listed for each failure cluster.
+const maxClusterExamples = 3
+
+var regexLogPrefix = regexp.MustCompile(`^\S+\.go:\d+: `)
+
+// writeSummary writes a short human readable summary of report to w.
+func writeSummary(w io.Writer, report *parser.Report, opts summaryOptions) {
+	tests, failures, errors, skippedCount := report.Counts()
+	fmt.Fprintf(w, "%d packages, %d tests, %d failures, %d errors, %d skipped\n",
+		len(report.Packages), tests, failures, errors, skippedCount)
+
+	var skipped []string
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.Tests {
+			if test.Result == parser.SKIP {
+				skipped = append(skipped, pkg.Name+" "+test.Name+skipReason(test))
+			}
+		}
+	}
+
+	if opts.maxSkipped > 0 && len(skipped) > 0 {
+		fmt.Fprintf(w, "Skipped tests:\n")
+		for i, s := range skipped {
+			if i == opts.maxSkipped {
+				fmt.Fprintf(w, "  ... and %d more\n", len(skipped)-i)
+				break
+			}
+			fmt.Fprintf(w, "  %s\n", s)
+		}
+	}
+
+	if opts.slowest > 0 && len(report.Packages) > 0 {
+		fmt.Fprintf(w, "Slowest packages:\n")
+		for _, pkg := range formatter.SlowestPackages(report, opts.slowest) {
+			fmt.Fprintf(w, "  %8.3fs %s\n", pkg.Duration.Seconds(), pkg.Name)
+		}
+		if tests > 0 {
+			fmt.Fprintf(w, "Slowest tests:\n")
+			for _, t := range formatter.SlowestTests(report, opts.slowest) {
+				fmt.Fprintf(w, "  %8.3fs %s %s\n", t.Test.Duration.Seconds(), t.Package, t.Test.Name)
+			}
+		}
+	}
+
+	if opts.clusters && failures+errors > 0 {
+		fmt.Fprintf(w, "Failure clusters:\n")
+		for _, c := range clusterFailures(report) {
+			fmt.Fprintf(w, "  [%d] %s\n", len(c.tests), c.fingerprint)
+			for i, test := range c.tests {
+				if i == maxClusterExamples {
+					fmt.Fprintf(w, "      ... and %d more\n", len(c.tests)-i)
+					break
+				}
+				fmt.Fprintf(w, "      %s\n", test)
+			}
+		}
+	}
+}
+
+// skipReason returns the reason a test was skipped, prefixed by ": ", or an
+// empty string if the reason is unknown. The reason is the last line logged by
+// the test, which is the message given to t.Skip.
+func skipReason(test *parser.Test) string {
+	for i := len(test.Output) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(test.Output[i])
+		if line != "" {
+			return ": " + regexLogPrefix.ReplaceAllString(line, "")
+		}
+	}
+	return ""
+}
+
+// writeStats writes a single line with the test counts of report, the total
+// test time of all packages and the wall clock time of the run to w.
+func writeStats(w io.Writer, report *parser.Report, wall time.Duration) {
+	tests, failures, errors, skipped := report.Counts()
+	fmt.Fprintf(w, "%d packages, %d tests, %d failures, %d errors, %d skipped, %.3fs test time, %.3fs wall time\n",
+		len(report.Packages), tests, failures, errors, skipped, report.TotalDuration().Seconds(), wall.Seconds())
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestWriteSummary(t *testing.T) {
+	report := &parser.Report{
+		Packages: []parser.Package{
+			{
+				Name: "package/name",
+				Tests: []*parser.Test{
+					{Name: "TestOne", Result: parser.PASS},
+					{Name: "TestTwo", Result: parser.SKIP, Output: []string{"two_test.go:10: not on this platform"}},
+					{Name: "TestThree", Result: parser.SKIP},
+					{Name: "TestFour", Result: parser.SKIP},
+					{Name: "TestFive", Result: parser.FAIL},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	writeSummary(&buf, report, summaryOptions{maxSkipped: 2})
+
+	want := `1 packages, 5 tests, 1 failures, 0 errors, 3 skipped
+Skipped tests:
+  package/name TestTwo: not on this platform
+  package/name TestThree
+  ... and 1 more
+`
+	if buf.String() != want {
+		t.Errorf("writeSummary() output\nEXP:\n%s\nGOT:\n%s", want, buf.String())
+	}
+}
+
+func TestWriteSummarySlowest(t *testing.T) {
+	report := &parser.Report{
+		Packages: []parser.Package{
+			{Name: "a", Duration: time.Second, Tests: []*parser.Test{{Name: "TestA", Duration: 250 * time.Millisecond}}},
+			{Name: "b", Duration: 12 * time.Second, Tests: []*parser.Test{{Name: "TestB", Duration: 11 * time.Second}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	writeSummary(&buf, report, summaryOptions{slowest: 1})
+
+	want := `2 packages, 2 tests, 0 failures, 0 errors, 0 skipped
+Slowest packages:
+    12.000s b
+Slowest tests:
+    11.000s b TestB
+`
+	if buf.String() != want {
+		t.Errorf("writeSummary() output\nEXP:\n%s\nGOT:\n%s", want, buf.String())
+	}
+}
+
+func TestWriteStats(t *testing.T) {
+	report := &parser.Report{
+		Packages: []parser.Package{
+			{Name: "a", Duration: time.Second, Tests: []*parser.Test{{Result: parser.PASS}, {Result: parser.FAIL}}},
+			{Name: "b", Duration: 500 * time.Millisecond, Tests: []*parser.Test{{Result: parser.ERROR}, {Result: parser.SKIP}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	writeStats(&buf, report, 2*time.Second)
+
+	want := "2 packages, 4 tests, 1 failures, 1 errors, 1 skipped, 1.500s test time, 2.000s wall time\n"
+	if buf.String() != want {
+		t.Errorf("writeStats() output == %q, want %q", buf.String(), want)
+	}
+}
+
+func TestClusterFailures(t *testing.T) {
+	fail := func(name string, output ...string) *parser.Test {
+		return &parser.Test{Name: name, Result: parser.FAIL, Output: output}
+	}
+	report := &parser.Report{
+		Packages: []parser.Package{
+			{
+				Name: "a",
+				Tests: []*parser.Test{
+					fail("TestOne", "a_test.go:10: dial tcp 10.0.0.1:5432: connection refused"),
+					fail("TestTwo", "b_test.go:99: unexpected value 3"),
+					{Name: "TestThree", Result: parser.PASS},
+				},
+			},
+			{
+				Name: "b",
+				Tests: []*parser.Test{
+					fail("TestFour", "", "c_test.go:1: dial tcp 10.0.0.2:5432: connection refused"),
+					{Name: "Error", Result: parser.ERROR},
+				},
+			},
+		},
+	}
+
+	clusters := clusterFailures(report)
+	var got []string
+	for _, c := range clusters {
+		got = append(got, fmt.Sprintf("%s: %v", c.fingerprint, c.tests))
+	}
+	want := []string{
+		"dial tcp N.N.N.N:N: connection refused: [a TestOne b TestFour]",
+		"unexpected value N: [a TestTwo]",
+		"(no output): [b Error]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("clusterFailures() == %q, want %q", got, want)
+	}
+}
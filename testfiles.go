@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// addTestFiles sets the file of every test in report to the test file
+// declaring its top-level test, as found with `go list` and the Go parser.
+// Tests in packages that can't be found are left unchanged.
+func addTestFiles(report *parser.Report) error {
+	var names []string
+	for _, pkg := range report.Packages {
+		if pkg.Name != "" {
+			names = append(names, pkg.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	listed, err := goList("", names...)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range report.Packages {
+		files := testFuncFiles(listed[pkg.Name])
+		for _, test := range pkg.Tests {
+			if file, ok := files[topLevelName(test.Name)]; ok {
+				test.File = file
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestAddTestFiles(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{{
+		Name: "github.com/hexon/go-junit-report/formatter",
+		Tests: []*parser.Test{
+			{Name: "TestTestFile/sub"},
+			{Name: "TestDoesNotExist"},
+		},
+	}}}
+	if err := addTestFiles(report); err != nil {
+		t.Fatal(err)
+	}
+	tests := report.Packages[0].Tests
+	if tests[0].File != "formatter/formatter_test.go" || tests[1].File != "" {
+		t.Errorf("addTestFiles() set files %q and %q, want formatter/formatter_test.go and none", tests[0].File, tests[1].File)
+	}
+}
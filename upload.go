@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// headerFlag is a repeatable flag of HTTP headers.
+type headerFlag map[string]string
+
+func (h headerFlag) String() string {
+	var headers []string
+	for name, value := range h {
+		headers = append(headers, name+": "+value)
+	}
+	sort.Strings(headers)
+	return strings.Join(headers, ",")
+}
+
+func (h headerFlag) Set(value string) error {
+	idx := strings.Index(value, ":")
+	if idx < 1 {
+		return errors.New("header must be of the form name:value")
+	}
+	h[strings.TrimSpace(value[:idx])] = strings.TrimSpace(value[idx+1:])
+	return nil
+}
+
+// uploadContentTypes are the content types of the formats that aren't plain
+// text.
+var uploadContentTypes = map[string]string{
+	"junit":      "application/xml",
+	"json":       "application/json",
+	"buildkite":  "application/json",
+	"otlp":       "application/json",
+	"prometheus": "text/plain; version=0.0.4",
+}
+
+// uploadReport posts report, written by f in format, to url with headers. The
+// token, if not empty, is sent as bearer token. Failed requests are retried
+// as by sendWithRetries.
+func uploadReport(f formatter.Formatter, format string, report *parser.Report, url string, headers map[string]string, token string, retries int) error {
+	var body bytes.Buffer
+	if err := f.Write(report, &body); err != nil {
+		return err
+	}
+
+	contentType, ok := uploadContentTypes[format]
+	if !ok {
+		contentType = "text/plain; charset=utf-8"
+	}
+	all := map[string]string{}
+	for name, value := range headers {
+		all[name] = value
+	}
+	if token != "" {
+		all["Authorization"] = "Bearer " + token
+	}
+	return sendWithRetries("POST", url, contentType, all, body.Bytes(), retries)
+}
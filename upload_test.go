@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestUploadReport(t *testing.T) {
+	defer func(d time.Duration) { retryDelay = d }(retryDelay)
+	retryDelay = 0
+
+	var requests int
+	var auth, team, contentType, body string
+	statuses := []int{http.StatusServiceUnavailable, http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, team, contentType = r.Header.Get("Authorization"), r.Header.Get("X-Team"), r.Header.Get("Content-Type")
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(statuses[requests])
+		requests++
+	}))
+	defer server.Close()
+
+	report := &parser.Report{Packages: []parser.Package{{Name: "a", Tests: []*parser.Test{{Name: "TestA", Result: parser.PASS}}}}}
+	f, err := formatter.New("junit", formatter.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := headerFlag{}
+	if err := headers.Set("X-Team: backend"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := uploadReport(f, "junit", report, server.URL, headers, "secret", 3); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("sent %d requests, want 2", requests)
+	}
+	if auth != "Bearer secret" || team != "backend" || contentType != "application/xml" {
+		t.Errorf("headers == %q, %q, %q, want the token, the X-Team header and application/xml", auth, team, contentType)
+	}
+	if !strings.Contains(body, `<testcase classname="a" name="TestA"`) {
+		t.Errorf("uploaded report does not contain TestA:\n%s", body)
+	}
+
+	requests = 0
+	statuses = []int{http.StatusBadRequest, http.StatusOK}
+	if err := uploadReport(f, "junit", report, server.URL, nil, "", 3); err == nil || requests != 1 {
+		t.Errorf("uploadReport() == %v after %d requests, want the 400 error without retries", err, requests)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+// moduleProperty is the testsuite property with the module of a package.
+const moduleProperty = "go.module"
+
+// reportModules returns the module paths packages are grouped by: prefixes
+// if not empty, otherwise the modules used by the go.work file of the current
+// directory or, without one, the module of the current directory.
+func reportModules(prefixes []string) ([]string, error) {
+	if len(prefixes) > 0 {
+		return prefixes, nil
+	}
+	modules, err := findWorkModules(".")
+	if err != nil || len(modules) > 0 {
+		return modules, err
+	}
+	module, err := findModulePath(".")
+	if err != nil || module == "" {
+		return nil, err
+	}
+	return []string{module}, nil
+}
+
+// findWorkModules returns the paths of the modules used by the go.work file in
+// dir or the closest parent directory that has one. It returns nil if there is
+// no go.work file.
+func findWorkModules(dir string) ([]string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		f, err := os.Open(filepath.Join(dir, "go.work"))
+		if err == nil {
+			defer f.Close()
+			return workModules(dir, f)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// workModules returns the paths of the modules in the use directives of the
+// go.work file in dir read from r.
+func workModules(dir string, r io.Reader) ([]string, error) {
+	var modules []string
+	inBlock := false
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		var use string
+		switch {
+		case inBlock && len(fields) == 1 && fields[0] == ")":
+			inBlock = false
+		case inBlock && len(fields) == 1:
+			use = fields[0]
+		case len(fields) == 2 && fields[0] == "use" && fields[1] == "(":
+			inBlock = true
+		case len(fields) == 2 && fields[0] == "use":
+			use = fields[1]
+		}
+		if use == "" {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(use); err == nil {
+			use = unquoted
+		}
+		if !filepath.IsAbs(use) {
+			use = filepath.Join(dir, use)
+		}
+		module, err := findModulePath(use)
+		if err != nil {
+			return nil, err
+		}
+		if module != "" {
+			modules = append(modules, module)
+		}
+	}
+	return modules, s.Err()
+}
+
+// packageModule returns the longest of modules that is the import path of
+// pkgName or a prefix of it, or an empty string if there is none.
+func packageModule(pkgName string, modules []string) string {
+	var module string
+	for _, m := range modules {
+		m = strings.TrimSuffix(m, "/")
+		if (pkgName == m || strings.HasPrefix(pkgName, m+"/")) && len(m) > len(module) {
+			module = m
+		}
+	}
+	return module
+}
+
+// addModules adds the module of each package in report as go.module property.
+// With group set, the packages of a module are moved next to each other, in
+// the order the modules first appear in report.
+func addModules(report *parser.Report, modules []string, group bool) {
+	order := map[string]int{}
+	for i := range report.Packages {
+		pkg := &report.Packages[i]
+		module := packageModule(pkg.Name, modules)
+		if module != "" {
+			pkg.Properties = append(pkg.Properties, parser.Property{Name: moduleProperty, Value: module})
+		}
+		if _, ok := order[module]; !ok {
+			order[module] = len(order)
+		}
+	}
+	if group {
+		sort.SliceStable(report.Packages, func(i, j int) bool {
+			return order[reportedModule(report.Packages[i])] < order[reportedModule(report.Packages[j])]
+		})
+	}
+}
+
+// reportedModule returns the module added to pkg by addModules.
+func reportedModule(pkg parser.Package) string {
+	for _, prop := range pkg.Properties {
+		if prop.Name == moduleProperty {
+			return prop.Value
+		}
+	}
+	return ""
+}
+
+// writeModuleDir writes a separate report for each module to dir, named
+// TEST-<module> with the extension of format, with the packages that addModules
+// added the module to. Packages outside of the modules are written to
+// TEST-unknown.
+func writeModuleDir(f formatter.Formatter, format string, report *parser.Report, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var modules []string
+	byModule := map[string]*parser.Report{}
+	for _, pkg := range report.Packages {
+		module := reportedModule(pkg)
+		single, ok := byModule[module]
+		if !ok {
+			single = &parser.Report{Stderr: report.Stderr}
+			byModule[module] = single
+			modules = append(modules, module)
+		}
+		single.Packages = append(single.Packages, pkg)
+	}
+	for _, module := range modules {
+		name := filepath.Join(dir, "TEST-"+packageFileName(module)+formatExtension(format))
+		if err := writeReport(f, byModule[module], name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hexon/go-junit-report/formatter"
+	"github.com/hexon/go-junit-report/parser"
+)
+
+func TestFindWorkModules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"go.work":       "go 1.18\n\nuse (\n\t./a // the a module\n\t\"./b\"\n)\n\nuse ./c\n",
+		"a/go.mod":      "module example.com/a\n",
+		"b/go.mod":      "module example.com/b\n",
+		"c/go.mod":      "module example.com/a/c\n",
+		"c/sub/.keep":   "",
+		"unused/go.mod": "module example.com/unused\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	modules, err := findWorkModules(filepath.Join(dir, "c", "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"example.com/a", "example.com/b", "example.com/a/c"}
+	if !reflect.DeepEqual(modules, want) {
+		t.Errorf("findWorkModules() == %v, want %v", modules, want)
+	}
+}
+
+func TestAddModules(t *testing.T) {
+	report := &parser.Report{Packages: []parser.Package{
+		{Name: "example.com/a"},
+		{Name: "example.com/a/c/x"},
+		{Name: "example.com/b/y"},
+		{Name: "example.com/a/z"},
+		{Name: "other"},
+	}}
+	addModules(report, []string{"example.com/a", "example.com/b", "example.com/a/c"}, true)
+
+	var got [][2]string
+	for _, pkg := range report.Packages {
+		got = append(got, [2]string{pkg.Name, reportedModule(pkg)})
+	}
+	want := [][2]string{
+		{"example.com/a", "example.com/a"},
+		{"example.com/a/z", "example.com/a"},
+		{"example.com/a/c/x", "example.com/a/c"},
+		{"example.com/b/y", "example.com/b"},
+		{"other", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("packages and modules == %v, want %v", got, want)
+	}
+}
+
+func TestWriteModuleDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "moduledir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	report := &parser.Report{Packages: []parser.Package{{Name: "example.com/a/x"}, {Name: "example.com/b"}, {Name: "example.com/a/y"}, {Name: "other"}}}
+	addModules(report, []string{"example.com/a", "example.com/b"}, false)
+	f, err := formatter.New("junit", formatter.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeModuleDir(f, "junit", report, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	sort.Strings(files)
+	want := []string{"TEST-example.com.a.xml", "TEST-example.com.b.xml", "TEST-unknown.xml"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("written files == %v, want %v", files, want)
+	}
+
+	merged, err := readReport(filepath.Join(dir, "TEST-example.com.a.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged.Packages) != 2 {
+		t.Errorf("TEST-example.com.a.xml has %d testsuites, want 2", len(merged.Packages))
+	}
+}